@@ -0,0 +1,64 @@
+// Runs the fake Youtube Data API on its own, so the service can be run against it
+// with YOUTUBE_ENDPOINT=http://localhost:8080/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/fakeyoutube"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	videosPath := flag.String("videos", "", "JSON file with a list of videos to serve")
+	publishInterval := flag.Duration("publish-interval", 10*time.Second, "how often a new video is published, 0 to only serve the videos file")
+	query := flag.String("query", "cricket", "word put in the title of the published videos, so the worker query finds them")
+	flag.Parse()
+
+	logger := common.GetLogger()
+
+	server := &fakeyoutube.Server{}
+
+	if *videosPath != "" {
+		data, err := os.ReadFile(*videosPath)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to read videos")
+		}
+
+		videos := []fakeyoutube.Video{}
+		err = json.Unmarshal(data, &videos)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to parse videos")
+		}
+		server.AddVideos(videos...)
+	}
+
+	if *publishInterval > 0 {
+		go func() {
+			for i := 1; ; i++ {
+				time.Sleep(*publishInterval)
+
+				video := fakeyoutube.Video{
+					ID:          fmt.Sprintf("fake%06d", i),
+					Title:       fmt.Sprintf("%s video %d", *query, i),
+					Description: "Published by the fake Youtube API",
+					PublishedAt: time.Now().UTC(),
+				}
+				server.AddVideos(video)
+				logger.WithField("VideoID", video.ID).Info("Published video")
+			}
+		}()
+	}
+
+	logger.WithField("Addr", *addr).Info("Starting fake Youtube API")
+	err := http.ListenAndServe(*addr, server.Handler())
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to start fake Youtube API")
+	}
+}
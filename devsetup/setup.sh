@@ -1,5 +1,6 @@
 #!/bin/sh
 
+export STORAGE_DRIVER=mongo
 export MONGO_BASE_URL=mongodb://localhost:27017
 export MONGO_DATABASE_NAME=youtube_data
 export YOUTUBE_QUERY="official|cricket"
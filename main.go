@@ -2,11 +2,18 @@ package main
 
 import (
 	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/ashmeet13/YoutubeDataService/source/common"
 	"github.com/ashmeet13/YoutubeDataService/source/server"
 	"github.com/ashmeet13/YoutubeDataService/source/storage"
 	"github.com/ashmeet13/YoutubeDataService/source/worker"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/cassette"
 
 	_ "github.com/golang/mock/mockgen/model"
 )
@@ -16,6 +23,7 @@ func main() {
 	logger := common.GetLogger()
 
 	logger.
+		WithField("StorageDriver", config.StorageDriver).
 		WithField("MongoURL", config.MongoBaseURL).
 		WithField("MongoDatabase", config.MongoDatabaseName).
 		Info("Initalising Server")
@@ -24,11 +32,118 @@ func main() {
 	logger.Info("Building Indexes")
 	storage.BuildIndexes(context.Background())
 
-	workerHandler, err := worker.NewWorkerHandler(config.YoutubeQuery, config.YoutubeAPIKeys)
+	// Calls made by the workers are cancelled with runCtx, only once the shutdown deadline passes
+	runCtx, abort := context.WithCancel(context.Background())
+	defer abort()
+
+	if config.YoutubeEndpoint != "" {
+		logger.WithField("Endpoint", config.YoutubeEndpoint).Info("Using a custom Youtube API endpoint")
+		youtube_handler.SetDefaultEndpoint(config.YoutubeEndpoint)
+	}
+
+	switch config.YoutubeCassetteMode {
+	case common.RecordCassetteMode:
+		logger.WithField("Path", config.YoutubeCassettePath).Info("Recording Youtube API responses")
+		youtube_handler.SetDefaultTransport(cassette.NewRecorder(config.YoutubeCassettePath, nil))
+	case common.ReplayCassetteMode:
+		logger.WithField("Path", config.YoutubeCassettePath).Info("Replaying Youtube API responses")
+		replayer, err := cassette.NewReplayer(config.YoutubeCassettePath)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to load cassette")
+		}
+		youtube_handler.SetDefaultTransport(replayer)
+	}
+
+	apiKeyPool := youtube_handler.NewAPIKeyPool(config.YoutubeAPIKeys, config.YoutubeDailyQuota)
+
+	// One worker per ingestion query, all of them share the API keys. The configured
+	// queries seed the database, after that they are managed through /admin/queries.
+	// With several instances every query and loop below runs on the one holding its lease.
+	manager := worker.NewManager(apiKeyPool, config.WorkerMaxCatchUp, config.WorkerMaxBackoff, config.QueryReloadInterval, config.InstanceID, config.LeaseDuration)
+	err := manager.Seed(runCtx, config.YoutubeQueries)
 	if err != nil {
-		logger.Fatal("Failed to init worker")
+		logger.WithError(err).Fatal("Failed to seed ingestion queries")
+	}
+	go manager.Start(runCtx)
+
+	shutdownFuncs := []func(ctx context.Context) error{manager.Shutdown}
+
+	if config.BackfillQuery != "" {
+		backfillRunner := worker.NewLeasedRunner("backfill", config.InstanceID, config.LeaseDuration, func(ctx context.Context) (worker.Runner, error) {
+			return worker.NewBackfillHandler(ctx, config.BackfillQuery, config.BackfillTag, config.BackfillPublishedAfter, config.BackfillPublishedBefore, apiKeyPool, config.WorkerMaxBackoff)
+		})
+		go backfillRunner.Start(runCtx)
+
+		shutdownFuncs = append(shutdownFuncs, backfillRunner.Shutdown)
+	}
+
+	if config.StatsRefreshMaxAge > 0 {
+		statisticsRunner := worker.NewLeasedRunner("statistics", config.InstanceID, config.LeaseDuration, func(ctx context.Context) (worker.Runner, error) {
+			return worker.NewStatisticsRefresher(apiKeyPool, config.StatsRefreshMaxAge, config.StatsRefreshInterval, config.WorkerMaxBackoff), nil
+		})
+		go statisticsRunner.Start(runCtx)
+
+		shutdownFuncs = append(shutdownFuncs, statisticsRunner.Shutdown)
+	}
+
+	if len(config.FollowChannels) > 0 {
+		channelsRunner := worker.NewLeasedRunner("channels", config.InstanceID, config.LeaseDuration, func(ctx context.Context) (worker.Runner, error) {
+			return worker.NewChannelFollower(ctx, config.FollowChannels, config.FollowChannelsTag, config.FollowChannelsPollInterval, apiKeyPool, config.WorkerMaxBackoff)
+		})
+		go channelsRunner.Start(runCtx)
+
+		shutdownFuncs = append(shutdownFuncs, channelsRunner.Shutdown)
+	}
+
+	// Pushes complement the polling of the followed channels, they do not replace it
+	var webSubSubscriber server.WebSubInterface
+	if config.WebSubCallbackURL != "" && len(config.FollowChannels) > 0 {
+		subscriber := worker.NewWebSubSubscriber(config.FollowChannels, config.FollowChannelsTag, config.WebSubHubURL, config.WebSubCallbackURL, config.WebSubSecret, config.WebSubLease, apiKeyPool, config.WorkerMaxBackoff)
+		go subscriber.Start(runCtx)
+
+		webSubSubscriber = subscriber
+		shutdownFuncs = append(shutdownFuncs, subscriber.Shutdown)
 	}
-	go workerHandler.Start()
 
-	server.Start()
+	httpServer := server.NewServer(apiKeyPool, manager, webSubSubscriber)
+	go func() {
+		logger.Info("Starting server")
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.WithError(err).Fatal("Failed to start server, exiting")
+		}
+	}()
+
+	shutdownFuncs = append(shutdownFuncs, httpServer.Shutdown)
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	<-signalCtx.Done()
+
+	logger.WithField("Timeout", config.ShutdownTimeout).Info("Shutting down, draining requests and worker executions")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	shutdown(shutdownCtx, shutdownFuncs)
+
+	logger.Info("Shutdown complete")
+}
+
+// Runs every shutdown function at once and waits for all of them. Anything still
+// running once ctx is done is abandoned, the deferred abort cancels its calls.
+func shutdown(ctx context.Context, shutdownFuncs []func(ctx context.Context) error) {
+	logger := common.GetLogger()
+
+	var wg sync.WaitGroup
+	for _, shutdownFunc := range shutdownFuncs {
+		wg.Add(1)
+		go func(shutdownFunc func(ctx context.Context) error) {
+			defer wg.Done()
+
+			err := shutdownFunc(ctx)
+			if err != nil {
+				logger.WithError(err).Error("Failed to shut down in time")
+			}
+		}(shutdownFunc)
+	}
+	wg.Wait()
 }
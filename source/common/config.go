@@ -4,22 +4,140 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	MongoBaseURL      = "MONGO_BASE_URL"
 	MongoDatabaseName = "MONGO_DATABASE_NAME"
 	YoutubeAPIKeys    = "YOUTUBE_API_KEYS"
-	DefaultPageSize   = "DEFAULT_PAGE_SIZE"
-	YoutubeQuery      = "YOUTUBE_QUERY"
+	YoutubeDailyQuota = "YOUTUBE_DAILY_QUOTA"
+	YoutubeEndpoint   = "YOUTUBE_ENDPOINT"
+
+	YoutubeCassetteMode = "YOUTUBE_CASSETTE_MODE"
+	YoutubeCassettePath = "YOUTUBE_CASSETTE_PATH"
+	DefaultPageSize     = "DEFAULT_PAGE_SIZE"
+	YoutubeQuery        = "YOUTUBE_QUERY"
+	YoutubeQueries      = "YOUTUBE_QUERIES"
+	StorageDriver       = "STORAGE_DRIVER"
+	SqlitePath          = "SQLITE_PATH"
+	WorkerMaxCatchUp    = "WORKER_MAX_CATCHUP"
+	WorkerMaxBackoff    = "WORKER_MAX_BACKOFF"
+
+	QueryReloadInterval = "QUERY_RELOAD_INTERVAL"
+	ShutdownTimeout     = "SHUTDOWN_TIMEOUT"
+
+	BackfillQuery           = "BACKFILL_QUERY"
+	BackfillPublishedAfter  = "BACKFILL_PUBLISHED_AFTER"
+	BackfillPublishedBefore = "BACKFILL_PUBLISHED_BEFORE"
+	BackfillTag             = "BACKFILL_TAG"
+
+	StatsRefreshMaxAge   = "STATS_REFRESH_MAX_AGE"
+	StatsRefreshInterval = "STATS_REFRESH_INTERVAL"
+
+	FollowChannels             = "FOLLOW_CHANNELS"
+	FollowChannelsTag          = "FOLLOW_CHANNELS_TAG"
+	FollowChannelsPollInterval = "FOLLOW_CHANNELS_POLL_INTERVAL"
+
+	WebSubCallbackURL = "WEBSUB_CALLBACK_URL"
+	WebSubSecret      = "WEBSUB_SECRET"
+	WebSubHubURL      = "WEBSUB_HUB_URL"
+	WebSubLease       = "WEBSUB_LEASE"
+
+	InstanceID    = "INSTANCE_ID"
+	LeaseDuration = "LEASE_DURATION"
+
+	PageTokenSecret = "PAGE_TOKEN_SECRET"
+
+	AdminToken = "ADMIN_TOKEN"
+
+	SearchHighlightPreTag  = "SEARCH_HIGHLIGHT_PRE_TAG"
+	SearchHighlightPostTag = "SEARCH_HIGHLIGHT_POST_TAG"
+	SearchFragmentLength   = "SEARCH_FRAGMENT_LENGTH"
+)
+
+// Quota units a key can spend per day unless YOUTUBE_DAILY_QUOTA says otherwise,
+// the default quota of a Google Cloud project
+const DefaultYoutubeDailyQuota = 10000
+
+// Cassette modes that can be selected with YOUTUBE_CASSETTE_MODE
+const (
+	RecordCassetteMode = "record"
+	ReplayCassetteMode = "replay"
+)
+
+// Storage drivers that can be selected with STORAGE_DRIVER
+const (
+	MongoStorageDriver  = "mongo"
+	MemoryStorageDriver = "memory"
+	SqliteStorageDriver = "sqlite"
 )
 
 type Configuration struct {
 	MongoBaseURL      string
 	MongoDatabaseName string
 	YoutubeAPIKeys    []string
-	DefaultPageSize   int
-	YoutubeQuery      string
+	YoutubeDailyQuota int64
+	YoutubeEndpoint   string
+
+	// Record or replay the responses of the Youtube API, off when empty
+	YoutubeCassetteMode string
+	YoutubeCassettePath string
+	DefaultPageSize     int
+	YoutubeQueries      []YoutubeQueryConfig
+	StorageDriver       string
+	SqlitePath          string
+	WorkerMaxCatchUp    time.Duration
+	WorkerMaxBackoff    time.Duration
+
+	// How often the workers are reconciled with the ingestion queries in the database
+	QueryReloadInterval time.Duration
+
+	// How long in-flight requests and worker executions get to finish on shutdown
+	ShutdownTimeout time.Duration
+
+	// Backfill is off unless BackfillQuery is set
+	BackfillQuery           string
+	BackfillPublishedAfter  time.Time
+	BackfillPublishedBefore time.Time
+	BackfillTag             string
+
+	// Statistics of videos younger than StatsRefreshMaxAge are refreshed every
+	// StatsRefreshInterval, off when the age is 0
+	StatsRefreshMaxAge   time.Duration
+	StatsRefreshInterval time.Duration
+
+	// Uploads of the FollowChannels are polled every FollowChannelsPollInterval
+	// and tagged with FollowChannelsTag
+	FollowChannels             []string
+	FollowChannelsTag          string
+	FollowChannelsPollInterval time.Duration
+
+	// The followed channels are subscribed to on WebSubHubURL for pushes to
+	// WebSubCallbackURL, signed with WebSubSecret. Off without a callback.
+	WebSubCallbackURL string
+	WebSubSecret      string
+	WebSubHubURL      string
+	WebSubLease       time.Duration
+
+	// Every ingestion query and background loop runs on the one instance holding
+	// its lease, InstanceID tells the instances apart. A lease not renewed within
+	// LeaseDuration is taken over by another instance.
+	InstanceID    string
+	LeaseDuration time.Duration
+
+	// Continuation tokens are signed with PageTokenSecret, a random one per
+	// process when empty. It is required when InstanceID is set.
+	PageTokenSecret string
+
+	// The /admin endpoints require AdminToken as a bearer token, they are off when empty
+	AdminToken string
+
+	// Matched terms in the fragments of search results are wrapped in the tags,
+	// fragments are cut around them to about SearchFragmentLength characters
+	SearchHighlightPreTag  string
+	SearchHighlightPostTag string
+	SearchFragmentLength   int
 }
 
 var config *Configuration
@@ -34,27 +152,67 @@ func GetConfiguration() *Configuration {
 func SetupConfiguration() *Configuration {
 	logger := GetLogger()
 
+	storageDriver := os.Getenv(StorageDriver)
+	if storageDriver == "" {
+		storageDriver = MongoStorageDriver
+	}
+
+	if storageDriver != MongoStorageDriver && storageDriver != MemoryStorageDriver && storageDriver != SqliteStorageDriver {
+		logger.Fatalln("Unknown storage driver", storageDriver)
+		return nil
+	}
+
+	// Mongo settings are only required when mongo is the selected driver
 	mongoBaseURL := os.Getenv(MongoBaseURL)
-	if mongoBaseURL == "" {
+	if mongoBaseURL == "" && storageDriver == MongoStorageDriver {
 		logger.Fatalln("Could not find environment variable", MongoBaseURL)
 		return nil
 	}
 
 	mongoDatabaseName := os.Getenv(MongoDatabaseName)
-	if mongoDatabaseName == "" {
+	if mongoDatabaseName == "" && storageDriver == MongoStorageDriver {
 		logger.Fatalln("Could not find environment variable", MongoDatabaseName)
 		return nil
 	}
 
+	sqlitePath := os.Getenv(SqlitePath)
+	if sqlitePath == "" {
+		sqlitePath = "youtube_data.db"
+	}
+
 	youtubeAPIKeys := os.Getenv(YoutubeAPIKeys)
 	if youtubeAPIKeys == "" {
 		logger.Fatalln("Could not find environment variable", YoutubeAPIKeys)
 		return nil
 	}
 
-	youtubeQuery := os.Getenv(YoutubeQuery)
-	if youtubeQuery == "" {
-		logger.Fatalln("Could not find environment variable", YoutubeQuery)
+	var youtubeDailyQuota int64 = DefaultYoutubeDailyQuota
+	if youtubeDailyQuotaString := os.Getenv(YoutubeDailyQuota); youtubeDailyQuotaString != "" {
+		parsed, err := strconv.ParseInt(youtubeDailyQuotaString, 10, 64)
+		if err != nil || parsed < 0 {
+			logger.Fatalln("Invalid number in environment variable", YoutubeDailyQuota)
+			return nil
+		}
+		youtubeDailyQuota = parsed
+	}
+
+	// Empty for the real API, set to run against another server such as the fake in devsetup
+	youtubeEndpoint := os.Getenv(YoutubeEndpoint)
+
+	youtubeCassetteMode := os.Getenv(YoutubeCassetteMode)
+	if youtubeCassetteMode != "" && youtubeCassetteMode != RecordCassetteMode && youtubeCassetteMode != ReplayCassetteMode {
+		logger.Fatalln("Unknown cassette mode", youtubeCassetteMode)
+		return nil
+	}
+
+	youtubeCassettePath := os.Getenv(YoutubeCassettePath)
+	if youtubeCassettePath == "" {
+		youtubeCassettePath = "youtube_cassette.json"
+	}
+
+	youtubeQueries, err := parseYoutubeQueries(os.Getenv(YoutubeQueries), os.Getenv(YoutubeQuery))
+	if err != nil {
+		logger.WithError(err).Fatalln("Invalid ingestion queries")
 		return nil
 	}
 
@@ -63,6 +221,178 @@ func SetupConfiguration() *Configuration {
 		defaultPageSizeString = "5"
 	}
 
+	// No catch up limit by default, the worker resumes from wherever it stopped
+	var workerMaxCatchUp time.Duration
+	if workerMaxCatchUpString := os.Getenv(WorkerMaxCatchUp); workerMaxCatchUpString != "" {
+		workerMaxCatchUp, err = time.ParseDuration(workerMaxCatchUpString)
+		if err != nil {
+			logger.Fatalln("Invalid duration in environment variable", WorkerMaxCatchUp)
+			return nil
+		}
+	}
+
+	// Ceiling of the delay between retries of a failing worker
+	workerMaxBackoff := 5 * time.Minute
+	if workerMaxBackoffString := os.Getenv(WorkerMaxBackoff); workerMaxBackoffString != "" {
+		workerMaxBackoff, err = time.ParseDuration(workerMaxBackoffString)
+		if err != nil || workerMaxBackoff <= 0 {
+			logger.Fatalln("Invalid duration in environment variable", WorkerMaxBackoff)
+			return nil
+		}
+	}
+
+	queryReloadInterval := 5 * time.Second
+	if queryReloadIntervalString := os.Getenv(QueryReloadInterval); queryReloadIntervalString != "" {
+		queryReloadInterval, err = time.ParseDuration(queryReloadIntervalString)
+		if err != nil || queryReloadInterval <= 0 {
+			logger.Fatalln("Invalid duration in environment variable", QueryReloadInterval)
+			return nil
+		}
+	}
+
+	// Below the default 30s grace period of Kubernetes, so the service exits before it is killed
+	shutdownTimeout := 25 * time.Second
+	if shutdownTimeoutString := os.Getenv(ShutdownTimeout); shutdownTimeoutString != "" {
+		shutdownTimeout, err = time.ParseDuration(shutdownTimeoutString)
+		if err != nil || shutdownTimeout <= 0 {
+			logger.Fatalln("Invalid duration in environment variable", ShutdownTimeout)
+			return nil
+		}
+	}
+
+	backfillTag := os.Getenv(BackfillTag)
+	if backfillTag == "" {
+		backfillTag = "backfill"
+	}
+
+	// Both bounds of the window are required for a backfill
+	backfillQuery := os.Getenv(BackfillQuery)
+	var backfillPublishedAfter, backfillPublishedBefore time.Time
+	if backfillQuery != "" {
+		backfillPublishedAfter, err = time.Parse(time.RFC3339, os.Getenv(BackfillPublishedAfter))
+		if err != nil {
+			logger.Fatalln("Invalid RFC3339 time in environment variable", BackfillPublishedAfter)
+			return nil
+		}
+
+		backfillPublishedBefore, err = time.Parse(time.RFC3339, os.Getenv(BackfillPublishedBefore))
+		if err != nil {
+			logger.Fatalln("Invalid RFC3339 time in environment variable", BackfillPublishedBefore)
+			return nil
+		}
+
+		if !backfillPublishedAfter.Before(backfillPublishedBefore) {
+			logger.Fatalln("Environment variable", BackfillPublishedAfter, "must be before", BackfillPublishedBefore)
+			return nil
+		}
+	}
+
+	statsRefreshMaxAge := 72 * time.Hour
+	if statsRefreshMaxAgeString := os.Getenv(StatsRefreshMaxAge); statsRefreshMaxAgeString != "" {
+		statsRefreshMaxAge, err = time.ParseDuration(statsRefreshMaxAgeString)
+		if err != nil || statsRefreshMaxAge < 0 {
+			logger.Fatalln("Invalid duration in environment variable", StatsRefreshMaxAge)
+			return nil
+		}
+	}
+
+	statsRefreshInterval := time.Hour
+	if statsRefreshIntervalString := os.Getenv(StatsRefreshInterval); statsRefreshIntervalString != "" {
+		statsRefreshInterval, err = time.ParseDuration(statsRefreshIntervalString)
+		if err != nil || statsRefreshInterval <= 0 {
+			logger.Fatalln("Invalid duration in environment variable", StatsRefreshInterval)
+			return nil
+		}
+	}
+
+	followChannels := []string{}
+	for _, channelID := range strings.Split(os.Getenv(FollowChannels), ",") {
+		channelID = strings.TrimSpace(channelID)
+		if channelID != "" {
+			followChannels = append(followChannels, channelID)
+		}
+	}
+
+	followChannelsTag := os.Getenv(FollowChannelsTag)
+	if followChannelsTag == "" {
+		followChannelsTag = "channels"
+	}
+
+	followChannelsPollInterval := 5 * time.Minute
+	if followChannelsPollIntervalString := os.Getenv(FollowChannelsPollInterval); followChannelsPollIntervalString != "" {
+		followChannelsPollInterval, err = time.ParseDuration(followChannelsPollIntervalString)
+		if err != nil || followChannelsPollInterval <= 0 {
+			logger.Fatalln("Invalid duration in environment variable", FollowChannelsPollInterval)
+			return nil
+		}
+	}
+
+	// Without a secret anyone could push videos to the callback
+	webSubCallbackURL := os.Getenv(WebSubCallbackURL)
+	webSubSecret := os.Getenv(WebSubSecret)
+	if webSubCallbackURL != "" && webSubSecret == "" {
+		logger.Fatalln("Environment variable", WebSubSecret, "is required with", WebSubCallbackURL)
+		return nil
+	}
+
+	webSubHubURL := os.Getenv(WebSubHubURL)
+	if webSubHubURL == "" {
+		webSubHubURL = "https://pubsubhubbub.appspot.com/subscribe"
+	}
+
+	// The Youtube hub grants at most 10 days
+	webSubLease := 5 * 24 * time.Hour
+	if webSubLeaseString := os.Getenv(WebSubLease); webSubLeaseString != "" {
+		webSubLease, err = time.ParseDuration(webSubLeaseString)
+		if err != nil || webSubLease < time.Hour {
+			logger.Fatalln("Invalid duration in environment variable", WebSubLease)
+			return nil
+		}
+	}
+
+	// The process id tells apart instances sharing a host, and a restarted
+	// instance from the one it replaces
+	instanceID := os.Getenv(InstanceID)
+	if instanceID == "" {
+		hostname, _ := os.Hostname()
+		instanceID = hostname + "-" + strconv.Itoa(os.Getpid())
+	}
+
+	// A named instance runs next to others, a token it signed has to hold on all of them
+	pageTokenSecret := os.Getenv(PageTokenSecret)
+	if pageTokenSecret == "" && os.Getenv(InstanceID) != "" {
+		logger.Fatalln("Environment variable", PageTokenSecret, "is required with", InstanceID)
+		return nil
+	}
+
+	leaseDuration := 30 * time.Second
+	if leaseDurationString := os.Getenv(LeaseDuration); leaseDurationString != "" {
+		leaseDuration, err = time.ParseDuration(leaseDurationString)
+		if err != nil || leaseDuration < time.Second {
+			logger.Fatalln("Invalid duration in environment variable", LeaseDuration)
+			return nil
+		}
+	}
+
+	searchHighlightPreTag, ok := os.LookupEnv(SearchHighlightPreTag)
+	if !ok {
+		searchHighlightPreTag = "<em>"
+	}
+
+	searchHighlightPostTag, ok := os.LookupEnv(SearchHighlightPostTag)
+	if !ok {
+		searchHighlightPostTag = "</em>"
+	}
+
+	searchFragmentLength := 150
+	if searchFragmentLengthString := os.Getenv(SearchFragmentLength); searchFragmentLengthString != "" {
+		searchFragmentLength, err = strconv.Atoi(searchFragmentLengthString)
+		if err != nil || searchFragmentLength <= 0 {
+			logger.Fatalln("Invalid number in environment variable", SearchFragmentLength)
+			return nil
+		}
+	}
+
 	keys := []string{}
 	for _, apiKey := range strings.Split(youtubeAPIKeys, ",") {
 		keys = append(keys, apiKey)
@@ -77,7 +407,47 @@ func SetupConfiguration() *Configuration {
 		MongoBaseURL:      mongoBaseURL,
 		MongoDatabaseName: mongoDatabaseName,
 		YoutubeAPIKeys:    keys,
-		DefaultPageSize:   defaultPageSize,
-		YoutubeQuery:      youtubeQuery,
+		YoutubeDailyQuota: youtubeDailyQuota,
+		YoutubeEndpoint:   youtubeEndpoint,
+
+		YoutubeCassetteMode: youtubeCassetteMode,
+		YoutubeCassettePath: youtubeCassettePath,
+		DefaultPageSize:     defaultPageSize,
+		YoutubeQueries:      youtubeQueries,
+		StorageDriver:       storageDriver,
+		SqlitePath:          sqlitePath,
+		WorkerMaxCatchUp:    workerMaxCatchUp,
+		WorkerMaxBackoff:    workerMaxBackoff,
+
+		QueryReloadInterval: queryReloadInterval,
+		ShutdownTimeout:     shutdownTimeout,
+
+		BackfillQuery:           backfillQuery,
+		BackfillPublishedAfter:  backfillPublishedAfter,
+		BackfillPublishedBefore: backfillPublishedBefore,
+		BackfillTag:             backfillTag,
+
+		StatsRefreshMaxAge:   statsRefreshMaxAge,
+		StatsRefreshInterval: statsRefreshInterval,
+
+		FollowChannels:             followChannels,
+		FollowChannelsTag:          followChannelsTag,
+		FollowChannelsPollInterval: followChannelsPollInterval,
+
+		WebSubCallbackURL: webSubCallbackURL,
+		WebSubSecret:      webSubSecret,
+		WebSubHubURL:      webSubHubURL,
+		WebSubLease:       webSubLease,
+
+		InstanceID:    instanceID,
+		LeaseDuration: leaseDuration,
+
+		PageTokenSecret: pageTokenSecret,
+
+		AdminToken: os.Getenv(AdminToken),
+
+		SearchHighlightPreTag:  searchHighlightPreTag,
+		SearchHighlightPostTag: searchHighlightPostTag,
+		SearchFragmentLength:   searchFragmentLength,
 	}
 }
@@ -0,0 +1,174 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Name of the query built from the legacy YOUTUBE_QUERY variable
+const DefaultQueryName = "default"
+
+const DefaultPollInterval = 10 * time.Second
+
+// Shortest poll interval accepted for a query, anything faster burns through the API quota
+const MinPollInterval = time.Second
+
+// Kinds of source a query ingests from
+const (
+	// Polls search.list for Query
+	SearchSource = "search"
+	// Polls the RSS or Atom feed at Location
+	FeedSource = "feed"
+	// Reads the JSON files dropped in the directory at Location
+	FileSource = "file"
+)
+
+// YoutubeQueryConfig is one ingestion query. Each query is polled by its own worker
+// from its Source and the videos it finds are tagged with Tag.
+type YoutubeQueryConfig struct {
+	Name         string
+	Source       string
+	Query        string
+	Location     string
+	Tag          string
+	PollInterval time.Duration
+}
+
+type youtubeQueryJSON struct {
+	Name         string `json:"name"`
+	Source       string `json:"source"`
+	Query        string `json:"query"`
+	Location     string `json:"location"`
+	Tag          string `json:"tag"`
+	PollInterval string `json:"poll_interval"`
+}
+
+// QueryNameSeparator prefixes the IDs of the checkpoints and leases that are not
+// those of a query, like "channel:" and "index:", no query name may contain it
+const QueryNameSeparator = ":"
+
+// ValidateQueryName checks that a query has a name which cannot collide with the
+// internal checkpoints and leases
+func ValidateQueryName(name string) error {
+	if name == "" {
+		return fmt.Errorf("every query needs a name")
+	}
+	if strings.Contains(name, QueryNameSeparator) {
+		return fmt.Errorf("query name %s cannot contain %q", name, QueryNameSeparator)
+	}
+	return nil
+}
+
+// ValidateSource checks that a query has what its source needs, a search needs
+// a query and the other sources a location
+func ValidateSource(source string, query string, location string) error {
+	switch source {
+	case SearchSource:
+		if query == "" {
+			return fmt.Errorf("a %s query needs a query", source)
+		}
+	case FeedSource, FileSource:
+		if location == "" {
+			return fmt.Errorf("a %s query needs a location", source)
+		}
+	default:
+		return fmt.Errorf("unknown source %s", source)
+	}
+	return nil
+}
+
+/*
+parseYoutubeQueries reads the ingestion queries.
+
+YOUTUBE_QUERIES is a JSON list of queries, of any mix of sources -
+
+	[
+		{"name": "sports", "query": "cricket|football", "tag": "sports", "poll_interval": "30s"},
+		{"name": "blog", "source": "feed", "location": "https://example.com/videos.rss"},
+		{"name": "catalog", "source": "file", "location": "/var/lib/catalog"}
+	]
+
+name is required and cannot contain ":", source defaults to search. A search needs a query and the other
+sources a location. tag defaults to the name and poll_interval to 10s, it cannot be shorter than 1s.
+
+If YOUTUBE_QUERIES is not set, YOUTUBE_QUERY is used as a single query named default.
+Neither is required, the queries only seed the ones stored in the database and
+more can be added at runtime through the admin API.
+*/
+func parseYoutubeQueries(queriesJSON string, legacyQuery string) ([]YoutubeQueryConfig, error) {
+	if queriesJSON == "" {
+		if legacyQuery == "" {
+			return nil, nil
+		}
+
+		return []YoutubeQueryConfig{{
+			Name:         DefaultQueryName,
+			Source:       SearchSource,
+			Query:        legacyQuery,
+			Tag:          DefaultQueryName,
+			PollInterval: DefaultPollInterval,
+		}}, nil
+	}
+
+	var decoded []youtubeQueryJSON
+	err := json.Unmarshal([]byte(queriesJSON), &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decoded) == 0 {
+		return nil, fmt.Errorf("%s has no queries", YoutubeQueries)
+	}
+
+	names := map[string]bool{}
+	queries := []YoutubeQueryConfig{}
+	for _, query := range decoded {
+		err = ValidateQueryName(query.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if query.Source == "" {
+			query.Source = SearchSource
+		}
+
+		err = ValidateSource(query.Source, query.Query, query.Location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query %s: %w", query.Name, err)
+		}
+
+		if names[query.Name] {
+			return nil, fmt.Errorf("query name %s is used more than once", query.Name)
+		}
+		names[query.Name] = true
+
+		queryConfig := YoutubeQueryConfig{
+			Name:         query.Name,
+			Source:       query.Source,
+			Query:        query.Query,
+			Location:     query.Location,
+			Tag:          query.Tag,
+			PollInterval: DefaultPollInterval,
+		}
+
+		if queryConfig.Tag == "" {
+			queryConfig.Tag = query.Name
+		}
+
+		if query.PollInterval != "" {
+			queryConfig.PollInterval, err = time.ParseDuration(query.PollInterval)
+			if err != nil {
+				return nil, fmt.Errorf("invalid poll_interval for query %s: %w", query.Name, err)
+			}
+			if queryConfig.PollInterval < MinPollInterval {
+				return nil, fmt.Errorf("poll_interval for query %s cannot be shorter than %s", query.Name, MinPollInterval)
+			}
+		}
+
+		queries = append(queries, queryConfig)
+	}
+
+	return queries, nil
+}
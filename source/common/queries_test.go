@@ -0,0 +1,61 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseYoutubeQueries_Legacy(t *testing.T) {
+	queries, err := parseYoutubeQueries("", "cricket|football")
+	require.NoError(t, err)
+	require.Equal(t, []YoutubeQueryConfig{{
+		Name:         DefaultQueryName,
+		Source:       SearchSource,
+		Query:        "cricket|football",
+		Tag:          DefaultQueryName,
+		PollInterval: DefaultPollInterval,
+	}}, queries)
+
+	queries, err = parseYoutubeQueries("", "")
+	require.NoError(t, err)
+	require.Empty(t, queries)
+}
+
+func TestParseYoutubeQueries(t *testing.T) {
+	queries, err := parseYoutubeQueries(`[
+		{"name": "sports", "query": "cricket|football", "tag": "sport", "poll_interval": "30s"},
+		{"name": "news", "query": "news"},
+		{"name": "blog", "source": "feed", "location": "https://example.com/videos.rss"},
+		{"name": "catalog", "source": "file", "location": "/var/lib/catalog", "tag": "internal"}
+	]`, "ignored")
+	require.NoError(t, err)
+	require.Equal(t, []YoutubeQueryConfig{
+		{Name: "sports", Source: SearchSource, Query: "cricket|football", Tag: "sport", PollInterval: 30 * time.Second},
+		{Name: "news", Source: SearchSource, Query: "news", Tag: "news", PollInterval: DefaultPollInterval},
+		{Name: "blog", Source: FeedSource, Location: "https://example.com/videos.rss", Tag: "blog", PollInterval: DefaultPollInterval},
+		{Name: "catalog", Source: FileSource, Location: "/var/lib/catalog", Tag: "internal", PollInterval: DefaultPollInterval},
+	}, queries)
+}
+
+func TestParseYoutubeQueries_Invalid(t *testing.T) {
+	testCases := []string{
+		`not json`,
+		`[]`,
+		`[{"name": "sports"}]`,
+		`[{"query": "cricket"}]`,
+		`[{"name": "channel:UC123", "query": "cricket"}]`,
+		`[{"name": "sports", "query": "cricket"}, {"name": "sports", "query": "football"}]`,
+		`[{"name": "sports", "query": "cricket", "poll_interval": "soon"}]`,
+		`[{"name": "sports", "query": "cricket", "poll_interval": "500ms"}]`,
+		`[{"name": "sports", "query": "cricket", "poll_interval": "-10s"}]`,
+		`[{"name": "blog", "source": "feed"}]`,
+		`[{"name": "blog", "source": "podcast", "location": "https://example.com/videos.rss"}]`,
+	}
+
+	for _, testCase := range testCases {
+		_, err := parseYoutubeQueries(testCase, "")
+		require.Error(t, err, testCase)
+	}
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// AdminAuthMiddleware only lets through the requests carrying ADMIN_TOKEN as a
+// bearer token. Without a configured token the admin endpoints are off.
+func (h *ServerHandler) AdminAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.config.AdminToken == "" {
+			http.Error(w, "Admin API is disabled, set ADMIN_TOKEN to enable it", http.StatusForbidden)
+			return
+		}
+
+		authorization := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		if !strings.HasPrefix(authorization, "Bearer ") || subtle.ConstantTimeCompare([]byte(token), []byte(h.config.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Invalid admin token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
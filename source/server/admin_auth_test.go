@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdminAuthSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	serverHandler *ServerHandler
+	handler       http.Handler
+	served        bool
+}
+
+func TestAdminAuthSuite(t *testing.T) {
+	suite.Run(t, new(AdminAuthSuite))
+}
+
+func (s *AdminAuthSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.served = false
+	s.serverHandler = &ServerHandler{
+		config: &common.Configuration{AdminToken: "admin-secret"},
+	}
+	s.handler = s.serverHandler.AdminAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.served = true
+	}))
+}
+
+func (s *AdminAuthSuite) request(authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/admin/workers", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	res := httptest.NewRecorder()
+	s.handler.ServeHTTP(res, req)
+	return res
+}
+
+func (s *AdminAuthSuite) TestAdminAuth_ValidToken() {
+	res := s.request("Bearer admin-secret")
+	s.Equal(http.StatusOK, res.Code)
+	s.True(s.served)
+}
+
+func (s *AdminAuthSuite) TestAdminAuth_InvalidToken() {
+	for _, authorization := range []string{"", "Bearer wrong", "admin-secret", "Basic admin-secret"} {
+		res := s.request(authorization)
+		s.Equal(http.StatusUnauthorized, res.Code)
+		s.Equal("Bearer", res.Header().Get("WWW-Authenticate"))
+	}
+	s.False(s.served)
+}
+
+func (s *AdminAuthSuite) TestAdminAuth_Disabled() {
+	s.serverHandler.config.AdminToken = ""
+
+	res := s.request("Bearer ")
+	s.Equal(http.StatusForbidden, res.Code)
+	s.False(s.served)
+}
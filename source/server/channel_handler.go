@@ -0,0 +1,111 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/gorilla/mux"
+)
+
+type ChannelResponse struct {
+	Channel *storage.Channel
+}
+
+type ChannelVideosResponse struct {
+	ChannelID string
+	User      string
+	Page      int
+	Metadata  []*storage.VideoMetadata
+}
+
+// Handles GET /channels/{id}, the details of a channel as of its last channels.list call
+func (h *ServerHandler) ChannelHandler(w http.ResponseWriter, r *http.Request) {
+	channel, ok := h.readChannel(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, &ChannelResponse{
+		Channel: channel,
+	})
+}
+
+// Handles GET /channels/{id}/videos/{userid}/{page}, the feed of a channel paged
+// like /fetch/{userid}/{page} with the timestamp and page size of the user
+func (h *ServerHandler) ChannelVideosHandler(w http.ResponseWriter, r *http.Request) {
+	logger := common.GetLogger()
+
+	channel, ok := h.readChannel(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	userID := vars["userid"]
+	if userID == "" {
+		http.Error(w, "userid is missing in parameters", http.StatusBadRequest)
+		return
+	}
+
+	page, err := strconv.Atoi(vars["page"])
+	if err != nil || page < 1 {
+		http.Error(w, fmt.Sprintf("Invalid page %s", vars["page"]), http.StatusBadRequest)
+		return
+	}
+
+	logger.WithField("ChannelID", channel.ChannelID).WithField("User", userID).WithField("Page", page).Info("Channel Fetch Request")
+
+	user, err := h.userHandler.ReadUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if user == nil {
+		http.Error(w, fmt.Sprintf("Could not find user with userid %s", userID), http.StatusBadRequest)
+		return
+	}
+
+	pageSize := h.feedPageSize(user)
+	offset := pageSize * int64(page-1)
+
+	metadata, err := h.videoMetadataHandler.FetchPagedMetadataWithChannelID(r.Context(), channel.ChannelID, user.Timestamp, offset, pageSize)
+	if err != nil {
+		logger.WithError(err).WithField("ChannelID", channel.ChannelID).Error("Failed in fetching channel page")
+		http.Error(w, "Failed in fetching page", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, &ChannelVideosResponse{
+		ChannelID: channel.ChannelID,
+		User:      userID,
+		Page:      page,
+		Metadata:  metadata,
+	})
+}
+
+// Reads the channel named in the path, writes the error response if there is none
+func (h *ServerHandler) readChannel(w http.ResponseWriter, r *http.Request) (*storage.Channel, bool) {
+	channelID := mux.Vars(r)["id"]
+	if channelID == "" {
+		http.Error(w, "id is missing in parameters", http.StatusBadRequest)
+		return nil, false
+	}
+
+	channel, err := h.channelHandler.ReadChannel(r.Context(), channelID)
+	if err != nil {
+		common.GetLogger().WithError(err).WithField("ChannelID", channelID).Error("Failed to read channel")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if channel == nil {
+		http.Error(w, fmt.Sprintf("Could not find channel with id %s", channelID), http.StatusNotFound)
+		return nil, false
+	}
+
+	return channel, true
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ChannelHandlerSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	mockChannelStore       *mock_storage.MockChannelInterface
+	mockUserStore          *mock_storage.MockUserInterface
+	mockVideoMetadataStore *mock_storage.MockVideoMetadataInterface
+	serverHandler          *ServerHandler
+}
+
+func TestChannelHandlerSuite(t *testing.T) {
+	suite.Run(t, new(ChannelHandlerSuite))
+}
+
+func (s *ChannelHandlerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.mockChannelStore = mock_storage.NewMockChannelInterface(s.ctrl)
+	s.mockUserStore = mock_storage.NewMockUserInterface(s.ctrl)
+	s.mockVideoMetadataStore = mock_storage.NewMockVideoMetadataInterface(s.ctrl)
+
+	s.serverHandler = &ServerHandler{
+		channelHandler:       s.mockChannelStore,
+		userHandler:          s.mockUserStore,
+		videoMetadataHandler: s.mockVideoMetadataStore,
+	}
+}
+
+func (s *ChannelHandlerSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *ChannelHandlerSuite) TestChannelHandler_Ok() {
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "channel").Return(&storage.Channel{ChannelID: "channel", Title: "Cricket TV", SubscriberCount: 1000}, nil)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/channels/channel", nil), map[string]string{"id": "channel"})
+	res := httptest.NewRecorder()
+	s.serverHandler.ChannelHandler(res, req)
+	s.Equal(http.StatusOK, res.Code)
+
+	var response ChannelResponse
+	s.NoError(json.NewDecoder(res.Body).Decode(&response))
+	s.Equal("Cricket TV", response.Channel.Title)
+	s.Equal(int64(1000), response.Channel.SubscriberCount)
+}
+
+func (s *ChannelHandlerSuite) TestChannelHandler_NotFound() {
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "channel").Return(nil, nil)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/channels/channel", nil), map[string]string{"id": "channel"})
+	res := httptest.NewRecorder()
+	s.serverHandler.ChannelHandler(res, req)
+	s.Equal(http.StatusNotFound, res.Code)
+}
+
+func (s *ChannelHandlerSuite) TestChannelVideosHandler_Ok() {
+	timestamp := time.Now().UTC()
+
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "channel").Return(&storage.Channel{ChannelID: "channel"}, nil)
+	s.mockUserStore.EXPECT().ReadUser(gomock.Any(), "user").Return(&storage.User{UserID: "user", PageSize: 5, Timestamp: timestamp}, nil)
+	s.mockVideoMetadataStore.EXPECT().FetchPagedMetadataWithChannelID(gomock.Any(), "channel", timestamp, int64(5), int64(5)).Return([]*storage.VideoMetadata{
+		{VideoID: "video", ChannelID: "channel"},
+	}, nil)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/channels/channel/videos/user/2", nil), map[string]string{"id": "channel", "userid": "user", "page": "2"})
+	res := httptest.NewRecorder()
+	s.serverHandler.ChannelVideosHandler(res, req)
+	s.Equal(http.StatusOK, res.Code)
+
+	var response ChannelVideosResponse
+	s.NoError(json.NewDecoder(res.Body).Decode(&response))
+	s.Equal("channel", response.ChannelID)
+	s.Equal("user", response.User)
+	s.Equal(2, response.Page)
+	s.Len(response.Metadata, 1)
+}
+
+func (s *ChannelHandlerSuite) TestChannelVideosHandler_UnknownUser() {
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "channel").Return(&storage.Channel{ChannelID: "channel"}, nil)
+	s.mockUserStore.EXPECT().ReadUser(gomock.Any(), "user").Return(nil, nil)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/channels/channel/videos/user/1", nil), map[string]string{"id": "channel", "userid": "user", "page": "1"})
+	res := httptest.NewRecorder()
+	s.serverHandler.ChannelVideosHandler(res, req)
+	s.Equal(http.StatusBadRequest, res.Code)
+}
+
+func (s *ChannelHandlerSuite) TestChannelVideosHandler_InvalidPage() {
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "channel").Return(&storage.Channel{ChannelID: "channel"}, nil)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/channels/channel/videos/user/0", nil), map[string]string{"id": "channel", "userid": "user", "page": "0"})
+	res := httptest.NewRecorder()
+	s.serverHandler.ChannelVideosHandler(res, req)
+	s.Equal(http.StatusBadRequest, res.Code)
+}
@@ -0,0 +1,322 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/gorilla/mux"
+)
+
+// IngestionQueryRequest is the body of the create and update requests. Source
+// defaults to search and is the only one accepted here, which needs a Query. Feeds
+// and files read any URL or directory, so they only come from configuration. Tag
+// defaults to the name and PollInterval, a Go duration like "30s", to 10s.
+type IngestionQueryRequest struct {
+	Name         string
+	Source       string
+	Query        string
+	Location     string
+	Tag          string
+	PollInterval string
+	Paused       bool
+}
+
+type IngestionQueryResponse struct {
+	Name         string
+	Source       string
+	Query        string
+	Location     string
+	Tag          string
+	PollInterval string
+	Paused       bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type IngestionQueriesResponse struct {
+	Queries []*IngestionQueryResponse
+}
+
+func newIngestionQueryResponse(ingestionQuery *storage.IngestionQuery) *IngestionQueryResponse {
+	source := ingestionQuery.Source
+	if source == "" {
+		source = common.SearchSource
+	}
+
+	return &IngestionQueryResponse{
+		Name:         ingestionQuery.Name,
+		Source:       source,
+		Query:        ingestionQuery.Query,
+		Location:     ingestionQuery.Location,
+		Tag:          ingestionQuery.Tag,
+		PollInterval: ingestionQuery.PollInterval.String(),
+		Paused:       ingestionQuery.Paused,
+		CreatedAt:    ingestionQuery.CreatedAt,
+		UpdatedAt:    ingestionQuery.UpdatedAt,
+	}
+}
+
+// Handles GET /admin/queries
+func (h *ServerHandler) ListIngestionQueriesHandler(w http.ResponseWriter, r *http.Request) {
+	logger := common.GetLogger()
+
+	ingestionQueries, err := h.ingestionQueryHandler.ListIngestionQueries(r.Context())
+	if err != nil {
+		logger.WithError(err).Error("Failed to list ingestion queries")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := &IngestionQueriesResponse{
+		Queries: []*IngestionQueryResponse{},
+	}
+	for _, ingestionQuery := range ingestionQueries {
+		response.Queries = append(response.Queries, newIngestionQueryResponse(ingestionQuery))
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// Handles POST /admin/queries
+func (h *ServerHandler) CreateIngestionQueryHandler(w http.ResponseWriter, r *http.Request) {
+	logger := common.GetLogger()
+
+	request, ok := decodeIngestionQueryRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := common.ValidateQueryName(request.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+	ingestionQuery := &storage.IngestionQuery{
+		Name:      request.Name,
+		Paused:    request.Paused,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = applyIngestionQueryRequest(ingestionQuery, request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger = logger.WithField("QueryName", ingestionQuery.Name)
+
+	err = h.ingestionQueryHandler.CreateIngestionQuery(r.Context(), ingestionQuery)
+	if err == storage.ErrIngestionQueryExists {
+		http.Error(w, fmt.Sprintf("Query %s already exists", ingestionQuery.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Error("Failed to create ingestion query")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Created ingestion query")
+	writeJSONResponse(w, http.StatusCreated, newIngestionQueryResponse(ingestionQuery))
+}
+
+// Handles GET /admin/queries/{name}
+func (h *ServerHandler) GetIngestionQueryHandler(w http.ResponseWriter, r *http.Request) {
+	ingestionQuery, ok := h.readIngestionQuery(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newIngestionQueryResponse(ingestionQuery))
+}
+
+// Handles PUT /admin/queries/{name}, the name of a query cannot be changed
+func (h *ServerHandler) UpdateIngestionQueryHandler(w http.ResponseWriter, r *http.Request) {
+	logger := common.GetLogger()
+
+	ingestionQuery, ok := h.readIngestionQuery(w, r)
+	if !ok {
+		return
+	}
+
+	request, ok := decodeIngestionQueryRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if request.Name != "" && request.Name != ingestionQuery.Name {
+		http.Error(w, "Name of a query cannot be changed", http.StatusBadRequest)
+		return
+	}
+
+	err := applyIngestionQueryRequest(ingestionQuery, request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.saveIngestionQuery(w, r, ingestionQuery)
+	logger.WithField("QueryName", ingestionQuery.Name).Info("Updated ingestion query")
+}
+
+// Handles POST /admin/queries/{name}/pause
+func (h *ServerHandler) PauseIngestionQueryHandler(w http.ResponseWriter, r *http.Request) {
+	h.setIngestionQueryPaused(w, r, true)
+}
+
+// Handles POST /admin/queries/{name}/resume
+func (h *ServerHandler) ResumeIngestionQueryHandler(w http.ResponseWriter, r *http.Request) {
+	h.setIngestionQueryPaused(w, r, false)
+}
+
+// Handles DELETE /admin/queries/{name}. The checkpoint of the query is kept, a
+// query created again with the same name resumes from it.
+func (h *ServerHandler) DeleteIngestionQueryHandler(w http.ResponseWriter, r *http.Request) {
+	logger := common.GetLogger()
+
+	ingestionQuery, ok := h.readIngestionQuery(w, r)
+	if !ok {
+		return
+	}
+
+	logger = logger.WithField("QueryName", ingestionQuery.Name)
+
+	err := h.ingestionQueryHandler.DeleteIngestionQuery(r.Context(), ingestionQuery.Name)
+	if err != nil {
+		logger.WithError(err).Error("Failed to delete ingestion query")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Deleted ingestion query")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ServerHandler) setIngestionQueryPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	logger := common.GetLogger()
+
+	ingestionQuery, ok := h.readIngestionQuery(w, r)
+	if !ok {
+		return
+	}
+
+	ingestionQuery.Paused = paused
+	h.saveIngestionQuery(w, r, ingestionQuery)
+	logger.WithField("QueryName", ingestionQuery.Name).WithField("Paused", paused).Info("Updated ingestion query")
+}
+
+// Reads the query named in the path, writes the error response if there is none
+func (h *ServerHandler) readIngestionQuery(w http.ResponseWriter, r *http.Request) (*storage.IngestionQuery, bool) {
+	logger := common.GetLogger()
+
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		http.Error(w, "name is missing in parameters", http.StatusBadRequest)
+		return nil, false
+	}
+
+	ingestionQuery, err := h.ingestionQueryHandler.ReadIngestionQuery(r.Context(), name)
+	if err != nil {
+		logger.WithError(err).WithField("QueryName", name).Error("Failed to read ingestion query")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if ingestionQuery == nil {
+		http.Error(w, fmt.Sprintf("Could not find query with name %s", name), http.StatusNotFound)
+		return nil, false
+	}
+
+	return ingestionQuery, true
+}
+
+func (h *ServerHandler) saveIngestionQuery(w http.ResponseWriter, r *http.Request, ingestionQuery *storage.IngestionQuery) {
+	ingestionQuery.UpdatedAt = time.Now().UTC()
+
+	err := h.ingestionQueryHandler.UpdateIngestionQuery(r.Context(), ingestionQuery.Name, ingestionQuery)
+	if err != nil {
+		common.GetLogger().WithError(err).WithField("QueryName", ingestionQuery.Name).Error("Failed to update ingestion query")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, newIngestionQueryResponse(ingestionQuery))
+}
+
+func decodeIngestionQueryRequest(w http.ResponseWriter, r *http.Request) (*IngestionQueryRequest, bool) {
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type header is not application/json", http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	var request IngestionQueryRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		common.GetLogger().WithError(err).Error("Failed to read request body")
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &request, true
+}
+
+// Copies the source, query, location, tag and poll interval of the request onto ingestionQuery
+func applyIngestionQueryRequest(ingestionQuery *storage.IngestionQuery, request *IngestionQueryRequest) error {
+	source := request.Source
+	if source == "" {
+		source = common.SearchSource
+	}
+
+	err := common.ValidateSource(source, request.Query, request.Location)
+	if err != nil {
+		return err
+	}
+
+	// A feed fetches any URL and a file source reads any directory of the server
+	if source != common.SearchSource {
+		return fmt.Errorf("Source %s can only be set in %s", source, common.YoutubeQueries)
+	}
+
+	ingestionQuery.Source = source
+	ingestionQuery.Query = request.Query
+	ingestionQuery.Location = request.Location
+
+	ingestionQuery.Tag = request.Tag
+	if ingestionQuery.Tag == "" {
+		ingestionQuery.Tag = ingestionQuery.Name
+	}
+
+	ingestionQuery.PollInterval = common.DefaultPollInterval
+	if request.PollInterval != "" {
+		pollInterval, err := time.ParseDuration(request.PollInterval)
+		if err != nil {
+			return fmt.Errorf("Invalid PollInterval %s", request.PollInterval)
+		}
+		if pollInterval < common.MinPollInterval {
+			return fmt.Errorf("PollInterval cannot be shorter than %s", common.MinPollInterval)
+		}
+		ingestionQuery.PollInterval = pollInterval
+	}
+
+	return nil
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, response interface{}) {
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		common.GetLogger().WithError(err).Error("Error happened in JSON marshal")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(jsonResponse)
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type IngestionQueryHandlerSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	mockIngestionQueryStore *mock_storage.MockIngestionQueryInterface
+	serverHandler           *ServerHandler
+}
+
+func TestIngestionQueryHandlerSuite(t *testing.T) {
+	suite.Run(t, new(IngestionQueryHandlerSuite))
+}
+
+func (s *IngestionQueryHandlerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.mockIngestionQueryStore = mock_storage.NewMockIngestionQueryInterface(s.ctrl)
+
+	s.serverHandler = &ServerHandler{
+		ingestionQueryHandler: s.mockIngestionQueryStore,
+	}
+}
+
+func (s *IngestionQueryHandlerSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *IngestionQueryHandlerSuite) newRequest(method string, name string, body *IngestionQueryRequest) *http.Request {
+	var req *http.Request
+	if body != nil {
+		jsonRequest, _ := json.Marshal(body)
+		req = httptest.NewRequest(method, "/admin/queries", bytes.NewBuffer(jsonRequest))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req = httptest.NewRequest(method, "/admin/queries", nil)
+	}
+
+	if name != "" {
+		req = mux.SetURLVars(req, map[string]string{"name": name})
+	}
+	return req
+}
+
+func (s *IngestionQueryHandlerSuite) decodeResponse(res *httptest.ResponseRecorder) *IngestionQueryResponse {
+	var response IngestionQueryResponse
+	s.NoError(json.NewDecoder(res.Body).Decode(&response))
+	return &response
+}
+
+func (s *IngestionQueryHandlerSuite) TestListIngestionQueriesHandler_Ok() {
+	s.mockIngestionQueryStore.EXPECT().ListIngestionQueries(gomock.Any()).Return([]*storage.IngestionQuery{
+		{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: 30 * time.Second},
+		{Name: "tennis", Query: "tennis", Tag: "tennis", PollInterval: time.Minute, Paused: true},
+	}, nil)
+
+	res := httptest.NewRecorder()
+	s.serverHandler.ListIngestionQueriesHandler(res, s.newRequest(http.MethodGet, "", nil))
+
+	s.Equal(http.StatusOK, res.Code)
+
+	var response IngestionQueriesResponse
+	s.NoError(json.NewDecoder(res.Body).Decode(&response))
+	s.Len(response.Queries, 2)
+	s.Equal("30s", response.Queries[0].PollInterval)
+	s.True(response.Queries[1].Paused)
+}
+
+func (s *IngestionQueryHandlerSuite) TestCreateIngestionQueryHandler_Defaults() {
+	s.mockIngestionQueryStore.EXPECT().CreateIngestionQuery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, ingestionQuery *storage.IngestionQuery) error {
+		s.Equal("cricket", ingestionQuery.Name)
+		s.Equal(common.SearchSource, ingestionQuery.Source)
+		s.Equal("cricket", ingestionQuery.Tag)
+		s.Equal(10*time.Second, ingestionQuery.PollInterval)
+		s.False(ingestionQuery.CreatedAt.IsZero())
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	s.serverHandler.CreateIngestionQueryHandler(res, s.newRequest(http.MethodPost, "", &IngestionQueryRequest{
+		Name:  "cricket",
+		Query: "cricket world cup",
+	}))
+
+	s.Equal(http.StatusCreated, res.Code)
+	s.Equal("cricket world cup", s.decodeResponse(res).Query)
+}
+
+func (s *IngestionQueryHandlerSuite) TestCreateIngestionQueryHandler_ConfiguredOnlySources() {
+	testCases := []*IngestionQueryRequest{
+		{Name: "blog", Source: common.FeedSource, Location: "https://example.com/videos.rss"},
+		{Name: "catalog", Source: common.FileSource, Location: "/var/lib/catalog"},
+	}
+
+	for _, testCase := range testCases {
+		res := httptest.NewRecorder()
+		s.serverHandler.CreateIngestionQueryHandler(res, s.newRequest(http.MethodPost, "", testCase))
+
+		message, _ := ioutil.ReadAll(res.Body)
+		s.Equal(http.StatusBadRequest, res.Code, testCase)
+		s.Equal(fmt.Sprintf("Source %s can only be set in YOUTUBE_QUERIES\n", testCase.Source), string(message))
+	}
+}
+
+func (s *IngestionQueryHandlerSuite) TestUpdateIngestionQueryHandler_ConfiguredOnlySources() {
+	s.mockIngestionQueryStore.EXPECT().ReadIngestionQuery(gomock.Any(), "cricket").Return(&storage.IngestionQuery{Name: "cricket", Query: "cricket"}, nil)
+
+	res := httptest.NewRecorder()
+	s.serverHandler.UpdateIngestionQueryHandler(res, s.newRequest(http.MethodPut, "cricket", &IngestionQueryRequest{
+		Source:   common.FeedSource,
+		Location: "http://169.254.169.254/latest/meta-data",
+	}))
+
+	s.Equal(http.StatusBadRequest, res.Code)
+}
+
+func (s *IngestionQueryHandlerSuite) TestCreateIngestionQueryHandler_Invalid() {
+	testCases := []*IngestionQueryRequest{
+		{Query: "cricket"},
+		{Name: "cricket"},
+		{Name: "index:title_suggestions", Query: "cricket"},
+		{Name: "cricket", Query: "cricket", PollInterval: "often"},
+		{Name: "cricket", Query: "cricket", PollInterval: "10ms"},
+		{Name: "blog", Source: common.FeedSource, Query: "cricket"},
+		{Name: "blog", Source: "podcast", Location: "https://example.com/videos.rss"},
+	}
+
+	for _, testCase := range testCases {
+		res := httptest.NewRecorder()
+		s.serverHandler.CreateIngestionQueryHandler(res, s.newRequest(http.MethodPost, "", testCase))
+		s.Equal(http.StatusBadRequest, res.Code, testCase)
+	}
+}
+
+func (s *IngestionQueryHandlerSuite) TestCreateIngestionQueryHandler_Exists() {
+	s.mockIngestionQueryStore.EXPECT().CreateIngestionQuery(gomock.Any(), gomock.Any()).Return(storage.ErrIngestionQueryExists)
+
+	res := httptest.NewRecorder()
+	s.serverHandler.CreateIngestionQueryHandler(res, s.newRequest(http.MethodPost, "", &IngestionQueryRequest{
+		Name:  "cricket",
+		Query: "cricket",
+	}))
+
+	message, _ := ioutil.ReadAll(res.Body)
+	s.Equal(http.StatusConflict, res.Code)
+	s.Equal("Query cricket already exists\n", string(message))
+}
+
+func (s *IngestionQueryHandlerSuite) TestGetIngestionQueryHandler_NotFound() {
+	s.mockIngestionQueryStore.EXPECT().ReadIngestionQuery(gomock.Any(), "missing").Return(nil, nil)
+
+	res := httptest.NewRecorder()
+	s.serverHandler.GetIngestionQueryHandler(res, s.newRequest(http.MethodGet, "missing", nil))
+
+	s.Equal(http.StatusNotFound, res.Code)
+}
+
+func (s *IngestionQueryHandlerSuite) TestUpdateIngestionQueryHandler_KeepsPausedAndCreatedAt() {
+	createdAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.mockIngestionQueryStore.EXPECT().ReadIngestionQuery(gomock.Any(), "cricket").Return(&storage.IngestionQuery{
+		Name: "cricket", Query: "cricket", Tag: "cricket", PollInterval: time.Minute, Paused: true, CreatedAt: createdAt,
+	}, nil)
+	s.mockIngestionQueryStore.EXPECT().UpdateIngestionQuery(gomock.Any(), "cricket", gomock.Any()).DoAndReturn(func(ctx context.Context, name string, ingestionQuery *storage.IngestionQuery) error {
+		s.Equal("cricket highlights", ingestionQuery.Query)
+		s.Equal("sports", ingestionQuery.Tag)
+		s.Equal(30*time.Second, ingestionQuery.PollInterval)
+		s.True(ingestionQuery.Paused)
+		s.Equal(createdAt, ingestionQuery.CreatedAt)
+		s.True(ingestionQuery.UpdatedAt.After(createdAt))
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	s.serverHandler.UpdateIngestionQueryHandler(res, s.newRequest(http.MethodPut, "cricket", &IngestionQueryRequest{
+		Query:        "cricket highlights",
+		Tag:          "sports",
+		PollInterval: "30s",
+	}))
+
+	s.Equal(http.StatusOK, res.Code)
+}
+
+func (s *IngestionQueryHandlerSuite) TestUpdateIngestionQueryHandler_Rename() {
+	s.mockIngestionQueryStore.EXPECT().ReadIngestionQuery(gomock.Any(), "cricket").Return(&storage.IngestionQuery{Name: "cricket", Query: "cricket"}, nil)
+
+	res := httptest.NewRecorder()
+	s.serverHandler.UpdateIngestionQueryHandler(res, s.newRequest(http.MethodPut, "cricket", &IngestionQueryRequest{
+		Name:  "tennis",
+		Query: "tennis",
+	}))
+
+	s.Equal(http.StatusBadRequest, res.Code)
+}
+
+func (s *IngestionQueryHandlerSuite) TestPauseAndResumeIngestionQueryHandler() {
+	s.mockIngestionQueryStore.EXPECT().ReadIngestionQuery(gomock.Any(), "cricket").Return(&storage.IngestionQuery{Name: "cricket", Query: "cricket"}, nil)
+	s.mockIngestionQueryStore.EXPECT().UpdateIngestionQuery(gomock.Any(), "cricket", gomock.Any()).DoAndReturn(func(ctx context.Context, name string, ingestionQuery *storage.IngestionQuery) error {
+		s.True(ingestionQuery.Paused)
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	s.serverHandler.PauseIngestionQueryHandler(res, s.newRequest(http.MethodPost, "cricket", nil))
+	s.Equal(http.StatusOK, res.Code)
+	s.True(s.decodeResponse(res).Paused)
+
+	s.mockIngestionQueryStore.EXPECT().ReadIngestionQuery(gomock.Any(), "cricket").Return(&storage.IngestionQuery{Name: "cricket", Query: "cricket", Paused: true}, nil)
+	s.mockIngestionQueryStore.EXPECT().UpdateIngestionQuery(gomock.Any(), "cricket", gomock.Any()).Return(nil)
+
+	res = httptest.NewRecorder()
+	s.serverHandler.ResumeIngestionQueryHandler(res, s.newRequest(http.MethodPost, "cricket", nil))
+	s.Equal(http.StatusOK, res.Code)
+	s.False(s.decodeResponse(res).Paused)
+}
+
+func (s *IngestionQueryHandlerSuite) TestDeleteIngestionQueryHandler() {
+	s.mockIngestionQueryStore.EXPECT().ReadIngestionQuery(gomock.Any(), "cricket").Return(&storage.IngestionQuery{Name: "cricket", Query: "cricket"}, nil)
+	s.mockIngestionQueryStore.EXPECT().DeleteIngestionQuery(gomock.Any(), "cricket").Return(nil)
+
+	res := httptest.NewRecorder()
+	s.serverHandler.DeleteIngestionQueryHandler(res, s.newRequest(http.MethodDelete, "cricket", nil))
+	s.Equal(http.StatusNoContent, res.Code)
+
+	s.mockIngestionQueryStore.EXPECT().ReadIngestionQuery(gomock.Any(), "cricket").Return(nil, errors.New("dummy test error"))
+
+	res = httptest.NewRecorder()
+	s.serverHandler.DeleteIngestionQueryHandler(res, s.newRequest(http.MethodDelete, "cricket", nil))
+	s.Equal(http.StatusInternalServerError, res.Code)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+)
+
+var errInvalidPageToken = errors.New("invalid continuation token")
+
+// pageTokenKind is what a token pages through, it is signed with the position
+// so a token of one kind is never decoded as another
+type pageTokenKind string
+
+const (
+	feedPageToken   pageTokenKind = "feed"
+	searchPageToken pageTokenKind = "search"
+)
+
+/*
+pageTokenSigner turns the position of a page into an opaque continuation token
+and back. A token is the JSON of the position and its HMAC-SHA256, both base64
+encoded, so clients cannot forge a position or read anything into it. The kind of
+the token is part of what is signed.
+
+Tokens signed with a random key only hold within the process that signed them,
+instances sharing their clients must share PAGE_TOKEN_SECRET.
+*/
+type pageTokenSigner struct {
+	key []byte
+}
+
+func newPageTokenSigner(secret string) *pageTokenSigner {
+	key := []byte(secret)
+	if secret == "" {
+		common.GetLogger().Warnln("No", common.PageTokenSecret, "set, continuation tokens are signed with a random key and only hold within this process")
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+
+	return &pageTokenSigner{
+		key: key,
+	}
+}
+
+func (s *pageTokenSigner) sign(kind pageTokenKind, payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Encodes and signs position, a struct that marshals to JSON, as a token of kind
+func (s *pageTokenSigner) Encode(kind pageTokenKind, position interface{}) (string, error) {
+	payload, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.sign(kind, payload)), nil
+}
+
+// Checks the signature of token and decodes its position, errInvalidPageToken if it
+// was not signed here as a token of kind
+func (s *pageTokenSigner) Decode(kind pageTokenKind, token string, position interface{}) error {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return errInvalidPageToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return errInvalidPageToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(kind, payload)) {
+		return errInvalidPageToken
+	}
+
+	err = json.Unmarshal(payload, position)
+	if err != nil {
+		return errInvalidPageToken
+	}
+
+	return nil
+}
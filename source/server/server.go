@@ -3,22 +3,48 @@ package server
 import (
 	"net/http"
 
-	"github.com/ashmeet13/YoutubeDataService/source/common"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
 	"github.com/gorilla/mux"
 )
 
-func Start() {
-	logger := common.GetLogger()
-	serverHandler := NewServerHandler()
+// NewServer returns the HTTP server of the service, the caller starts it and shuts it down.
+// The WebSub callback is only served with a webSubSubscriber.
+func NewServer(apiKeyPool *youtube_handler.APIKeyPool, workerManager WorkerStatesInterface, webSubSubscriber WebSubInterface) *http.Server {
+	serverHandler := NewServerHandler(apiKeyPool, workerManager, webSubSubscriber)
 
 	r := mux.NewRouter()
 
 	r.HandleFunc("/search", serverHandler.SearchHandler).Methods("POST")
+	r.HandleFunc("/suggest", serverHandler.SuggestHandler).Methods("GET")
 	r.HandleFunc("/fetch", serverHandler.NewFetchHandler).Methods("GET")
+	r.HandleFunc("/fetch/{userid}", serverHandler.KeysetFetchHandler).Methods("GET")
 	r.HandleFunc("/fetch/{userid}/{page}", serverHandler.FetchHandler).Methods("GET")
+	r.HandleFunc("/videos/{id}/statistics", serverHandler.VideoStatisticsHandler).Methods("GET")
+	r.HandleFunc("/channels/{id}", serverHandler.ChannelHandler).Methods("GET")
+	r.HandleFunc("/channels/{id}/videos/{userid}/{page}", serverHandler.ChannelVideosHandler).Methods("GET")
 
-	logger.Info("Starting server")
-	if err := http.ListenAndServe(":3000", r); err != nil {
-		logger.WithError(err).Fatal("Failed to start server, exiting")
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(serverHandler.AdminAuthMiddleware)
+
+	// Ingestion queries, the workers pick up changes on their next reload
+	admin.HandleFunc("/queries", serverHandler.ListIngestionQueriesHandler).Methods("GET")
+	admin.HandleFunc("/queries", serverHandler.CreateIngestionQueryHandler).Methods("POST")
+	admin.HandleFunc("/queries/{name}", serverHandler.GetIngestionQueryHandler).Methods("GET")
+	admin.HandleFunc("/queries/{name}", serverHandler.UpdateIngestionQueryHandler).Methods("PUT")
+	admin.HandleFunc("/queries/{name}", serverHandler.DeleteIngestionQueryHandler).Methods("DELETE")
+	admin.HandleFunc("/queries/{name}/pause", serverHandler.PauseIngestionQueryHandler).Methods("POST")
+	admin.HandleFunc("/queries/{name}/resume", serverHandler.ResumeIngestionQueryHandler).Methods("POST")
+
+	admin.HandleFunc("/keys", serverHandler.APIKeysHandler).Methods("GET")
+	admin.HandleFunc("/workers", serverHandler.WorkersHandler).Methods("GET")
+
+	if webSubSubscriber != nil {
+		r.HandleFunc("/websub/callback", serverHandler.WebSubVerificationHandler).Methods("GET")
+		r.HandleFunc("/websub/callback", serverHandler.WebSubNotificationHandler).Methods("POST")
+	}
+
+	return &http.Server{
+		Addr:    ":3000",
+		Handler: r,
 	}
 }
@@ -2,39 +2,117 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ashmeet13/YoutubeDataService/source/common"
 	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/worker"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
-func NewServerHandler() *ServerHandler {
+// WorkerStatesInterface reports the state of the ingestion workers, worker.Manager in production
+type WorkerStatesInterface interface {
+	WorkerStates() []worker.WorkerState
+}
+
+func NewServerHandler(apiKeyPool *youtube_handler.APIKeyPool, workerManager WorkerStatesInterface, webSubSubscriber WebSubInterface) *ServerHandler {
+	config := common.SetupConfiguration()
+
 	return &ServerHandler{
-		config: common.SetupConfiguration(),
+		config:     config,
+		pageTokens: newPageTokenSigner(config.PageTokenSecret),
+
+		videoMetadataHandler: storage.NewVideoMetadataHandler(),
+		userHandler:          storage.NewUserHandler(),
+
+		ingestionQueryHandler: storage.NewIngestionQueryHandler(),
 
-		videoMetadataHandler: storage.NewVideoMetadataImpl(),
-		userHandler:          storage.NewUserImpl(),
+		videoStatisticsSnapshotHandler: storage.NewVideoStatisticsSnapshotHandler(),
+		channelHandler:                 storage.NewChannelHandler(),
+		suggestionHandler:              storage.NewSuggestionHandler(),
+
+		apiKeyPool:       apiKeyPool,
+		workerManager:    workerManager,
+		webSubSubscriber: webSubSubscriber,
 	}
 }
 
 type ServerHandler struct {
 	config               *common.Configuration
+	pageTokens           *pageTokenSigner
 	videoMetadataHandler storage.VideoMetadataInterface
 	userHandler          storage.UserInterface
+
+	ingestionQueryHandler storage.IngestionQueryInterface
+
+	videoStatisticsSnapshotHandler storage.VideoStatisticsSnapshotInterface
+	channelHandler                 storage.ChannelInterface
+	suggestionHandler              storage.SuggestionInterface
+
+	apiKeyPool       *youtube_handler.APIKeyPool
+	workerManager    WorkerStatesInterface
+	webSubSubscriber WebSubInterface
 }
 
-type SearchFilters struct {
+// The most results a page of /search holds
+const maxSearchPageSize = 100
+
+// The most videos a page of the feed holds
+const maxFetchPageSize = 100
+
+// SearchRequest is the body of /search. Query is matched against the title and
+// description, Title only against the title and Description only against the
+// description. A result matches every one of them that is set. Continuation is the Next token of a previous response, it carries the query of
+// that search so the rest of the body is ignored with it.
+type SearchRequest struct {
+	Query       string
 	Title       string
 	Description string
+
+	PublishedAfter  time.Time
+	PublishedBefore time.Time
+	ChannelIDs      []string
+	QueryTags       []string
+
+	// relevance or date, relevance by default when there is a text to score
+	Sort     storage.SearchSort
+	PageSize int
+
+	Continuation string
+
+	// Overrides the highlighting settings of the service for this page
+	Highlight *HighlightOptions
+}
+
+// HighlightOptions are the tags wrapped around matched terms and the length of
+// the fragments of a search result, the configured ones are used when empty
+type HighlightOptions struct {
+	PreTag         string
+	PostTag        string
+	FragmentLength int
+}
+
+// SearchResult is a video matching a search, with the fragments where its terms were found
+type SearchResult struct {
+	*storage.SearchHit
+	Highlights *storage.Highlights `json:",omitempty"`
 }
 
 type SearchResponse struct {
-	Metadata []*storage.VideoMetadata
+	Results []*SearchResult
+
+	// Videos matching the search across every page, ingestion moves it between pages
+	TotalEstimate int64
+
+	// Continuation token of the next page, empty on the last page
+	Next string
 }
 
 type FetchResponse struct {
@@ -43,88 +121,196 @@ type FetchResponse struct {
 	Metadata []*storage.VideoMetadata
 }
 
-// Handles /search request
+// Handles POST /search, a page of the videos matching the query and the filters
 func (h *ServerHandler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 	logger := common.GetLogger()
 	logger.Info("New Search Request")
 
-	var err error
-	var matchedDocs []*storage.VideoMetadata
-
 	// Make sure JSON body is present
 	if r.Header.Get("Content-Type") == "" || r.Header.Get("Content-Type") != "application/json" {
 		logger.Error("Content Type not correct")
-		w.WriteHeader(http.StatusUnsupportedMediaType)
 		msg := "Content-Type header is not application/json"
 		http.Error(w, msg, http.StatusUnsupportedMediaType)
 		return
 	}
 
-	var searchFilters SearchFilters
+	var searchRequest SearchRequest
 
 	// Fetch and decode the JSON Body
-	err = json.NewDecoder(r.Body).Decode(&searchFilters)
+	err := json.NewDecoder(r.Body).Decode(&searchRequest)
 	if err != nil {
 		logger.WithError(err).Error("Failed to read request body")
-		w.WriteHeader(http.StatusBadRequest)
-		msg := "Failed to read request body"
-		http.Error(w, msg, http.StatusBadRequest)
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
 		return
 	}
 
-	// Make sure we have some search parameter
-	if searchFilters.Title == "" && searchFilters.Description == "" {
-		logger.Error("Both title and description are empty")
-		w.WriteHeader(http.StatusBadRequest)
-		http.Error(w, "Title and Description both cannot be empty", http.StatusBadRequest)
+	var query *storage.SearchQuery
+	if searchRequest.Continuation != "" {
+		query = &storage.SearchQuery{}
+		err = h.pageTokens.Decode(searchPageToken, searchRequest.Continuation, query)
+		if err == nil {
+			err = checkSearchContinuation(query)
+		}
+		if err != nil {
+			http.Error(w, "Invalid continuation token", http.StatusBadRequest)
+			return
+		}
+	} else {
+		query, err = h.newSearchQuery(&searchRequest)
+		if err != nil {
+			logger.WithError(err).Error("Invalid search request")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	logger = logger.WithField("Query", query.Text).
+		WithField("Title", query.Title).
+		WithField("Description", query.Description).
+		WithField("Sort", query.Sort)
+
+	// One result more than the page tells whether there is a next page
+	limit := query.Limit
+	query.Limit = limit + 1
+	result, err := h.videoMetadataHandler.SearchMetadata(r.Context(), query)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get data from database")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	query.Limit = limit
 
-	logger = logger.WithField("Title", searchFilters.Title).
-		WithField("Description", searchFilters.Description)
+	response := &SearchResponse{
+		TotalEstimate: result.Total,
+	}
 
-	// Make DB call to search matching titles
-	if searchFilters.Title != "" {
-		logger.Info("Searching data matching the title")
-		titleMatchedDocs, err := h.videoMetadataHandler.FindMetadataTextSearch(searchFilters.Title)
+	hits := result.Hits
+	if int64(len(hits)) > limit {
+		hits = hits[:limit]
+
+		query.After = storage.NewSearchKey(hits[limit-1])
+		next, err := h.pageTokens.Encode(searchPageToken, query)
 		if err != nil {
-			logger.WithError(err).Error("Failed to get data from database")
-			w.WriteHeader(http.StatusInternalServerError)
+			logger.WithError(err).Error("Failed to encode continuation token")
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		matchedDocs = append(matchedDocs, titleMatchedDocs...)
+		response.Next = next
 	}
 
-	// Make DB call to search matching descriptions
-	if searchFilters.Description != "" {
-		logger.Info("Searching data matching the description")
-		desMatchedDocs, err := h.videoMetadataHandler.FindMetadataTextSearch(searchFilters.Description)
-		if err != nil {
-			logger.WithError(err).Error("Failed to get data from database")
-			w.WriteHeader(http.StatusInternalServerError)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+	highlighter := h.newHighlighter(searchRequest.Highlight)
+	for _, hit := range hits {
+		response.Results = append(response.Results, &SearchResult{
+			SearchHit:  hit,
+			Highlights: highlighter.Highlight(query, hit.Metadata),
+		})
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// The highlighter of the service, with the options of the request in place of the configured ones
+func (h *ServerHandler) newHighlighter(options *HighlightOptions) *storage.Highlighter {
+	highlighter := &storage.Highlighter{
+		PreTag:         h.config.SearchHighlightPreTag,
+		PostTag:        h.config.SearchHighlightPostTag,
+		FragmentLength: h.config.SearchFragmentLength,
+	}
+
+	if options != nil {
+		if options.PreTag != "" {
+			highlighter.PreTag = options.PreTag
+		}
+		if options.PostTag != "" {
+			highlighter.PostTag = options.PostTag
+		}
+		if options.FragmentLength > 0 {
+			highlighter.FragmentLength = options.FragmentLength
 		}
-		matchedDocs = append(matchedDocs, desMatchedDocs...)
 	}
+	return highlighter
+}
 
-	// Build and return response
-	response := &SearchResponse{
-		Metadata: matchedDocs,
+// Validates the first page of a search and turns it into the query of the store
+func (h *ServerHandler) newSearchQuery(searchRequest *SearchRequest) (*storage.SearchQuery, error) {
+	query := &storage.SearchQuery{
+		Text:            strings.TrimSpace(searchRequest.Query),
+		Title:           strings.TrimSpace(searchRequest.Title),
+		Description:     strings.TrimSpace(searchRequest.Description),
+		PublishedAfter:  searchRequest.PublishedAfter,
+		PublishedBefore: searchRequest.PublishedBefore,
+		ChannelIDs:      searchRequest.ChannelIDs,
+		QueryTags:       searchRequest.QueryTags,
+		Sort:            searchRequest.Sort,
+		Limit:           int64(searchRequest.PageSize),
 	}
 
-	jsonResponse, err := json.Marshal(response)
-	if err != nil {
-		logger.WithError(err).Error("Error happened in JSON marshal")
-		w.WriteHeader(http.StatusInternalServerError)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	hasText := query.Text != "" || query.Title != "" || query.Description != ""
+
+	if !hasText && query.PublishedAfter.IsZero() && query.PublishedBefore.IsZero() &&
+		len(query.ChannelIDs) == 0 && len(query.QueryTags) == 0 {
+		return nil, errors.New("Query and filters cannot all be empty")
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(jsonResponse)
+	if !query.PublishedAfter.IsZero() && !query.PublishedBefore.IsZero() && query.PublishedAfter.After(query.PublishedBefore) {
+		return nil, errors.New("PublishedAfter cannot be after PublishedBefore")
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = storage.SortByDate
+		if hasText {
+			query.Sort = storage.SortByRelevance
+		}
+	case storage.SortByRelevance:
+		if !hasText {
+			return nil, errors.New("Sort by relevance needs a Query, Title or Description")
+		}
+	case storage.SortByDate:
+	default:
+		return nil, fmt.Errorf("Sort must be %s or %s", storage.SortByRelevance, storage.SortByDate)
+	}
+
+	if query.Limit < 0 {
+		return nil, errors.New("PageSize cannot be negative")
+	}
+	if query.Limit == 0 {
+		query.Limit = int64(h.config.DefaultPageSize)
+	}
+	if query.Limit > maxSearchPageSize {
+		query.Limit = maxSearchPageSize
+	}
+
+	return query, nil
+}
+
+// checkSearchContinuation checks a query decoded from a continuation token is one
+// newSearchQuery could have built
+func checkSearchContinuation(query *storage.SearchQuery) error {
+	if query.Limit <= 0 || query.Limit > maxSearchPageSize {
+		return errInvalidPageToken
+	}
+
+	switch query.Sort {
+	case storage.SortByRelevance, storage.SortByDate:
+	default:
+		return errInvalidPageToken
+	}
+
+	return nil
+}
+
+// feedPageSize is the page size of user, users stored before page sizes were
+// checked may have one out of bounds
+func (h *ServerHandler) feedPageSize(user *storage.User) int64 {
+	pageSize := int64(user.PageSize)
+	if pageSize <= 0 {
+		pageSize = int64(h.config.DefaultPageSize)
+	}
+	if pageSize > maxFetchPageSize {
+		pageSize = maxFetchPageSize
+	}
+	return pageSize
 }
 
 func (h *ServerHandler) NewFetchHandler(w http.ResponseWriter, r *http.Request) {
@@ -137,11 +323,13 @@ func (h *ServerHandler) NewFetchHandler(w http.ResponseWriter, r *http.Request)
 		pageSize = h.config.DefaultPageSize
 	} else {
 		pageSize, err = strconv.Atoi(pagesizeParam)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if err != nil || pageSize <= 0 {
+			http.Error(w, "pagesize must be a positive number", http.StatusBadRequest)
 			return
 		}
+		if pageSize > maxFetchPageSize {
+			pageSize = maxFetchPageSize
+		}
 	}
 
 	userID := r.URL.Query().Get("userid")
@@ -149,9 +337,8 @@ func (h *ServerHandler) NewFetchHandler(w http.ResponseWriter, r *http.Request)
 		userID = uuid.NewString()
 	}
 
-	user, err := h.userHandler.ReadUser(userID)
+	user, err := h.userHandler.ReadUser(r.Context(), userID)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -159,10 +346,10 @@ func (h *ServerHandler) NewFetchHandler(w http.ResponseWriter, r *http.Request)
 	if user != nil {
 		logger.WithField("UserID", userID).Info("Updating User")
 		user.Timestamp = time.Now().UTC()
-		err = h.userHandler.UpdateUser(userID, user)
+		err = h.userHandler.UpdateUser(r.Context(), userID, user)
 	} else {
 		logger.WithField("UserID", userID).Info("Creating User")
-		err = h.userHandler.CreateUser(&storage.User{
+		err = h.userHandler.CreateUser(r.Context(), &storage.User{
 			UserID:    userID,
 			PageSize:  pageSize,
 			Timestamp: time.Now().UTC(),
@@ -170,7 +357,6 @@ func (h *ServerHandler) NewFetchHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -216,7 +402,7 @@ func (h *ServerHandler) FetchHandler(w http.ResponseWriter, r *http.Request) {
 
 	logger.WithField("User", userID).WithField("Page", page).Info("Fetch Request")
 
-	user, err := h.userHandler.ReadUser(userID)
+	user, err := h.userHandler.ReadUser(r.Context(), userID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -230,9 +416,10 @@ func (h *ServerHandler) FetchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	offset := user.PageSize * (page - 1)
+	pageSize := h.feedPageSize(user)
+	offset := pageSize * int64(page-1)
 
-	metadata, err := h.videoMetadataHandler.FetchPagedMetadata(user.Timestamp, int64(offset), int64(user.PageSize))
+	metadata, err := h.videoMetadataHandler.FetchPagedMetadata(r.Context(), user.Timestamp, offset, pageSize)
 	if err != nil {
 		msg := "Failed in fetching page"
 		http.Error(w, msg, http.StatusInternalServerError)
@@ -252,3 +439,132 @@ func (h *ServerHandler) FetchHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonResponse)
 }
+
+type KeysetFetchResponse struct {
+	User     string
+	Metadata []*storage.VideoMetadata
+
+	// Continuation tokens of the pages around this one, empty at either end of the feed
+	Next     string
+	Previous string
+}
+
+// feedPosition is the content of a /fetch/{userid} continuation token, the snapshot
+// timestamp of the feed and the video the page starts after, or ends before
+type feedPosition struct {
+	UserID      string    `json:"u"`
+	Timestamp   time.Time `json:"t"`
+	PublishedAt time.Time `json:"p"`
+	VideoID     string    `json:"v"`
+	Before      bool      `json:"b,omitempty"`
+}
+
+// Handles GET /fetch/{userid}, the feed of a user paged on continuation tokens instead
+// of page numbers. Without the continuation parameter it returns the first page as of
+// the timestamp of the user, the tokens of a page keep that timestamp.
+func (h *ServerHandler) KeysetFetchHandler(w http.ResponseWriter, r *http.Request) {
+	logger := common.GetLogger()
+
+	userID := mux.Vars(r)["userid"]
+	if userID == "" {
+		http.Error(w, "userid is missing in parameters", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userHandler.ReadUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if user == nil {
+		http.Error(w, fmt.Sprintf("Could not find user with userid %s", userID), http.StatusBadRequest)
+		return
+	}
+
+	timestamp := user.Timestamp
+	var key *storage.FeedKey
+	before := false
+
+	if token := r.URL.Query().Get("continuation"); token != "" {
+		var position feedPosition
+		err = h.pageTokens.Decode(feedPageToken, token, &position)
+		if err != nil || position.UserID != userID {
+			http.Error(w, "Invalid continuation token", http.StatusBadRequest)
+			return
+		}
+
+		timestamp = position.Timestamp
+		key = &storage.FeedKey{PublishedAt: position.PublishedAt, VideoID: position.VideoID}
+		before = position.Before
+	}
+
+	logger.WithField("User", userID).WithField("Before", before).Info("Keyset Fetch Request")
+
+	// One video more than the page tells whether there is another page in the direction read
+	limit := h.feedPageSize(user)
+	metadata, err := h.videoMetadataHandler.FetchMetadataPageByKey(r.Context(), timestamp, key, before, limit+1)
+	if err != nil {
+		logger.WithError(err).Error("Failed in fetching page")
+		http.Error(w, "Failed in fetching page", http.StatusInternalServerError)
+		return
+	}
+
+	more := int64(len(metadata)) > limit
+	if more && before {
+		metadata = metadata[int64(len(metadata))-limit:]
+	} else if more {
+		metadata = metadata[:limit]
+	}
+
+	response := &KeysetFetchResponse{
+		User:     userID,
+		Metadata: metadata,
+	}
+
+	if len(metadata) > 0 {
+		// A page read from a key has that key on its other side
+		hasNext := (more && !before) || (key != nil && before)
+		hasPrevious := (more && before) || (key != nil && !before)
+
+		if hasNext {
+			response.Next, err = h.pageTokens.Encode(feedPageToken, newFeedPosition(userID, timestamp, metadata[len(metadata)-1], false))
+		}
+		if err == nil && hasPrevious {
+			response.Previous, err = h.pageTokens.Encode(feedPageToken, newFeedPosition(userID, timestamp, metadata[0], true))
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to encode continuation token")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+func newFeedPosition(userID string, timestamp time.Time, metadata *storage.VideoMetadata, before bool) *feedPosition {
+	return &feedPosition{
+		UserID:      userID,
+		Timestamp:   timestamp,
+		PublishedAt: metadata.PublishedAt,
+		VideoID:     metadata.VideoID,
+		Before:      before,
+	}
+}
+
+// Handles GET /admin/keys, the quota estimates of the API keys
+func (h *ServerHandler) APIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, h.apiKeyPool.Snapshot())
+}
+
+type WorkersResponse struct {
+	Workers []worker.WorkerState
+}
+
+// Handles GET /admin/workers, the state of every running ingestion worker
+func (h *ServerHandler) WorkersHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, &WorkersResponse{
+		Workers: h.workerManager.WorkerStates(),
+	})
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
@@ -13,6 +14,8 @@ import (
 	"github.com/ashmeet13/YoutubeDataService/source/common"
 	"github.com/ashmeet13/YoutubeDataService/source/storage"
 	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	"github.com/ashmeet13/YoutubeDataService/source/worker"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/require"
@@ -46,7 +49,12 @@ func (s *ServerHandlerSuite) SetupSuite() {
 
 		config: &common.Configuration{
 			DefaultPageSize: 25,
+
+			SearchHighlightPreTag:  "<em>",
+			SearchHighlightPostTag: "</em>",
+			SearchFragmentLength:   150,
 		},
+		pageTokens: newPageTokenSigner("secret"),
 	}
 }
 
@@ -80,41 +88,51 @@ func (s *ServerHandlerSuite) TestSearchHandler_NoBody() {
 	s.Equal("Failed to read request body\n", string(message))
 }
 
-func (s *ServerHandlerSuite) TestSearchHandler_EmptyBody() {
-	testSearchFilters := &SearchFilters{
-		Title:       "",
-		Description: "",
-	}
-
-	jsonRequest, _ := json.Marshal(testSearchFilters)
+func (s *ServerHandlerSuite) search(searchRequest *SearchRequest) *httptest.ResponseRecorder {
+	jsonRequest, _ := json.Marshal(searchRequest)
 
 	req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewBuffer(jsonRequest))
 	req.Header.Set("Content-Type", "application/json")
-
 	res := httptest.NewRecorder()
 
 	s.serverHandler.SearchHandler(res, req)
+	return res
+}
+
+func (s *ServerHandlerSuite) TestSearchHandler_EmptyBody() {
+	res := s.search(&SearchRequest{})
 
 	message, _ := ioutil.ReadAll(res.Body)
 
 	s.Equal(http.StatusBadRequest, res.Code)
-	s.Equal("Title and Description both cannot be empty\n", string(message))
+	s.Equal("Query and filters cannot all be empty\n", string(message))
 }
 
-func (s *ServerHandlerSuite) TestSearchHandler_DBFail() {
-	testSearchFilters := &SearchFilters{
-		Title:       "test_title",
-		Description: "test_description",
+func (s *ServerHandlerSuite) TestSearchHandler_InvalidRequest() {
+	testCases := []struct {
+		searchRequest *SearchRequest
+		message       string
+	}{
+		{&SearchRequest{ChannelIDs: []string{"channel"}, Sort: storage.SortByRelevance}, "Sort by relevance needs a Query, Title or Description\n"},
+		{&SearchRequest{Query: "cricket", Sort: "views"}, "Sort must be relevance or date\n"},
+		{&SearchRequest{Query: "cricket", PageSize: -1}, "PageSize cannot be negative\n"},
+		{&SearchRequest{Query: "cricket", PublishedAfter: time.Now(), PublishedBefore: time.Now().Add(-time.Hour)}, "PublishedAfter cannot be after PublishedBefore\n"},
+		{&SearchRequest{Continuation: "forged.token"}, "Invalid continuation token\n"},
 	}
 
-	jsonRequest, _ := json.Marshal(testSearchFilters)
+	for _, testCase := range testCases {
+		res := s.search(testCase.searchRequest)
 
-	req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewBuffer(jsonRequest))
-	req.Header.Set("Content-Type", "application/json")
-	res := httptest.NewRecorder()
+		message, _ := ioutil.ReadAll(res.Body)
 
-	s.mockVideoMetadataStore.EXPECT().FindMetadataTextSearch("test_title").Return(nil, errors.New("dummy test error"))
-	s.serverHandler.SearchHandler(res, req)
+		s.Equal(http.StatusBadRequest, res.Code)
+		s.Equal(testCase.message, string(message))
+	}
+}
+
+func (s *ServerHandlerSuite) TestSearchHandler_DBFail() {
+	s.mockVideoMetadataStore.EXPECT().SearchMetadata(gomock.Any(), gomock.Any()).Return(nil, errors.New("dummy test error"))
+	res := s.search(&SearchRequest{Query: "test_title"})
 
 	message, _ := ioutil.ReadAll(res.Body)
 
@@ -123,45 +141,152 @@ func (s *ServerHandlerSuite) TestSearchHandler_DBFail() {
 }
 
 func (s *ServerHandlerSuite) TestSearchHandler_Ok() {
-	testSearchFilters := &SearchFilters{
-		Title:       "test_title",
-		Description: "test_description",
+	publishedAfter := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	hits := []*storage.SearchHit{
+		{Metadata: &storage.VideoMetadata{VideoID: "123", Title: "test_title1"}, Score: 2},
+		{Metadata: &storage.VideoMetadata{VideoID: "456", Title: "test_title2"}, Score: 1.5},
+		{Metadata: &storage.VideoMetadata{VideoID: "789", Title: "test_title3"}, Score: 1},
 	}
 
-	jsonRequest, _ := json.Marshal(testSearchFilters)
+	// Title and Description are searched in their own field, sorted on relevance by default
+	s.mockVideoMetadataStore.EXPECT().SearchMetadata(gomock.Any(), &storage.SearchQuery{
+		Text:           "test",
+		Title:          "test_title",
+		Description:    "test_description",
+		PublishedAfter: publishedAfter,
+		ChannelIDs:     []string{"channel"},
+		QueryTags:      []string{"sports"},
+		Sort:           storage.SortByRelevance,
+		Limit:          3,
+	}).Return(&storage.SearchResult{Hits: hits, Total: 7}, nil)
+
+	res := s.search(&SearchRequest{
+		Query:          "test",
+		Title:          "test_title",
+		Description:    "test_description",
+		PublishedAfter: publishedAfter,
+		ChannelIDs:     []string{"channel"},
+		QueryTags:      []string{"sports"},
+		PageSize:       2,
+	})
 
-	req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewBuffer(jsonRequest))
-	req.Header.Set("Content-Type", "application/json")
-	res := httptest.NewRecorder()
+	var response SearchResponse
+	_ = json.NewDecoder(res.Body).Decode(&response)
+
+	s.Equal(http.StatusOK, res.Code)
+	s.Len(response.Results, 2)
+	s.Equal("123", response.Results[0].Metadata.VideoID)
+	s.Equal(2.0, response.Results[0].Score)
+	s.Equal("456", response.Results[1].Metadata.VideoID)
+	s.Equal(int64(7), response.TotalEstimate)
+	s.NotEmpty(response.Next)
+
+	// The next page carries the search, the rest of the body is ignored
+	s.mockVideoMetadataStore.EXPECT().SearchMetadata(gomock.Any(), &storage.SearchQuery{
+		Text:           "test",
+		Title:          "test_title",
+		Description:    "test_description",
+		PublishedAfter: publishedAfter,
+		ChannelIDs:     []string{"channel"},
+		QueryTags:      []string{"sports"},
+		Sort:           storage.SortByRelevance,
+		After:          &storage.SearchKey{Score: 1.5, VideoID: "456"},
+		Limit:          3,
+	}).Return(&storage.SearchResult{Hits: hits[2:], Total: 7}, nil)
+
+	res = s.search(&SearchRequest{Query: "ignored", Continuation: response.Next})
+
+	response = SearchResponse{}
+	_ = json.NewDecoder(res.Body).Decode(&response)
+
+	s.Equal(http.StatusOK, res.Code)
+	s.Len(response.Results, 1)
+	s.Equal("789", response.Results[0].Metadata.VideoID)
+	s.Empty(response.Next)
+}
+
+func (s *ServerHandlerSuite) TestSearchHandler_InvalidContinuation() {
+	feedToken, err := s.serverHandler.pageTokens.Encode(feedPageToken, &feedPosition{UserID: "12345", Timestamp: time.Now().UTC()})
+	s.NoError(err)
+
+	tokens := []string{"garbage", feedToken}
+	for _, query := range []*storage.SearchQuery{
+		{Text: "test", Sort: storage.SortByRelevance},
+		{Text: "test", Sort: storage.SortByRelevance, Limit: maxSearchPageSize + 1},
+		{Text: "test", Sort: "views", Limit: 2},
+	} {
+		token, err := s.serverHandler.pageTokens.Encode(searchPageToken, query)
+		s.NoError(err)
+		tokens = append(tokens, token)
+	}
+
+	for _, token := range tokens {
+		res := s.search(&SearchRequest{Continuation: token})
+		message, _ := ioutil.ReadAll(res.Body)
 
-	returnMetadata1 := &storage.VideoMetadata{
-		Title:       "test_title1",
-		Description: "test_description1",
-		VideoID:     "123",
+		s.Equal(http.StatusBadRequest, res.Code)
+		s.Equal("Invalid continuation token\n", string(message))
 	}
+}
 
-	returnMetadata2 := &storage.VideoMetadata{
-		Title:       "test_title2",
-		Description: "test_description2",
-		VideoID:     "456",
+func (s *ServerHandlerSuite) TestSearchHandler_Highlights() {
+	hits := []*storage.SearchHit{
+		{Metadata: &storage.VideoMetadata{VideoID: "123", Title: "Cricket final", Description: "Highlights of the cricket final"}, Score: 2},
+		{Metadata: &storage.VideoMetadata{VideoID: "456", Title: "Football", Description: "Nothing matches here"}, Score: 1},
 	}
+	s.mockVideoMetadataStore.EXPECT().SearchMetadata(gomock.Any(), gomock.Any()).Return(&storage.SearchResult{Hits: hits, Total: 2}, nil).Times(2)
 
-	s.mockVideoMetadataStore.EXPECT().FindMetadataTextSearch("test_title").Return([]*storage.VideoMetadata{returnMetadata1}, nil)
-	s.mockVideoMetadataStore.EXPECT().FindMetadataTextSearch("test_description").Return([]*storage.VideoMetadata{returnMetadata2}, nil)
-	s.serverHandler.SearchHandler(res, req)
+	res := s.search(&SearchRequest{Query: "cricket", Title: "final"})
 
 	var response SearchResponse
 	_ = json.NewDecoder(res.Body).Decode(&response)
 
 	s.Equal(http.StatusOK, res.Code)
-	s.Equal(2, len(response.Metadata))
-	s.Equal("test_title1", response.Metadata[0].Title)
-	s.Equal("test_description1", response.Metadata[0].Description)
-	s.Equal("123", response.Metadata[0].VideoID)
+	s.Equal("123", response.Results[0].Metadata.VideoID)
+	s.Equal([]string{"<em>Cricket</em> <em>final</em>"}, response.Results[0].Highlights.Title)
+	s.Equal([]string{"Highlights of the <em>cricket</em> final"}, response.Results[0].Highlights.Description)
+	s.Nil(response.Results[1].Highlights)
+
+	// Options of the request replace the configured ones
+	res = s.search(&SearchRequest{Query: "cricket", Highlight: &HighlightOptions{PreTag: "**", PostTag: "**", FragmentLength: 20}})
+
+	response = SearchResponse{}
+	_ = json.NewDecoder(res.Body).Decode(&response)
+
+	s.Equal([]string{"**Cricket** final"}, response.Results[0].Highlights.Title)
+	s.Equal([]string{"of the **cricket** final"}, response.Results[0].Highlights.Description)
+}
+
+func (s *ServerHandlerSuite) TestSearchHandler_TitleOnly() {
+	// A title alone is a text to rank on
+	s.mockVideoMetadataStore.EXPECT().SearchMetadata(gomock.Any(), &storage.SearchQuery{
+		Title: "final",
+		Sort:  storage.SortByRelevance,
+		Limit: 26,
+	}).Return(&storage.SearchResult{}, nil)
+
+	res := s.search(&SearchRequest{Title: " final "})
+
+	s.Equal(http.StatusOK, res.Code)
+}
 
-	s.Equal("test_title2", response.Metadata[1].Title)
-	s.Equal("test_description2", response.Metadata[1].Description)
-	s.Equal("456", response.Metadata[1].VideoID)
+func (s *ServerHandlerSuite) TestSearchHandler_FiltersOnly() {
+	// Without a text the results are sorted on date, the page size is capped
+	s.mockVideoMetadataStore.EXPECT().SearchMetadata(gomock.Any(), &storage.SearchQuery{
+		QueryTags: []string{"sports"},
+		Sort:      storage.SortByDate,
+		Limit:     maxSearchPageSize + 1,
+	}).Return(&storage.SearchResult{}, nil)
+
+	res := s.search(&SearchRequest{QueryTags: []string{"sports"}, PageSize: 1000})
+
+	var response SearchResponse
+	_ = json.NewDecoder(res.Body).Decode(&response)
+
+	s.Equal(http.StatusOK, res.Code)
+	s.Empty(response.Results)
+	s.Empty(response.Next)
 }
 
 func (s *ServerHandlerSuite) TestNewFetchHandler_Ok() {
@@ -169,8 +294,8 @@ func (s *ServerHandlerSuite) TestNewFetchHandler_Ok() {
 	res := httptest.NewRecorder()
 
 	// Mocking new user behaviour
-	s.mockUserStore.EXPECT().ReadUser("12345").Return(nil, nil)
-	s.mockUserStore.EXPECT().CreateUser(gomock.Any()).Return(nil)
+	s.mockUserStore.EXPECT().ReadUser(gomock.Any(), "12345").Return(nil, nil)
+	s.mockUserStore.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil)
 
 	s.serverHandler.NewFetchHandler(res, req)
 
@@ -183,20 +308,39 @@ func (s *ServerHandlerSuite) TestNewFetchHandler_Ok() {
 }
 
 func (s *ServerHandlerSuite) TestNewFetchHandler_WrongPageSize() {
-	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/fetch?userid=12345&pagesize=ab", nil)
+	for _, pageSize := range []string{"ab", "0", "-1"} {
+		req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/fetch?userid=12345&pagesize="+pageSize, nil)
+		res := httptest.NewRecorder()
+
+		s.serverHandler.NewFetchHandler(res, req)
+
+		message, _ := ioutil.ReadAll(res.Body)
+		s.Equal(http.StatusBadRequest, res.Code, pageSize)
+		s.Equal("pagesize must be a positive number\n", string(message))
+	}
+}
+
+func (s *ServerHandlerSuite) TestNewFetchHandler_CapsPageSize() {
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/fetch?userid=12345&pagesize=100000", nil)
 	res := httptest.NewRecorder()
 
+	s.mockUserStore.EXPECT().ReadUser(gomock.Any(), "12345").Return(nil, nil)
+	s.mockUserStore.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user *storage.User) error {
+		s.Equal(maxFetchPageSize, user.PageSize)
+		return nil
+	})
+
 	s.serverHandler.NewFetchHandler(res, req)
 
-	s.Equal(http.StatusInternalServerError, res.Code)
+	s.Equal(http.StatusOK, res.Code)
 }
 
 func (s *ServerHandlerSuite) TestNewFetchHandler_OK_ExistingUser() {
 	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/fetch?userid=12345", nil)
 	res := httptest.NewRecorder()
 
-	s.mockUserStore.EXPECT().ReadUser("12345").Return(&storage.User{UserID: "12345"}, nil)
-	s.mockUserStore.EXPECT().UpdateUser("12345", gomock.Any()).Return(nil)
+	s.mockUserStore.EXPECT().ReadUser(gomock.Any(), "12345").Return(&storage.User{UserID: "12345"}, nil)
+	s.mockUserStore.EXPECT().UpdateUser(gomock.Any(), "12345", gomock.Any()).Return(nil)
 
 	s.serverHandler.NewFetchHandler(res, req)
 
@@ -234,8 +378,8 @@ func (s *ServerHandlerSuite) TestFetchHandler_OK() {
 		},
 	}
 
-	s.mockUserStore.EXPECT().ReadUser("12345").Return(user, nil)
-	s.mockVideoMetadataStore.EXPECT().FetchPagedMetadata(user.Timestamp, int64(5), int64(5)).Return(metadata, nil)
+	s.mockUserStore.EXPECT().ReadUser(gomock.Any(), "12345").Return(user, nil)
+	s.mockVideoMetadataStore.EXPECT().FetchPagedMetadata(gomock.Any(), user.Timestamp, int64(5), int64(5)).Return(metadata, nil)
 
 	s.serverHandler.FetchHandler(res, req)
 
@@ -249,3 +393,152 @@ func (s *ServerHandlerSuite) TestFetchHandler_OK() {
 	s.Equal("abc", response.Metadata[0].VideoID)
 	s.Equal("def", response.Metadata[1].VideoID)
 }
+
+func (s *ServerHandlerSuite) keysetFetch(userID string, continuation string) (*httptest.ResponseRecorder, *KeysetFetchResponse) {
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/fetch/"+userID+"?continuation="+continuation, nil)
+	req = mux.SetURLVars(req, map[string]string{"userid": userID})
+	res := httptest.NewRecorder()
+
+	s.serverHandler.KeysetFetchHandler(res, req)
+
+	var response KeysetFetchResponse
+	_ = json.NewDecoder(res.Body).Decode(&response)
+	return res, &response
+}
+
+func (s *ServerHandlerSuite) TestKeysetFetchHandler_Pages() {
+	user := &storage.User{
+		UserID:    "12345",
+		PageSize:  2,
+		Timestamp: time.Now().UTC().Truncate(time.Second),
+	}
+	publishedAt := user.Timestamp.Add(-time.Hour)
+
+	first := []*storage.VideoMetadata{
+		{VideoID: "a", PublishedAt: publishedAt},
+		{VideoID: "b", PublishedAt: publishedAt},
+		{VideoID: "c", PublishedAt: publishedAt},
+	}
+
+	s.mockUserStore.EXPECT().ReadUser(gomock.Any(), "12345").Return(user, nil).Times(3)
+	s.mockVideoMetadataStore.EXPECT().FetchMetadataPageByKey(gomock.Any(), user.Timestamp, nil, false, int64(3)).Return(first, nil)
+
+	res, response := s.keysetFetch("12345", "")
+	s.Equal(http.StatusOK, res.Code)
+	s.Equal([]string{"a", "b"}, []string{response.Metadata[0].VideoID, response.Metadata[1].VideoID})
+	s.NotEmpty(response.Next)
+	s.Empty(response.Previous)
+
+	// The next page starts after b, as of the timestamp of the first page
+	s.mockVideoMetadataStore.EXPECT().FetchMetadataPageByKey(gomock.Any(), user.Timestamp, &storage.FeedKey{PublishedAt: publishedAt, VideoID: "b"}, false, int64(3)).
+		Return(first[2:], nil)
+
+	res, response = s.keysetFetch("12345", response.Next)
+	s.Equal(http.StatusOK, res.Code)
+	s.Len(response.Metadata, 1)
+	s.Empty(response.Next)
+	s.NotEmpty(response.Previous)
+
+	// The previous page ends before c
+	s.mockVideoMetadataStore.EXPECT().FetchMetadataPageByKey(gomock.Any(), user.Timestamp, &storage.FeedKey{PublishedAt: publishedAt, VideoID: "c"}, true, int64(3)).
+		Return(first[:2], nil)
+
+	res, response = s.keysetFetch("12345", response.Previous)
+	s.Equal(http.StatusOK, res.Code)
+	s.Len(response.Metadata, 2)
+	s.NotEmpty(response.Next)
+	s.Empty(response.Previous)
+}
+
+func (s *ServerHandlerSuite) TestKeysetFetchHandler_StoredPageSizeOutOfBounds() {
+	user := &storage.User{UserID: "12345", PageSize: -1, Timestamp: time.Now().UTC()}
+	s.mockUserStore.EXPECT().ReadUser(gomock.Any(), "12345").Return(user, nil)
+
+	// The default page size is used instead
+	s.mockVideoMetadataStore.EXPECT().FetchMetadataPageByKey(gomock.Any(), user.Timestamp, nil, false, int64(26)).
+		Return([]*storage.VideoMetadata{{VideoID: "a", PublishedAt: user.Timestamp}}, nil)
+
+	res, response := s.keysetFetch("12345", "")
+	s.Equal(http.StatusOK, res.Code)
+	s.Len(response.Metadata, 1)
+}
+
+func (s *ServerHandlerSuite) TestKeysetFetchHandler_InvalidToken() {
+	user := &storage.User{UserID: "12345", PageSize: 2, Timestamp: time.Now().UTC()}
+	s.mockUserStore.EXPECT().ReadUser(gomock.Any(), "12345").Return(user, nil).Times(4)
+
+	res, _ := s.keysetFetch("12345", "garbage")
+	s.Equal(http.StatusBadRequest, res.Code)
+
+	// Signed with another secret
+	forged, err := newPageTokenSigner("other").Encode(feedPageToken, &feedPosition{UserID: "12345", Timestamp: time.Now().UTC()})
+	s.NoError(err)
+	res, _ = s.keysetFetch("12345", forged)
+	s.Equal(http.StatusBadRequest, res.Code)
+
+	// Issued to another user
+	token, err := s.serverHandler.pageTokens.Encode(feedPageToken, &feedPosition{UserID: "67890", Timestamp: time.Now().UTC()})
+	s.NoError(err)
+	res, _ = s.keysetFetch("12345", token)
+	s.Equal(http.StatusBadRequest, res.Code)
+
+	// Issued for a search
+	token, err = s.serverHandler.pageTokens.Encode(searchPageToken, &feedPosition{UserID: "12345", Timestamp: time.Now().UTC()})
+	s.NoError(err)
+	res, _ = s.keysetFetch("12345", token)
+	s.Equal(http.StatusBadRequest, res.Code)
+}
+
+func (s *ServerHandlerSuite) TestAPIKeysHandler_OK() {
+	pool := youtube_handler.NewAPIKeyPool([]string{"first-key", "second-key"}, 1000)
+	pool.Record("first-key", youtube_handler.SearchListCost)
+
+	serverHandler := &ServerHandler{apiKeyPool: pool}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/keys", nil)
+	res := httptest.NewRecorder()
+
+	serverHandler.APIKeysHandler(res, req)
+
+	s.Equal(http.StatusOK, res.Code)
+
+	var snapshot youtube_handler.APIKeyPoolSnapshot
+	s.NoError(json.NewDecoder(res.Body).Decode(&snapshot))
+	s.Equal(int64(1000), snapshot.DailyQuota)
+	s.Len(snapshot.Keys, 2)
+	s.Equal("****-key", snapshot.Keys[0].Key)
+	s.Equal(int64(youtube_handler.SearchListCost), snapshot.Keys[0].UnitsUsed)
+	s.True(snapshot.Keys[0].Current)
+}
+
+type fakeWorkerManager struct {
+	states []worker.WorkerState
+}
+
+func (m *fakeWorkerManager) WorkerStates() []worker.WorkerState {
+	return m.states
+}
+
+func (s *ServerHandlerSuite) TestWorkersHandler_OK() {
+	serverHandler := &ServerHandler{
+		workerManager: &fakeWorkerManager{
+			states: []worker.WorkerState{
+				{Name: "cricket", Query: "cricket", Status: worker.WorkerStatusRunning},
+				{Name: "tennis", Query: "tennis", Status: worker.WorkerStatusDegraded, ConsecutiveFailures: 3, LastError: "dummy test error"},
+			},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/workers", nil)
+	res := httptest.NewRecorder()
+
+	serverHandler.WorkersHandler(res, req)
+
+	s.Equal(http.StatusOK, res.Code)
+
+	var response WorkersResponse
+	s.NoError(json.NewDecoder(res.Body).Decode(&response))
+	s.Len(response.Workers, 2)
+	s.Equal(worker.WorkerStatusDegraded, response.Workers[1].Status)
+	s.Equal(3, response.Workers[1].ConsecutiveFailures)
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+)
+
+// Suggestions returned by /suggest without a limit, and the most it returns
+const (
+	defaultSuggestionLimit = 10
+	maxSuggestionLimit     = 50
+)
+
+type SuggestResponse struct {
+	Suggestions []*storage.TitleSuggestion
+}
+
+// Handles GET /suggest?q=<text>&limit=<limit>, the titles completing text as it is typed
+func (h *ServerHandler) SuggestHandler(w http.ResponseWriter, r *http.Request) {
+	logger := common.GetLogger()
+
+	text := r.URL.Query().Get("q")
+	if text == "" {
+		http.Error(w, "q is missing in parameters", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultSuggestionLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit > maxSuggestionLimit {
+		limit = maxSuggestionLimit
+	}
+
+	suggestions, err := h.suggestionHandler.SuggestTitles(r.Context(), text, limit)
+	if err != nil {
+		logger.WithError(err).WithField("Text", text).Error("Failed to suggest titles")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, &SuggestResponse{
+		Suggestions: suggestions,
+	})
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuggestionHandlerSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	mockSuggestionStore *mock_storage.MockSuggestionInterface
+	serverHandler       *ServerHandler
+}
+
+func TestSuggestionHandlerSuite(t *testing.T) {
+	suite.Run(t, new(SuggestionHandlerSuite))
+}
+
+func (s *SuggestionHandlerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.mockSuggestionStore = mock_storage.NewMockSuggestionInterface(s.ctrl)
+
+	s.serverHandler = &ServerHandler{
+		suggestionHandler: s.mockSuggestionStore,
+	}
+}
+
+func (s *SuggestionHandlerSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *SuggestionHandlerSuite) suggest(target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	res := httptest.NewRecorder()
+	s.serverHandler.SuggestHandler(res, req)
+	return res
+}
+
+func (s *SuggestionHandlerSuite) TestSuggestHandler_Ok() {
+	s.mockSuggestionStore.EXPECT().SuggestTitles(gomock.Any(), "crick", defaultSuggestionLimit).
+		Return([]*storage.TitleSuggestion{{VideoID: "123", Title: "Cricket highlights", Grams: []string{" cr"}}}, nil)
+
+	res := s.suggest("/suggest?q=crick")
+	s.Equal(http.StatusOK, res.Code)
+
+	var response SuggestResponse
+	s.NoError(json.NewDecoder(res.Body).Decode(&response))
+	s.Len(response.Suggestions, 1)
+	s.Equal("123", response.Suggestions[0].VideoID)
+	s.Equal("Cricket highlights", response.Suggestions[0].Title)
+	s.Empty(response.Suggestions[0].Grams)
+}
+
+func (s *SuggestionHandlerSuite) TestSuggestHandler_Limit() {
+	s.mockSuggestionStore.EXPECT().SuggestTitles(gomock.Any(), "crick", 3).Return(nil, nil)
+	s.Equal(http.StatusOK, s.suggest("/suggest?q=crick&limit=3").Code)
+
+	// The limit is capped
+	s.mockSuggestionStore.EXPECT().SuggestTitles(gomock.Any(), "crick", maxSuggestionLimit).Return(nil, nil)
+	s.Equal(http.StatusOK, s.suggest("/suggest?q=crick&limit=1000").Code)
+
+	res := s.suggest("/suggest?q=crick&limit=none")
+	message, _ := ioutil.ReadAll(res.Body)
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("limit must be a positive number\n", string(message))
+}
+
+func (s *SuggestionHandlerSuite) TestSuggestHandler_MissingText() {
+	res := s.suggest("/suggest")
+	message, _ := ioutil.ReadAll(res.Body)
+
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Equal("q is missing in parameters\n", string(message))
+}
+
+func (s *SuggestionHandlerSuite) TestSuggestHandler_DBFail() {
+	s.mockSuggestionStore.EXPECT().SuggestTitles(gomock.Any(), "crick", defaultSuggestionLimit).Return(nil, errors.New("dummy test error"))
+
+	res := s.suggest("/suggest?q=crick")
+	message, _ := ioutil.ReadAll(res.Body)
+
+	s.Equal(http.StatusInternalServerError, res.Code)
+	s.Equal("dummy test error\n", string(message))
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/gorilla/mux"
+)
+
+type VideoStatisticsResponse struct {
+	VideoID   string
+	Snapshots []*storage.VideoStatisticsSnapshot
+}
+
+// Handles GET /videos/{id}/statistics, the snapshots of the statistics of a video oldest first
+func (h *ServerHandler) VideoStatisticsHandler(w http.ResponseWriter, r *http.Request) {
+	logger := common.GetLogger()
+
+	videoID := mux.Vars(r)["id"]
+	if videoID == "" {
+		http.Error(w, "id is missing in parameters", http.StatusBadRequest)
+		return
+	}
+
+	videoMetadata, err := h.videoMetadataHandler.FindOneMetadataWithVideoID(r.Context(), videoID)
+	if err != nil {
+		logger.WithError(err).WithField("VideoID", videoID).Error("Failed to read video metadata")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if videoMetadata == nil {
+		http.Error(w, fmt.Sprintf("Could not find video with id %s", videoID), http.StatusNotFound)
+		return
+	}
+
+	snapshots, err := h.videoStatisticsSnapshotHandler.FindSnapshotsWithVideoID(r.Context(), videoID)
+	if err != nil {
+		logger.WithError(err).WithField("VideoID", videoID).Error("Failed to read video statistics snapshots")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, &VideoStatisticsResponse{
+		VideoID:   videoID,
+		Snapshots: snapshots,
+	})
+}
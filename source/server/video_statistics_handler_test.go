@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type VideoStatisticsHandlerSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	mockVideoMetadataStore           *mock_storage.MockVideoMetadataInterface
+	mockVideoStatisticsSnapshotStore *mock_storage.MockVideoStatisticsSnapshotInterface
+	serverHandler                    *ServerHandler
+}
+
+func TestVideoStatisticsHandlerSuite(t *testing.T) {
+	suite.Run(t, new(VideoStatisticsHandlerSuite))
+}
+
+func (s *VideoStatisticsHandlerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.mockVideoMetadataStore = mock_storage.NewMockVideoMetadataInterface(s.ctrl)
+	s.mockVideoStatisticsSnapshotStore = mock_storage.NewMockVideoStatisticsSnapshotInterface(s.ctrl)
+
+	s.serverHandler = &ServerHandler{
+		videoMetadataHandler:           s.mockVideoMetadataStore,
+		videoStatisticsSnapshotHandler: s.mockVideoStatisticsSnapshotStore,
+	}
+}
+
+func (s *VideoStatisticsHandlerSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *VideoStatisticsHandlerSuite) newRequest(videoID string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/videos/"+videoID+"/statistics", nil)
+	return mux.SetURLVars(req, map[string]string{"id": videoID})
+}
+
+func (s *VideoStatisticsHandlerSuite) TestVideoStatisticsHandler_Ok() {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	s.mockVideoMetadataStore.EXPECT().FindOneMetadataWithVideoID(gomock.Any(), "video").Return(&storage.VideoMetadata{VideoID: "video"}, nil)
+	s.mockVideoStatisticsSnapshotStore.EXPECT().FindSnapshotsWithVideoID(gomock.Any(), "video").Return([]*storage.VideoStatisticsSnapshot{
+		{VideoID: "video", Timestamp: now.Add(-time.Hour), ViewCount: 10},
+		{VideoID: "video", Timestamp: now, ViewCount: 25},
+	}, nil)
+
+	res := httptest.NewRecorder()
+	s.serverHandler.VideoStatisticsHandler(res, s.newRequest("video"))
+	s.Equal(http.StatusOK, res.Code)
+
+	var response VideoStatisticsResponse
+	s.NoError(json.NewDecoder(res.Body).Decode(&response))
+	s.Equal("video", response.VideoID)
+	s.Len(response.Snapshots, 2)
+	s.Equal(int64(10), response.Snapshots[0].ViewCount)
+	s.Equal(int64(25), response.Snapshots[1].ViewCount)
+}
+
+func (s *VideoStatisticsHandlerSuite) TestVideoStatisticsHandler_NotFound() {
+	s.mockVideoMetadataStore.EXPECT().FindOneMetadataWithVideoID(gomock.Any(), "video").Return(nil, nil)
+
+	res := httptest.NewRecorder()
+	s.serverHandler.VideoStatisticsHandler(res, s.newRequest("video"))
+	s.Equal(http.StatusNotFound, res.Code)
+}
+
+func (s *VideoStatisticsHandlerSuite) TestVideoStatisticsHandler_Error() {
+	s.mockVideoMetadataStore.EXPECT().FindOneMetadataWithVideoID(gomock.Any(), "video").Return(&storage.VideoMetadata{VideoID: "video"}, nil)
+	s.mockVideoStatisticsSnapshotStore.EXPECT().FindSnapshotsWithVideoID(gomock.Any(), "video").Return(nil, errors.New("error"))
+
+	res := httptest.NewRecorder()
+	s.serverHandler.VideoStatisticsHandler(res, s.newRequest("video"))
+	s.Equal(http.StatusInternalServerError, res.Code)
+}
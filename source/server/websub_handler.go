@@ -0,0 +1,77 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/websub"
+)
+
+// Largest notification read, a feed of a single upload is around 1KB
+const maxNotificationSize = 1 << 20
+
+// WebSubInterface handles what the WebSub hub sends to the callback, worker.WebSubSubscriber in production
+type WebSubInterface interface {
+	Verify(mode string, topic string, leaseSeconds int) bool
+	Ingest(ctx context.Context, videoMetadataList []*storage.VideoMetadata) error
+}
+
+// Handles GET /websub/callback, the hub verifying a subscription request. The
+// challenge is echoed back to confirm it, a 404 refuses it.
+func (h *ServerHandler) WebSubVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	mode := query.Get("hub.mode")
+	topic := query.Get("hub.topic")
+
+	leaseSeconds, _ := strconv.Atoi(query.Get("hub.lease_seconds"))
+
+	if !h.webSubSubscriber.Verify(mode, topic, leaseSeconds) {
+		http.Error(w, "Subscription not wanted", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if mode != websub.DeniedMode {
+		w.Write([]byte(query.Get("hub.challenge")))
+	}
+}
+
+// Handles POST /websub/callback, a notification pushed by the hub. Notifications
+// with an invalid signature are acknowledged and ignored, as WebSub requires.
+// Failing to store the videos answers a 500 so the hub delivers them again.
+func (h *ServerHandler) WebSubNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	logger := common.GetLogger()
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxNotificationSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !websub.VerifySignature(h.config.WebSubSecret, body, r.Header.Get(websub.SignatureHeader)) {
+		logger.Warn("Ignoring WebSub notification with an invalid signature")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	videoMetadataList, err := websub.ParseFeed(bytes.NewReader(body))
+	if err != nil {
+		logger.WithError(err).Error("Failed to parse WebSub notification")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.webSubSubscriber.Ingest(r.Context(), videoMetadataList)
+	if err != nil {
+		logger.WithError(err).Error("Failed to store WebSub notification")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
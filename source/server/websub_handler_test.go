@@ -0,0 +1,142 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/websub"
+	"github.com/ashmeet13/YoutubeDataService/source/websub/fakehub"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeWebSubSubscriber struct {
+	topics    map[string]bool
+	ingestErr error
+
+	leaseSeconds int
+	ingested     []*storage.VideoMetadata
+}
+
+func (f *fakeWebSubSubscriber) Verify(mode string, topic string, leaseSeconds int) bool {
+	f.leaseSeconds = leaseSeconds
+	return mode == websub.DeniedMode || f.topics[topic]
+}
+
+func (f *fakeWebSubSubscriber) Ingest(ctx context.Context, videoMetadataList []*storage.VideoMetadata) error {
+	f.ingested = append(f.ingested, videoMetadataList...)
+	return f.ingestErr
+}
+
+type WebSubHandlerSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	subscriber    *fakeWebSubSubscriber
+	serverHandler *ServerHandler
+	publishedAt   time.Time
+}
+
+func TestWebSubHandlerSuite(t *testing.T) {
+	suite.Run(t, new(WebSubHandlerSuite))
+}
+
+func (s *WebSubHandlerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.subscriber = &fakeWebSubSubscriber{topics: map[string]bool{websub.TopicURL("UCchannel"): true}}
+	s.serverHandler = &ServerHandler{
+		config:           &common.Configuration{WebSubSecret: "secret"},
+		webSubSubscriber: s.subscriber,
+	}
+	s.publishedAt = time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *WebSubHandlerSuite) verify(mode string, topic string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/websub/callback", nil)
+	query := req.URL.Query()
+	query.Set("hub.mode", mode)
+	query.Set("hub.topic", topic)
+	query.Set("hub.challenge", "challenge")
+	query.Set("hub.lease_seconds", "86400")
+	req.URL.RawQuery = query.Encode()
+
+	res := httptest.NewRecorder()
+	s.serverHandler.WebSubVerificationHandler(res, req)
+	return res
+}
+
+func (s *WebSubHandlerSuite) notify(body []byte, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/websub/callback", bytes.NewReader(body))
+	req.Header.Set(websub.SignatureHeader, signature)
+
+	res := httptest.NewRecorder()
+	s.serverHandler.WebSubNotificationHandler(res, req)
+	return res
+}
+
+func (s *WebSubHandlerSuite) feed() []byte {
+	return fakehub.Feed(fakehub.Entry{VideoID: "abc", ChannelID: "UCchannel", ChannelTitle: "Cricket TV", Title: "Highlights", PublishedAt: s.publishedAt})
+}
+
+func (s *WebSubHandlerSuite) TestWebSubVerificationHandler_Confirmed() {
+	res := s.verify(websub.SubscribeMode, websub.TopicURL("UCchannel"))
+	s.Equal(http.StatusOK, res.Code)
+	s.Equal("challenge", res.Body.String())
+	s.Equal(86400, s.subscriber.leaseSeconds)
+}
+
+func (s *WebSubHandlerSuite) TestWebSubVerificationHandler_Refused() {
+	res := s.verify(websub.SubscribeMode, websub.TopicURL("UCother"))
+	s.Equal(http.StatusNotFound, res.Code)
+	s.NotContains(res.Body.String(), "challenge")
+}
+
+func (s *WebSubHandlerSuite) TestWebSubVerificationHandler_Denied() {
+	res := s.verify(websub.DeniedMode, websub.TopicURL("UCchannel"))
+	s.Equal(http.StatusOK, res.Code)
+	s.Empty(res.Body.String())
+}
+
+func (s *WebSubHandlerSuite) TestWebSubNotificationHandler_Ok() {
+	feed := s.feed()
+
+	res := s.notify(feed, websub.Sign("secret", feed))
+	s.Equal(http.StatusNoContent, res.Code)
+	s.Equal([]*storage.VideoMetadata{
+		{VideoID: "abc", Title: "Highlights", ChannelID: "UCchannel", ChannelTitle: "Cricket TV", PublishedAt: s.publishedAt},
+	}, s.subscriber.ingested)
+}
+
+func (s *WebSubHandlerSuite) TestWebSubNotificationHandler_InvalidSignature() {
+	feed := s.feed()
+
+	for _, signature := range []string{"", websub.Sign("other", feed)} {
+		res := s.notify(feed, signature)
+		s.Equal(http.StatusNoContent, res.Code)
+	}
+	s.Empty(s.subscriber.ingested)
+}
+
+func (s *WebSubHandlerSuite) TestWebSubNotificationHandler_InvalidFeed() {
+	body := []byte("not a feed")
+
+	res := s.notify(body, websub.Sign("secret", body))
+	s.Equal(http.StatusBadRequest, res.Code)
+	s.Empty(s.subscriber.ingested)
+}
+
+func (s *WebSubHandlerSuite) TestWebSubNotificationHandler_IngestFailure() {
+	s.subscriber.ingestErr = errors.New("dummy test error")
+	feed := s.feed()
+
+	res := s.notify(feed, websub.Sign("secret", feed))
+	s.Equal(http.StatusInternalServerError, res.Code)
+}
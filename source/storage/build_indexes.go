@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"sync"
 
+	"github.com/ashmeet13/YoutubeDataService/source/common"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/x/bsonx"
@@ -13,6 +15,20 @@ var onceIndex sync.Once
 
 func BuildIndexes(ctx context.Context) {
 	onceIndex.Do(func() {
+		switch common.GetConfiguration().StorageDriver {
+		case common.MemoryStorageDriver:
+			// The memory driver has nothing to index
+			return
+		case common.SqliteStorageDriver:
+			// Indexes are part of the sqlite schema migrations
+			err := MigrateSqlite(ctx, GetSqliteDB())
+			if err != nil {
+				common.GetLogger().WithError(err).Fatal("Failed to migrate sqlite database")
+			}
+			backfillTitleSuggestions(ctx)
+			return
+		}
+
 		db := GetDatabase()
 
 		db.Collection(UserC).Indexes().CreateOne(ctx, mongo.IndexModel{
@@ -22,28 +38,140 @@ func BuildIndexes(ctx context.Context) {
 			Options: options.Index().SetUnique(true).SetBackground(true),
 		})
 
-		db.Collection(VideoMetadataC).Indexes().CreateOne(ctx, mongo.IndexModel{
+		db.Collection(CheckpointC).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bsonx.Doc{
+				{Key: "query_id", Value: bsonx.Int32(1)},
+			},
+			Options: options.Index().SetUnique(true).SetBackground(true),
+		})
+
+		db.Collection(IngestionQueryC).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bsonx.Doc{
+				{Key: "name", Value: bsonx.Int32(1)},
+			},
+			Options: options.Index().SetUnique(true).SetBackground(true),
+		})
+
+		db.Collection(VideoStatisticsSnapshotC).Indexes().CreateOne(ctx, mongo.IndexModel{
 			Keys: bsonx.Doc{
 				{Key: "video_id", Value: bsonx.Int32(1)},
+				{Key: "timestamp", Value: bsonx.Int32(1)},
+			},
+			Options: options.Index().SetUnique(true).SetBackground(true),
+		})
+
+		db.Collection(ChannelC).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bsonx.Doc{
+				{Key: "channel_id", Value: bsonx.Int32(1)},
+			},
+			Options: options.Index().SetUnique(true).SetBackground(true),
+		})
+
+		// Leases are taken with an upsert, the unique index refuses a second holder
+		db.Collection(LeaseC).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bsonx.Doc{
+				{Key: "name", Value: bsonx.Int32(1)},
+			},
+			Options: options.Index().SetUnique(true).SetBackground(true),
+		})
+
+		// Titles are indexed with an upsert on video_id, and looked up on their grams
+		db.Collection(TitleSuggestionC).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bsonx.Doc{
+				{Key: "video_id", Value: bsonx.Int32(1)},
+			},
+			Options: options.Index().SetUnique(true).SetBackground(true),
+		})
+		db.Collection(TitleSuggestionC).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bsonx.Doc{
+				{Key: "grams", Value: bsonx.Int32(1)},
 			},
 			Options: options.Index().SetUnique(false).SetBackground(true),
 		})
 
+		// Ingestion upserts on video_id, the unique index keeps concurrent workers from
+		// inserting a video twice, so the service does not start without it. Older
+		// deployments have a non unique video_id_1 index, MongoDB refuses a second
+		// index on the same key so it is dropped first.
+		_, err := db.Collection(VideoMetadataC).Indexes().DropOne(ctx, "video_id_1")
+		if err != nil && !isIndexNotFoundError(err) {
+			common.GetLogger().WithError(err).Fatal("Failed to drop the non unique video_id index")
+		}
+
+		_, err = db.Collection(VideoMetadataC).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bsonx.Doc{
+				{Key: "video_id", Value: bsonx.Int32(1)},
+			},
+			Options: options.Index().SetName("video_id_unique").SetUnique(true).SetBackground(true),
+		})
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				common.GetLogger().WithError(err).Fatal("Failed to build the unique video_id index, remove the duplicate videos and restart")
+			}
+			common.GetLogger().WithError(err).Fatal("Failed to build the unique video_id index")
+		}
+
 		db.Collection(VideoMetadataC).Indexes().CreateOne(ctx, mongo.IndexModel{
 			Keys: bsonx.Doc{
 				{Key: "published_at", Value: bsonx.Int32(-1)},
 			},
 			Options: options.Index().SetUnique(false).SetBackground(true),
 		})
+
+		// Keyset pages of the feed, ties on the publish time are broken on the video id
 		db.Collection(VideoMetadataC).Indexes().CreateOne(ctx, mongo.IndexModel{
 			Keys: bsonx.Doc{
-				{Key: "title", Value: bsonx.String("text")},
+				{Key: "published_at", Value: bsonx.Int32(-1)},
+				{Key: "video_id", Value: bsonx.Int32(1)},
+			},
+			Options: options.Index().SetUnique(false).SetBackground(true),
+		})
+
+		// Channel feeds, newest first
+		db.Collection(VideoMetadataC).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bsonx.Doc{
+				{Key: "channel_id", Value: bsonx.Int32(1)},
+				{Key: "published_at", Value: bsonx.Int32(-1)},
 			},
+			Options: options.Index().SetUnique(false).SetBackground(true),
 		})
+
+		// A collection can only have one text index, so title and description
+		// share one, weighted so title matches score higher. Older deployments
+		// created a title only index, then an unweighted one, both block the
+		// weighted one from being built so they are dropped first.
+		db.Collection(VideoMetadataC).Indexes().DropOne(ctx, "title_text")
+		db.Collection(VideoMetadataC).Indexes().DropOne(ctx, "title_text_description_text")
 		db.Collection(VideoMetadataC).Indexes().CreateOne(ctx, mongo.IndexModel{
 			Keys: bsonx.Doc{
+				{Key: "title", Value: bsonx.String("text")},
 				{Key: "description", Value: bsonx.String("text")},
 			},
+			Options: options.Index().SetName("title_description_weighted_text").SetWeights(bsonx.Doc{
+				{Key: "title", Value: bsonx.Int32(titleWeight)},
+				{Key: "description", Value: bsonx.Int32(descriptionWeight)},
+			}),
 		})
+
+		backfillTitleSuggestions(ctx)
 	})
 }
+
+// Whether err is MongoDB answering that the index to drop does not exist, or its
+// collection does not on a new deployment
+func isIndexNotFoundError(err error) bool {
+	var commandErr mongo.CommandError
+	if !errors.As(err, &commandErr) {
+		return false
+	}
+	return commandErr.Name == "IndexNotFound" || commandErr.Name == "NamespaceNotFound" ||
+		commandErr.Code == 27 || commandErr.Code == 26
+}
+
+// Indexes the titles of the videos stored before the title index existed
+func backfillTitleSuggestions(ctx context.Context) {
+	err := BackfillTitleSuggestions(ctx, NewVideoMetadataHandler(), NewSuggestionHandler(), NewCheckpointHandler())
+	if err != nil {
+		common.GetLogger().WithError(err).Error("Failed to index the titles of stored videos, retrying on the next start")
+	}
+}
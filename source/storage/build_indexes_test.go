@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsIndexNotFoundError(t *testing.T) {
+	require.True(t, isIndexNotFoundError(mongo.CommandError{Code: 27, Name: "IndexNotFound"}))
+	require.True(t, isIndexNotFoundError(fmt.Errorf("drop: %w", mongo.CommandError{Code: 26, Name: "NamespaceNotFound"})))
+
+	require.False(t, isIndexNotFoundError(mongo.CommandError{Code: 13, Name: "Unauthorized"}))
+	require.False(t, isIndexNotFoundError(errors.New("connection refused")))
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:generate mockgen --destination=./mock_storage/channel.go github.com/ashmeet13/YoutubeDataService/source/storage ChannelInterface
+type ChannelInterface interface {
+	// Inserts the channel, or overwrites the one with the same ChannelID
+	UpsertChannel(ctx context.Context, channel *Channel) error
+	ReadChannel(ctx context.Context, id string) (*Channel, error)
+}
+
+func NewChannelImpl() *ChannelImpl {
+	return &ChannelImpl{
+		collection: ChannelC,
+	}
+}
+
+type ChannelImpl struct {
+	collection string
+}
+
+func (c *ChannelImpl) UpsertChannel(ctx context.Context, channel *Channel) error {
+	filters := bson.M{
+		"channel_id": bson.M{"$eq": channel.ChannelID},
+	}
+
+	modifier := bson.M{
+		"$set": channel,
+	}
+
+	_, err := UpdateOne(ctx, c.collection, filters, modifier, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *ChannelImpl) ReadChannel(ctx context.Context, id string) (*Channel, error) {
+	query := bson.M{
+		"channel_id": bson.M{"$eq": id},
+	}
+
+	result := FindOne(ctx, c.collection, query)
+
+	var decodedResult Channel
+	err := result.Decode(&decodedResult)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &decodedResult, nil
+}
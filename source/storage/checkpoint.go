@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:generate mockgen --destination=./mock_storage/checkpoint.go github.com/ashmeet13/YoutubeDataService/source/storage CheckpointInterface
+type CheckpointInterface interface {
+	ReadCheckpoint(ctx context.Context, queryID string) (*Checkpoint, error)
+	SaveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error
+}
+
+func NewCheckpointImpl() *CheckpointImpl {
+	return &CheckpointImpl{
+		collection: CheckpointC,
+	}
+}
+
+type CheckpointImpl struct {
+	collection string
+}
+
+func (c *CheckpointImpl) ReadCheckpoint(ctx context.Context, queryID string) (*Checkpoint, error) {
+	query := bson.M{
+		"query_id": bson.M{"$eq": queryID},
+	}
+
+	result := FindOne(ctx, c.collection, query)
+
+	var decodedResult Checkpoint
+	err := result.Decode(&decodedResult)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &decodedResult, nil
+}
+
+// SaveCheckpoint creates the checkpoint of the query or overwrites the existing one
+func (c *CheckpointImpl) SaveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error {
+	filters := bson.M{
+		"query_id": bson.M{"$eq": checkpoint.QueryID},
+	}
+
+	modifier := bson.M{
+		"$set": checkpoint,
+	}
+
+	_, err := UpdateOne(ctx, c.collection, filters, modifier, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/storagetest"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	storagetest.RunConformanceSuite(t, func(t *testing.T) *storagetest.Backend {
+		store := storage.NewMemoryStore()
+
+		return &storagetest.Backend{
+			VideoMetadata: storage.NewMemoryVideoMetadataImplWithStore(store),
+			User:          storage.NewMemoryUserImplWithStore(store),
+			Checkpoint:    storage.NewMemoryCheckpointImplWithStore(store),
+
+			IngestionQuery: storage.NewMemoryIngestionQueryImplWithStore(store),
+
+			VideoStatisticsSnapshot: storage.NewMemoryVideoStatisticsSnapshotImplWithStore(store),
+			Channel:                 storage.NewMemoryChannelImplWithStore(store),
+			Lease:                   storage.NewMemoryLeaseImplWithStore(store),
+			Suggestion:              storage.NewMemorySuggestionImplWithStore(store),
+		}
+	})
+}
+
+func TestSqliteConformance(t *testing.T) {
+	storagetest.RunConformanceSuite(t, func(t *testing.T) *storagetest.Backend {
+		db, err := storage.OpenSqliteDB(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		require.NoError(t, storage.MigrateSqlite(context.Background(), db))
+
+		return &storagetest.Backend{
+			VideoMetadata: storage.NewSqliteVideoMetadataImplWithDB(db),
+			User:          storage.NewSqliteUserImplWithDB(db),
+			Checkpoint:    storage.NewSqliteCheckpointImplWithDB(db),
+
+			IngestionQuery: storage.NewSqliteIngestionQueryImplWithDB(db),
+
+			VideoStatisticsSnapshot: storage.NewSqliteVideoStatisticsSnapshotImplWithDB(db),
+			Channel:                 storage.NewSqliteChannelImplWithDB(db),
+			Lease:                   storage.NewSqliteLeaseImplWithDB(db),
+			Suggestion:              storage.NewSqliteSuggestionImplWithDB(db),
+		}
+	})
+}
+
+// The mongo driver needs a live server, set MONGO_TEST_URL to run it.
+// The tests use the youtube_data_conformance database and empty it between tests.
+func TestMongoConformance(t *testing.T) {
+	mongoURL := os.Getenv("MONGO_TEST_URL")
+	if mongoURL == "" {
+		t.Skip("MONGO_TEST_URL is not set")
+	}
+
+	t.Setenv(common.StorageDriver, common.MongoStorageDriver)
+	t.Setenv(common.MongoBaseURL, mongoURL)
+	t.Setenv(common.MongoDatabaseName, "youtube_data_conformance")
+	t.Setenv(common.YoutubeAPIKeys, "conformance")
+	t.Setenv(common.YoutubeQuery, "conformance")
+
+	ctx := context.Background()
+	storage.Init()
+	storage.BuildIndexes(ctx)
+
+	storagetest.RunConformanceSuite(t, func(t *testing.T) *storagetest.Backend {
+		for _, collection := range []string{storage.VideoMetadataC, storage.UserC, storage.CheckpointC, storage.IngestionQueryC, storage.VideoStatisticsSnapshotC, storage.ChannelC, storage.LeaseC, storage.TitleSuggestionC} {
+			_, err := storage.GetCollection(collection).DeleteMany(ctx, bson.M{})
+			require.NoError(t, err)
+		}
+
+		return &storagetest.Backend{
+			VideoMetadata: storage.NewVideoMetadataImpl(),
+			User:          storage.NewUserImpl(),
+			Checkpoint:    storage.NewCheckpointImpl(),
+
+			IngestionQuery: storage.NewIngestionQueryImpl(),
+
+			VideoStatisticsSnapshot: storage.NewVideoStatisticsSnapshotImpl(),
+			Channel:                 storage.NewChannelImpl(),
+			Lease:                   storage.NewLeaseImpl(),
+			Suggestion:              storage.NewSuggestionImpl(),
+		}
+	})
+}
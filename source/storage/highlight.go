@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// The most fragments returned for a field
+const maxHighlightFragments = 3
+
+// Highlights are the fragments of the title and the description of a search
+// result where the terms of the search were found, empty for a field without any
+type Highlights struct {
+	Title       []string
+	Description []string
+}
+
+/*
+Highlighter marks the terms of a search in its results. It works on the videos
+returned by any backend, the terms are found the way textSearch finds them, so
+stemmed forms are marked too and stop words never are.
+
+A field shorter than FragmentLength characters is a single fragment. Longer ones
+are cut into up to 3 fragments of about FragmentLength characters around the
+terms, on word boundaries. Each term is wrapped in PreTag and PostTag. When the
+tags are HTML the text of the video is HTML escaped around them, so a fragment is
+safe to render as HTML, otherwise it is returned as stored.
+*/
+type Highlighter struct {
+	PreTag         string
+	PostTag        string
+	FragmentLength int
+}
+
+// Highlight returns the fragments of metadata matching query, nil when none of its
+// terms are found. Title terms are only marked in the title, description terms
+// only in the description.
+func (h *Highlighter) Highlight(query *SearchQuery, metadata *VideoMetadata) *Highlights {
+	titleTerms := highlightTerms(query.Text, query.Title)
+	descriptionTerms := highlightTerms(query.Text, query.Description)
+
+	highlights := &Highlights{
+		Title:       h.fragments(metadata.Title, titleTerms),
+		Description: h.fragments(metadata.Description, descriptionTerms),
+	}
+
+	if len(highlights.Title) == 0 && len(highlights.Description) == 0 {
+		return nil
+	}
+	return highlights
+}
+
+// The stemmed terms of the texts, negated terms are not highlighted
+func highlightTerms(texts ...string) map[string]bool {
+	terms := map[string]bool{}
+	for _, text := range texts {
+		for _, term := range newTextSearch(text).terms {
+			terms[stem(term)] = true
+		}
+	}
+	return terms
+}
+
+// wordSpan is a word of a text, as rune offsets
+type wordSpan struct {
+	start int
+	end   int
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// The words of text that are one of the terms
+func matchedWords(text []rune, terms map[string]bool) []wordSpan {
+	var matches []wordSpan
+	for start := 0; start < len(text); {
+		if !isWordRune(text[start]) {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(text) && isWordRune(text[end]) {
+			end++
+		}
+
+		word := strings.ToLower(string(text[start:end]))
+		if !stopWords[word] && terms[stem(word)] {
+			matches = append(matches, wordSpan{start: start, end: end})
+		}
+		start = end
+	}
+	return matches
+}
+
+func (h *Highlighter) fragments(field string, terms map[string]bool) []string {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	text := []rune(field)
+	matches := matchedWords(text, terms)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	if len(text) <= h.FragmentLength {
+		return []string{h.mark(text, 0, len(text), matches)}
+	}
+
+	var fragments []string
+	for len(matches) > 0 && len(fragments) < maxHighlightFragments {
+		match := matches[0]
+
+		// Center the fragment on the first match it holds
+		start := match.start - (h.FragmentLength-(match.end-match.start))/2
+		if start > len(text)-h.FragmentLength {
+			start = len(text) - h.FragmentLength
+		}
+		if start < 0 {
+			start = 0
+		}
+		if start > match.start {
+			// The word alone is longer than a fragment
+			start = match.start
+		}
+
+		end := start + h.FragmentLength
+		if end < match.end {
+			end = match.end
+		}
+
+		// Don't cut words in half at either end
+		for start < match.start && start > 0 && isWordRune(text[start-1]) {
+			start++
+		}
+		for end > match.end && end < len(text) && isWordRune(text[end]) {
+			end--
+		}
+		for start < match.start && unicode.IsSpace(text[start]) {
+			start++
+		}
+		for end > match.end && unicode.IsSpace(text[end-1]) {
+			end--
+		}
+
+		held := 0
+		for held < len(matches) && matches[held].end <= end {
+			held++
+		}
+
+		fragments = append(fragments, h.mark(text, start, end, matches[:held]))
+		matches = matches[held:]
+	}
+	return fragments
+}
+
+// Whether both tags are HTML elements, the text around them must then be escaped
+func (h *Highlighter) htmlTags() bool {
+	return isHTMLTag(h.PreTag) && isHTMLTag(h.PostTag)
+}
+
+func isHTMLTag(tag string) bool {
+	tag = strings.TrimSpace(tag)
+	return strings.HasPrefix(tag, "<") && strings.HasSuffix(tag, ">")
+}
+
+// mark returns text[start:end] with the matches wrapped in the tags
+func (h *Highlighter) mark(text []rune, start, end int, matches []wordSpan) string {
+	escape := func(text []rune) string { return string(text) }
+	if h.htmlTags() {
+		escape = func(text []rune) string { return html.EscapeString(string(text)) }
+	}
+
+	var fragment strings.Builder
+	position := start
+	for _, match := range matches {
+		fragment.WriteString(escape(text[position:match.start]))
+		fragment.WriteString(h.PreTag)
+		fragment.WriteString(escape(text[match.start:match.end]))
+		fragment.WriteString(h.PostTag)
+		position = match.end
+	}
+	fragment.WriteString(escape(text[position:end]))
+	return fragment.String()
+}
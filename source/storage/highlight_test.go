@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type HighlighterSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	highlighter *Highlighter
+}
+
+func TestHighlighterSuite(t *testing.T) {
+	suite.Run(t, new(HighlighterSuite))
+}
+
+func (s *HighlighterSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.highlighter = &Highlighter{PreTag: "<em>", PostTag: "</em>", FragmentLength: 40}
+}
+
+func (s *HighlighterSuite) TestHighlight_ShortFields() {
+	highlights := s.highlighter.Highlight(&SearchQuery{Text: "cricket matches"}, &VideoMetadata{
+		Title:       "Cricket: the match of the year",
+		Description: "Watch every Match",
+	})
+
+	s.Equal([]string{"<em>Cricket</em>: the <em>match</em> of the year"}, highlights.Title)
+	s.Equal([]string{"Watch every <em>Match</em>"}, highlights.Description)
+}
+
+func (s *HighlighterSuite) TestHighlight_EscapesAroundHTMLTags() {
+	metadata := &VideoMetadata{Title: `<b>"final"</b> & more`}
+
+	highlights := s.highlighter.Highlight(&SearchQuery{Text: "final"}, metadata)
+	s.Equal([]string{`&lt;b&gt;&#34;<em>final</em>&#34;&lt;/b&gt; &amp; more`}, highlights.Title)
+
+	// Plain delimiters leave the text as stored
+	plain := &Highlighter{PreTag: "[", PostTag: "]", FragmentLength: 40}
+	highlights = plain.Highlight(&SearchQuery{Text: "final"}, metadata)
+	s.Equal([]string{`<b>"[final]"</b> & more`}, highlights.Title)
+}
+
+func (s *HighlighterSuite) TestHighlight_ScopedTerms() {
+	metadata := &VideoMetadata{Title: "Final replay", Description: "Before the final"}
+
+	highlights := s.highlighter.Highlight(&SearchQuery{Title: "final", Description: "replay"}, metadata)
+	s.Equal([]string{"<em>Final</em> replay"}, highlights.Title)
+	s.Empty(highlights.Description)
+
+	// Stop words and negated terms are not marked
+	s.Nil(s.highlighter.Highlight(&SearchQuery{Text: "the -final"}, metadata))
+}
+
+func (s *HighlighterSuite) TestHighlight_Fragments() {
+	description := "Opening words that do not matter at all. " +
+		"The bowler takes a wicket in the first over. " +
+		strings.Repeat("Filler text about the crowd. ", 10) +
+		"Another wicket falls right before lunch."
+
+	highlights := s.highlighter.Highlight(&SearchQuery{Text: "wickets"}, &VideoMetadata{Description: description})
+
+	s.Len(highlights.Description, 2)
+	for _, fragment := range highlights.Description {
+		s.Contains(fragment, "<em>wicket</em>")
+		s.LessOrEqual(len(strings.ReplaceAll(strings.ReplaceAll(fragment, "<em>", ""), "</em>", "")), 40)
+
+		// Fragments hold whole words
+		s.Contains(description, strings.ReplaceAll(strings.ReplaceAll(fragment, "<em>", ""), "</em>", ""))
+		s.NotEqual(" ", fragment[:1])
+	}
+	s.Equal("bowler takes a <em>wicket</em> in the first", highlights.Description[0])
+}
+
+func (s *HighlighterSuite) TestHighlight_MaxFragments() {
+	description := strings.Repeat("A goal is scored after a long build up play. ", 10)
+
+	highlights := s.highlighter.Highlight(&SearchQuery{Text: "goal"}, &VideoMetadata{Description: description})
+	s.Len(highlights.Description, maxHighlightFragments)
+}
+
+func (s *HighlighterSuite) TestHighlight_LongWord() {
+	s.highlighter.FragmentLength = 5
+
+	highlights := s.highlighter.Highlight(&SearchQuery{Text: "supercalifragilistic"}, &VideoMetadata{
+		Description: "A supercalifragilistic day out at the ground",
+	})
+	s.Equal([]string{"<em>supercalifragilistic</em>"}, highlights.Description)
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrIngestionQueryExists is returned when creating a query with a name that is already taken
+var ErrIngestionQueryExists = errors.New("ingestion query already exists")
+
+//go:generate mockgen --destination=./mock_storage/ingestion_query.go github.com/ashmeet13/YoutubeDataService/source/storage IngestionQueryInterface
+type IngestionQueryInterface interface {
+	CreateIngestionQuery(ctx context.Context, ingestionQuery *IngestionQuery) error
+	ReadIngestionQuery(ctx context.Context, name string) (*IngestionQuery, error)
+	ListIngestionQueries(ctx context.Context) ([]*IngestionQuery, error)
+	UpdateIngestionQuery(ctx context.Context, name string, ingestionQuery *IngestionQuery) error
+	DeleteIngestionQuery(ctx context.Context, name string) error
+}
+
+func NewIngestionQueryImpl() *IngestionQueryImpl {
+	return &IngestionQueryImpl{
+		collection: IngestionQueryC,
+	}
+}
+
+type IngestionQueryImpl struct {
+	collection string
+}
+
+func (q *IngestionQueryImpl) CreateIngestionQuery(ctx context.Context, ingestionQuery *IngestionQuery) error {
+	_, err := InsertOne(ctx, q.collection, ingestionQuery)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrIngestionQueryExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (q *IngestionQueryImpl) ReadIngestionQuery(ctx context.Context, name string) (*IngestionQuery, error) {
+	query := bson.M{
+		"name": bson.M{"$eq": name},
+	}
+
+	result := FindOne(ctx, q.collection, query)
+
+	var decodedResult IngestionQuery
+	err := result.Decode(&decodedResult)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &decodedResult, nil
+}
+
+// ListIngestionQueries returns every query ordered by name
+func (q *IngestionQueryImpl) ListIngestionQueries(ctx context.Context) ([]*IngestionQuery, error) {
+	queryOpts := &options.FindOptions{
+		Sort: bson.M{"name": 1},
+	}
+
+	cur, err := Find(ctx, q.collection, bson.M{}, queryOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cur.Close(ctx)
+
+	var ingestionQueries []*IngestionQuery
+	for cur.Next(ctx) {
+		var ingestionQuery IngestionQuery
+		err := cur.Decode(&ingestionQuery)
+		if err != nil {
+			return nil, err
+		}
+		ingestionQueries = append(ingestionQueries, &ingestionQuery)
+	}
+
+	return ingestionQueries, cur.Err()
+}
+
+// Overwrites the query with name, does nothing if there is no such query
+func (q *IngestionQueryImpl) UpdateIngestionQuery(ctx context.Context, name string, ingestionQuery *IngestionQuery) error {
+	updated := *ingestionQuery
+	updated.Name = name
+
+	filters := bson.M{
+		"name": bson.M{"$eq": name},
+	}
+
+	modifier := bson.M{
+		"$set": &updated,
+	}
+
+	_, err := UpdateOne(ctx, q.collection, filters, modifier)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *IngestionQueryImpl) DeleteIngestionQuery(ctx context.Context, name string) error {
+	filters := bson.M{
+		"name": bson.M{"$eq": name},
+	}
+
+	_, err := DeleteOne(ctx, q.collection, filters)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
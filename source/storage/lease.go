@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Leases expire on the clock of the instances taking them, so the clocks of the
+// instances must drift apart by much less than the duration of a lease.
+//
+//go:generate mockgen --destination=./mock_storage/lease.go github.com/ashmeet13/YoutubeDataService/source/storage LeaseInterface
+type LeaseInterface interface {
+	// Takes the lease for holder until now + duration if it is free, expired or
+	// already held by holder. Returns false while another holder has it.
+	AcquireLease(ctx context.Context, name string, holder string, duration time.Duration) (bool, error)
+	// Frees the lease if holder has it, so another holder can take it at once
+	ReleaseLease(ctx context.Context, name string, holder string) error
+}
+
+func NewLeaseImpl() *LeaseImpl {
+	return &LeaseImpl{
+		collection: LeaseC,
+	}
+}
+
+type LeaseImpl struct {
+	collection string
+}
+
+// The filter only matches a lease that can be taken. Otherwise the upsert tries to
+// insert a second lease with the same name, which the unique index on name refuses.
+func (l *LeaseImpl) AcquireLease(ctx context.Context, name string, holder string, duration time.Duration) (bool, error) {
+	now := time.Now().UTC()
+
+	filters := bson.M{
+		"name": bson.M{"$eq": name},
+		"$or": bson.A{
+			bson.M{"holder": bson.M{"$eq": holder}},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+
+	modifier := bson.M{
+		"$set": bson.M{
+			"holder":     holder,
+			"expires_at": now.Add(duration),
+		},
+	}
+
+	_, err := UpdateOne(ctx, l.collection, filters, modifier, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (l *LeaseImpl) ReleaseLease(ctx context.Context, name string, holder string) error {
+	filters := bson.M{
+		"name":   bson.M{"$eq": name},
+		"holder": bson.M{"$eq": holder},
+	}
+
+	_, err := DeleteOne(ctx, l.collection, filters)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
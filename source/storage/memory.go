@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"sync"
+)
+
+var memoryStoreOnce sync.Once
+var memoryStore *MemoryStore
+
+// MemoryStore holds every collection of the memory storage driver. A single
+// store is shared by all memory handlers so the server and the worker see the
+// same data, just like they would with a database.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	videoMetadata map[string]*VideoMetadata
+	users         map[string]*User
+	checkpoints   map[string]*Checkpoint
+
+	ingestionQueries map[string]*IngestionQuery
+
+	// Snapshots of every video, keyed by video id
+	videoStatisticsSnapshots map[string][]*VideoStatisticsSnapshot
+
+	channels map[string]*Channel
+
+	leases map[string]*Lease
+
+	// Indexed titles keyed by video id, and the video ids of every gram
+	titleSuggestions map[string]*TitleSuggestion
+	titleGrams       map[string]map[string]bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		videoMetadata: map[string]*VideoMetadata{},
+		users:         map[string]*User{},
+		checkpoints:   map[string]*Checkpoint{},
+
+		ingestionQueries: map[string]*IngestionQuery{},
+
+		videoStatisticsSnapshots: map[string][]*VideoStatisticsSnapshot{},
+
+		channels: map[string]*Channel{},
+
+		leases: map[string]*Lease{},
+
+		titleSuggestions: map[string]*TitleSuggestion{},
+		titleGrams:       map[string]map[string]bool{},
+	}
+}
+
+// GetMemoryStore returns the process wide store used by the memory driver
+func GetMemoryStore() *MemoryStore {
+	memoryStoreOnce.Do(func() {
+		memoryStore = NewMemoryStore()
+	})
+	return memoryStore
+}
+
+func copyVideoMetadata(videoMetadata *VideoMetadata) *VideoMetadata {
+	copied := *videoMetadata
+	copied.QueryTags = append([]string(nil), videoMetadata.QueryTags...)
+	copied.Tags = append([]string(nil), videoMetadata.Tags...)
+	return &copied
+}
+
+func copyUser(user *User) *User {
+	copied := *user
+	return &copied
+}
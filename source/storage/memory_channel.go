@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+)
+
+func NewMemoryChannelImpl() *MemoryChannelImpl {
+	return NewMemoryChannelImplWithStore(GetMemoryStore())
+}
+
+func NewMemoryChannelImplWithStore(store *MemoryStore) *MemoryChannelImpl {
+	return &MemoryChannelImpl{
+		store: store,
+	}
+}
+
+// MemoryChannelImpl implements ChannelInterface on top of a MemoryStore
+type MemoryChannelImpl struct {
+	store *MemoryStore
+}
+
+func (c *MemoryChannelImpl) UpsertChannel(ctx context.Context, channel *Channel) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	copied := *channel
+	c.store.channels[channel.ChannelID] = &copied
+
+	return nil
+}
+
+func (c *MemoryChannelImpl) ReadChannel(ctx context.Context, id string) (*Channel, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	channel, ok := c.store.channels[id]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *channel
+	return &copied, nil
+}
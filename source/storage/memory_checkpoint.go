@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+)
+
+func NewMemoryCheckpointImpl() *MemoryCheckpointImpl {
+	return NewMemoryCheckpointImplWithStore(GetMemoryStore())
+}
+
+func NewMemoryCheckpointImplWithStore(store *MemoryStore) *MemoryCheckpointImpl {
+	return &MemoryCheckpointImpl{
+		store: store,
+	}
+}
+
+// MemoryCheckpointImpl implements CheckpointInterface on top of a MemoryStore
+type MemoryCheckpointImpl struct {
+	store *MemoryStore
+}
+
+func (c *MemoryCheckpointImpl) ReadCheckpoint(ctx context.Context, queryID string) (*Checkpoint, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	checkpoint, ok := c.store.checkpoints[queryID]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *checkpoint
+	return &copied, nil
+}
+
+func (c *MemoryCheckpointImpl) SaveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	copied := *checkpoint
+	c.store.checkpoints[checkpoint.QueryID] = &copied
+	return nil
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"sort"
+)
+
+func NewMemoryIngestionQueryImpl() *MemoryIngestionQueryImpl {
+	return NewMemoryIngestionQueryImplWithStore(GetMemoryStore())
+}
+
+func NewMemoryIngestionQueryImplWithStore(store *MemoryStore) *MemoryIngestionQueryImpl {
+	return &MemoryIngestionQueryImpl{
+		store: store,
+	}
+}
+
+// MemoryIngestionQueryImpl implements IngestionQueryInterface on top of a MemoryStore
+type MemoryIngestionQueryImpl struct {
+	store *MemoryStore
+}
+
+func (q *MemoryIngestionQueryImpl) CreateIngestionQuery(ctx context.Context, ingestionQuery *IngestionQuery) error {
+	q.store.mu.Lock()
+	defer q.store.mu.Unlock()
+
+	if _, ok := q.store.ingestionQueries[ingestionQuery.Name]; ok {
+		return ErrIngestionQueryExists
+	}
+
+	copied := *ingestionQuery
+	q.store.ingestionQueries[ingestionQuery.Name] = &copied
+	return nil
+}
+
+func (q *MemoryIngestionQueryImpl) ReadIngestionQuery(ctx context.Context, name string) (*IngestionQuery, error) {
+	q.store.mu.RLock()
+	defer q.store.mu.RUnlock()
+
+	ingestionQuery, ok := q.store.ingestionQueries[name]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *ingestionQuery
+	return &copied, nil
+}
+
+func (q *MemoryIngestionQueryImpl) ListIngestionQueries(ctx context.Context) ([]*IngestionQuery, error) {
+	q.store.mu.RLock()
+	defer q.store.mu.RUnlock()
+
+	var ingestionQueries []*IngestionQuery
+	for _, ingestionQuery := range q.store.ingestionQueries {
+		copied := *ingestionQuery
+		ingestionQueries = append(ingestionQueries, &copied)
+	}
+
+	sort.Slice(ingestionQueries, func(i, j int) bool {
+		return ingestionQueries[i].Name < ingestionQueries[j].Name
+	})
+	return ingestionQueries, nil
+}
+
+// Overwrites the query with name, does nothing if there is no such query
+func (q *MemoryIngestionQueryImpl) UpdateIngestionQuery(ctx context.Context, name string, ingestionQuery *IngestionQuery) error {
+	q.store.mu.Lock()
+	defer q.store.mu.Unlock()
+
+	if _, ok := q.store.ingestionQueries[name]; !ok {
+		return nil
+	}
+
+	copied := *ingestionQuery
+	copied.Name = name
+	q.store.ingestionQueries[name] = &copied
+	return nil
+}
+
+func (q *MemoryIngestionQueryImpl) DeleteIngestionQuery(ctx context.Context, name string) error {
+	q.store.mu.Lock()
+	defer q.store.mu.Unlock()
+
+	delete(q.store.ingestionQueries, name)
+	return nil
+}
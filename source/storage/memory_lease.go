@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+func NewMemoryLeaseImpl() *MemoryLeaseImpl {
+	return NewMemoryLeaseImplWithStore(GetMemoryStore())
+}
+
+func NewMemoryLeaseImplWithStore(store *MemoryStore) *MemoryLeaseImpl {
+	return &MemoryLeaseImpl{
+		store: store,
+	}
+}
+
+// MemoryLeaseImpl implements LeaseInterface on top of a MemoryStore. The store
+// belongs to one process, so it only keeps apart the holders within it.
+type MemoryLeaseImpl struct {
+	store *MemoryStore
+}
+
+func (l *MemoryLeaseImpl) AcquireLease(ctx context.Context, name string, holder string, duration time.Duration) (bool, error) {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	lease, ok := l.store.leases[name]
+	if ok && lease.Holder != holder && lease.ExpiresAt.After(now) {
+		return false, nil
+	}
+
+	l.store.leases[name] = &Lease{
+		Name:      name,
+		Holder:    holder,
+		ExpiresAt: now.Add(duration),
+	}
+
+	return true, nil
+}
+
+func (l *MemoryLeaseImpl) ReleaseLease(ctx context.Context, name string, holder string) error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	lease, ok := l.store.leases[name]
+	if ok && lease.Holder == holder {
+		delete(l.store.leases, name)
+	}
+
+	return nil
+}
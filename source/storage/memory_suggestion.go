@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"sort"
+)
+
+func NewMemorySuggestionImpl() *MemorySuggestionImpl {
+	return NewMemorySuggestionImplWithStore(GetMemoryStore())
+}
+
+func NewMemorySuggestionImplWithStore(store *MemoryStore) *MemorySuggestionImpl {
+	return &MemorySuggestionImpl{
+		store: store,
+	}
+}
+
+// MemorySuggestionImpl implements SuggestionInterface on top of a MemoryStore
+type MemorySuggestionImpl struct {
+	store *MemoryStore
+}
+
+func (s *MemorySuggestionImpl) IndexTitles(ctx context.Context, videoMetadatas []*VideoMetadata) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	for _, metadata := range videoMetadatas {
+		indexed, ok := s.store.titleSuggestions[metadata.VideoID]
+		if ok && !indexed.PublishedAt.Before(metadata.PublishedAt) {
+			continue
+		}
+
+		if ok {
+			for _, gram := range indexed.Grams {
+				delete(s.store.titleGrams[gram], metadata.VideoID)
+			}
+		}
+
+		suggestion := newTitleSuggestion(metadata)
+		s.store.titleSuggestions[metadata.VideoID] = suggestion
+		for _, gram := range suggestion.Grams {
+			if s.store.titleGrams[gram] == nil {
+				s.store.titleGrams[gram] = map[string]bool{}
+			}
+			s.store.titleGrams[gram][metadata.VideoID] = true
+		}
+	}
+
+	return nil
+}
+
+func (s *MemorySuggestionImpl) SuggestTitles(ctx context.Context, text string, limit int) ([]*TitleSuggestion, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	// Count the grams every video shares with text
+	shared := map[string]int{}
+	for _, gram := range titleGrams(text) {
+		for videoID := range s.store.titleGrams[gram] {
+			shared[videoID]++
+		}
+	}
+
+	videoIDs := []string{}
+	for videoID := range shared {
+		videoIDs = append(videoIDs, videoID)
+	}
+
+	sort.Slice(videoIDs, func(i, j int) bool {
+		if shared[videoIDs[i]] != shared[videoIDs[j]] {
+			return shared[videoIDs[i]] > shared[videoIDs[j]]
+		}
+		return videoIDs[i] < videoIDs[j]
+	})
+	if len(videoIDs) > maxSuggestionCandidates {
+		videoIDs = videoIDs[:maxSuggestionCandidates]
+	}
+
+	candidates := []*TitleSuggestion{}
+	for _, videoID := range videoIDs {
+		copied := *s.store.titleSuggestions[videoID]
+		copied.Grams = nil
+		candidates = append(candidates, &copied)
+	}
+
+	return rankSuggestions(text, candidates, limit), nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+)
+
+func NewMemoryUserImpl() *MemoryUserImpl {
+	return NewMemoryUserImplWithStore(GetMemoryStore())
+}
+
+func NewMemoryUserImplWithStore(store *MemoryStore) *MemoryUserImpl {
+	return &MemoryUserImpl{
+		store: store,
+	}
+}
+
+// MemoryUserImpl implements UserInterface on top of a MemoryStore
+type MemoryUserImpl struct {
+	store *MemoryStore
+}
+
+func (u *MemoryUserImpl) CreateUser(ctx context.Context, user *User) error {
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	// user_id is unique, an existing user is left untouched
+	if _, ok := u.store.users[user.UserID]; ok {
+		return nil
+	}
+
+	u.store.users[user.UserID] = copyUser(user)
+	return nil
+}
+
+func (u *MemoryUserImpl) ReadUser(ctx context.Context, userID string) (*User, error) {
+	u.store.mu.RLock()
+	defer u.store.mu.RUnlock()
+
+	user, ok := u.store.users[userID]
+	if !ok {
+		return nil, nil
+	}
+
+	return copyUser(user), nil
+}
+
+func (u *MemoryUserImpl) UpdateUser(ctx context.Context, id string, user *User) error {
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	if _, ok := u.store.users[id]; !ok {
+		return nil
+	}
+
+	delete(u.store.users, id)
+	u.store.users[user.UserID] = copyUser(user)
+	return nil
+}
@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+func NewMemoryVideoMetadataImpl() *MemoryVideoMetadataImpl {
+	return NewMemoryVideoMetadataImplWithStore(GetMemoryStore())
+}
+
+func NewMemoryVideoMetadataImplWithStore(store *MemoryStore) *MemoryVideoMetadataImpl {
+	return &MemoryVideoMetadataImpl{
+		store: store,
+	}
+}
+
+// MemoryVideoMetadataImpl implements VideoMetadataInterface on top of a MemoryStore
+type MemoryVideoMetadataImpl struct {
+	store *MemoryStore
+}
+
+func (m *MemoryVideoMetadataImpl) BulkInsertMetadata(ctx context.Context, videoMetadatas []*VideoMetadata) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	for _, metadata := range videoMetadatas {
+		if _, ok := m.store.videoMetadata[metadata.VideoID]; ok {
+			return ErrVideoMetadataExists
+		}
+		m.store.videoMetadata[metadata.VideoID] = copyVideoMetadata(metadata)
+	}
+
+	return nil
+}
+
+func (m *MemoryVideoMetadataImpl) FindOneMetadataWithVideoID(ctx context.Context, id string) (*VideoMetadata, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	metadata, ok := m.store.videoMetadata[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return copyVideoMetadata(metadata), nil
+}
+
+func (m *MemoryVideoMetadataImpl) UpdateOneMetadata(ctx context.Context, id string, videoMetadata *VideoMetadata) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.videoMetadata[id]; !ok {
+		return nil
+	}
+
+	// Like $set the update can change the video id itself
+	delete(m.store.videoMetadata, id)
+	m.store.videoMetadata[videoMetadata.VideoID] = copyVideoMetadata(videoMetadata)
+
+	return nil
+}
+
+func (m *MemoryVideoMetadataImpl) UpdateMetadataStatistics(ctx context.Context, snapshot *VideoStatisticsSnapshot) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	metadata, ok := m.store.videoMetadata[snapshot.VideoID]
+	if !ok {
+		return nil
+	}
+
+	metadata.ViewCount = snapshot.ViewCount
+	metadata.LikeCount = snapshot.LikeCount
+	metadata.CommentCount = snapshot.CommentCount
+
+	return nil
+}
+
+func (m *MemoryVideoMetadataImpl) BulkUpsertMetadata(ctx context.Context, videoMetadatas []*VideoMetadata) ([]*UpsertResult, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	results := []*UpsertResult{}
+	for _, metadata := range videoMetadatas {
+		stored, ok := m.store.videoMetadata[metadata.VideoID]
+		if !ok {
+			m.store.videoMetadata[metadata.VideoID] = copyVideoMetadata(metadata)
+			results = append(results, &UpsertResult{VideoID: metadata.VideoID, Outcome: UpsertInserted})
+			continue
+		}
+
+		queryTags := mergeQueryTags(stored.QueryTags, metadata.QueryTags)
+		if stored.PublishedAt.Before(metadata.PublishedAt) {
+			stored = copyVideoMetadata(metadata)
+			m.store.videoMetadata[metadata.VideoID] = stored
+		}
+		stored.QueryTags = queryTags
+
+		results = append(results, &UpsertResult{VideoID: metadata.VideoID, Outcome: UpsertMatched})
+	}
+
+	return results, nil
+}
+
+func (m *MemoryVideoMetadataImpl) FetchPagedMetadata(ctx context.Context, timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error) {
+	return m.fetchPaged(timestamp, offset, limit, func(metadata *VideoMetadata) bool {
+		return true
+	}), nil
+}
+
+func (m *MemoryVideoMetadataImpl) FetchPagedMetadataWithChannelID(ctx context.Context, channelID string, timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error) {
+	return m.fetchPaged(timestamp, offset, limit, func(metadata *VideoMetadata) bool {
+		return metadata.ChannelID == channelID
+	}), nil
+}
+
+func (m *MemoryVideoMetadataImpl) FetchMetadataPageByKey(ctx context.Context, timestamp time.Time, key *FeedKey, before bool, limit int64) ([]*VideoMetadata, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	var matched []*VideoMetadata
+	for _, metadata := range m.store.videoMetadata {
+		if metadata.PublishedAt.After(timestamp) {
+			continue
+		}
+
+		if key != nil && (key.precedes(metadata) == before || metadata.VideoID == key.VideoID) {
+			continue
+		}
+		matched = append(matched, metadata)
+	}
+
+	sortByPublishedAtDesc(matched)
+
+	if limit > 0 && limit < int64(len(matched)) {
+		if before {
+			matched = matched[int64(len(matched))-limit:]
+		} else {
+			matched = matched[:limit]
+		}
+	}
+
+	var metadata []*VideoMetadata
+	for _, videoMetadata := range matched {
+		metadata = append(metadata, copyVideoMetadata(videoMetadata))
+	}
+
+	return metadata, nil
+}
+
+// Pages through the videos published at or before timestamp that match, most recent first
+func (m *MemoryVideoMetadataImpl) fetchPaged(timestamp time.Time, offset, limit int64, match func(metadata *VideoMetadata) bool) []*VideoMetadata {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	var matched []*VideoMetadata
+	for _, metadata := range m.store.videoMetadata {
+		if !metadata.PublishedAt.After(timestamp) && match(metadata) {
+			matched = append(matched, metadata)
+		}
+	}
+
+	sortByPublishedAtDesc(matched)
+
+	if offset >= int64(len(matched)) {
+		return nil
+	}
+	if offset > 0 {
+		matched = matched[offset:]
+	}
+
+	// A limit of zero means no limit, same as mongo
+	if limit > 0 && limit < int64(len(matched)) {
+		matched = matched[:limit]
+	}
+
+	var metadata []*VideoMetadata
+	for _, videoMetadata := range matched {
+		metadata = append(metadata, copyVideoMetadata(videoMetadata))
+	}
+
+	return metadata
+}
+
+func (m *MemoryVideoMetadataImpl) FindMetadataTextSearch(ctx context.Context, searchText string) ([]*VideoMetadata, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	search := newTextSearch(searchText)
+
+	var matched []*VideoMetadata
+	for _, metadata := range m.store.videoMetadata {
+		if search.matches(metadata.Title, metadata.Description) {
+			matched = append(matched, metadata)
+		}
+	}
+
+	sortByPublishedAtDesc(matched)
+
+	var metadata []*VideoMetadata
+	for _, videoMetadata := range matched {
+		metadata = append(metadata, copyVideoMetadata(videoMetadata))
+	}
+
+	return metadata, nil
+}
+
+func (m *MemoryVideoMetadataImpl) SearchMetadata(ctx context.Context, query *SearchQuery) (*SearchResult, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	search := newTextSearch(query.Text)
+	titleSearch := newTextSearch(query.Title)
+	descriptionSearch := newTextSearch(query.Description)
+	scoreSearch := newTextSearch(query.allText())
+
+	var hits []*SearchHit
+	for _, metadata := range m.store.videoMetadata {
+		if !query.matchesFilters(metadata) {
+			continue
+		}
+		if query.Text != "" && !search.matches(metadata.Title, metadata.Description) {
+			continue
+		}
+		if query.Title != "" && !titleSearch.matches(metadata.Title) {
+			continue
+		}
+		if query.Description != "" && !descriptionSearch.matches(metadata.Description) {
+			continue
+		}
+
+		hit := &SearchHit{Metadata: metadata}
+		if query.hasText() {
+			hit.Score = scoreSearch.fieldScore(metadata)
+		}
+		hits = append(hits, hit)
+	}
+
+	sortSearchHits(hits, query.Sort)
+
+	result := &SearchResult{Total: int64(len(hits))}
+	for _, hit := range hits {
+		if query.After != nil && !query.After.precedes(hit, query.Sort) {
+			continue
+		}
+		if query.Limit > 0 && int64(len(result.Hits)) == query.Limit {
+			break
+		}
+		result.Hits = append(result.Hits, &SearchHit{Metadata: copyVideoMetadata(hit.Metadata), Score: hit.Score})
+	}
+
+	return result, nil
+}
+
+func (m *MemoryVideoMetadataImpl) FindMetadataPublishedAfter(ctx context.Context, timestamp time.Time) ([]*VideoMetadata, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	var matched []*VideoMetadata
+	for _, metadata := range m.store.videoMetadata {
+		if !metadata.PublishedAt.Before(timestamp) {
+			matched = append(matched, metadata)
+		}
+	}
+
+	sortByPublishedAtDesc(matched)
+
+	var metadata []*VideoMetadata
+	for _, videoMetadata := range matched {
+		metadata = append(metadata, copyVideoMetadata(videoMetadata))
+	}
+
+	return metadata, nil
+}
+
+// sortByPublishedAtDesc orders the most recent video first, ties are broken on the video id
+func sortByPublishedAtDesc(metadata []*VideoMetadata) {
+	sort.Slice(metadata, func(i, j int) bool {
+		if metadata[i].PublishedAt.Equal(metadata[j].PublishedAt) {
+			return metadata[i].VideoID < metadata[j].VideoID
+		}
+		return metadata[i].PublishedAt.After(metadata[j].PublishedAt)
+	})
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"sort"
+)
+
+func NewMemoryVideoStatisticsSnapshotImpl() *MemoryVideoStatisticsSnapshotImpl {
+	return NewMemoryVideoStatisticsSnapshotImplWithStore(GetMemoryStore())
+}
+
+func NewMemoryVideoStatisticsSnapshotImplWithStore(store *MemoryStore) *MemoryVideoStatisticsSnapshotImpl {
+	return &MemoryVideoStatisticsSnapshotImpl{
+		store: store,
+	}
+}
+
+// MemoryVideoStatisticsSnapshotImpl implements VideoStatisticsSnapshotInterface on top of a MemoryStore
+type MemoryVideoStatisticsSnapshotImpl struct {
+	store *MemoryStore
+}
+
+func (m *MemoryVideoStatisticsSnapshotImpl) BulkInsertSnapshots(ctx context.Context, snapshots []*VideoStatisticsSnapshot) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	for _, snapshot := range snapshots {
+		if m.hasSnapshot(snapshot) {
+			continue
+		}
+
+		copied := *snapshot
+		m.store.videoStatisticsSnapshots[snapshot.VideoID] = append(m.store.videoStatisticsSnapshots[snapshot.VideoID], &copied)
+	}
+
+	return nil
+}
+
+// Callers hold the lock
+func (m *MemoryVideoStatisticsSnapshotImpl) hasSnapshot(snapshot *VideoStatisticsSnapshot) bool {
+	for _, stored := range m.store.videoStatisticsSnapshots[snapshot.VideoID] {
+		if stored.Timestamp.Equal(snapshot.Timestamp) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryVideoStatisticsSnapshotImpl) FindSnapshotsWithVideoID(ctx context.Context, id string) ([]*VideoStatisticsSnapshot, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	var snapshots []*VideoStatisticsSnapshot
+	for _, snapshot := range m.store.videoStatisticsSnapshots[id] {
+		copied := *snapshot
+		snapshots = append(snapshots, &copied)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+	})
+
+	return snapshots, nil
+}
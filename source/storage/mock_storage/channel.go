@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ashmeet13/YoutubeDataService/source/storage (interfaces: ChannelInterface)
+
+// Package mock_storage is a generated GoMock package.
+package mock_storage
+
+import (
+	context "context"
+	reflect "reflect"
+
+	storage "github.com/ashmeet13/YoutubeDataService/source/storage"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockChannelInterface is a mock of ChannelInterface interface.
+type MockChannelInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockChannelInterfaceMockRecorder
+}
+
+// MockChannelInterfaceMockRecorder is the mock recorder for MockChannelInterface.
+type MockChannelInterfaceMockRecorder struct {
+	mock *MockChannelInterface
+}
+
+// NewMockChannelInterface creates a new mock instance.
+func NewMockChannelInterface(ctrl *gomock.Controller) *MockChannelInterface {
+	mock := &MockChannelInterface{ctrl: ctrl}
+	mock.recorder = &MockChannelInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChannelInterface) EXPECT() *MockChannelInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ReadChannel mocks base method.
+func (m *MockChannelInterface) ReadChannel(arg0 context.Context, arg1 string) (*storage.Channel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadChannel", arg0, arg1)
+	ret0, _ := ret[0].(*storage.Channel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadChannel indicates an expected call of ReadChannel.
+func (mr *MockChannelInterfaceMockRecorder) ReadChannel(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChannel", reflect.TypeOf((*MockChannelInterface)(nil).ReadChannel), arg0, arg1)
+}
+
+// UpsertChannel mocks base method.
+func (m *MockChannelInterface) UpsertChannel(arg0 context.Context, arg1 *storage.Channel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertChannel", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertChannel indicates an expected call of UpsertChannel.
+func (mr *MockChannelInterfaceMockRecorder) UpsertChannel(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertChannel", reflect.TypeOf((*MockChannelInterface)(nil).UpsertChannel), arg0, arg1)
+}
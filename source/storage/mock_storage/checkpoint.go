@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ashmeet13/YoutubeDataService/source/storage (interfaces: CheckpointInterface)
+
+// Package mock_storage is a generated GoMock package.
+package mock_storage
+
+import (
+	context "context"
+	reflect "reflect"
+
+	storage "github.com/ashmeet13/YoutubeDataService/source/storage"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCheckpointInterface is a mock of CheckpointInterface interface.
+type MockCheckpointInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCheckpointInterfaceMockRecorder
+}
+
+// MockCheckpointInterfaceMockRecorder is the mock recorder for MockCheckpointInterface.
+type MockCheckpointInterfaceMockRecorder struct {
+	mock *MockCheckpointInterface
+}
+
+// NewMockCheckpointInterface creates a new mock instance.
+func NewMockCheckpointInterface(ctrl *gomock.Controller) *MockCheckpointInterface {
+	mock := &MockCheckpointInterface{ctrl: ctrl}
+	mock.recorder = &MockCheckpointInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCheckpointInterface) EXPECT() *MockCheckpointInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ReadCheckpoint mocks base method.
+func (m *MockCheckpointInterface) ReadCheckpoint(arg0 context.Context, arg1 string) (*storage.Checkpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadCheckpoint", arg0, arg1)
+	ret0, _ := ret[0].(*storage.Checkpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadCheckpoint indicates an expected call of ReadCheckpoint.
+func (mr *MockCheckpointInterfaceMockRecorder) ReadCheckpoint(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadCheckpoint", reflect.TypeOf((*MockCheckpointInterface)(nil).ReadCheckpoint), arg0, arg1)
+}
+
+// SaveCheckpoint mocks base method.
+func (m *MockCheckpointInterface) SaveCheckpoint(arg0 context.Context, arg1 *storage.Checkpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCheckpoint", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCheckpoint indicates an expected call of SaveCheckpoint.
+func (mr *MockCheckpointInterfaceMockRecorder) SaveCheckpoint(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCheckpoint", reflect.TypeOf((*MockCheckpointInterface)(nil).SaveCheckpoint), arg0, arg1)
+}
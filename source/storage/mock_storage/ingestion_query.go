@@ -0,0 +1,108 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ashmeet13/YoutubeDataService/source/storage (interfaces: IngestionQueryInterface)
+
+// Package mock_storage is a generated GoMock package.
+package mock_storage
+
+import (
+	context "context"
+	reflect "reflect"
+
+	storage "github.com/ashmeet13/YoutubeDataService/source/storage"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIngestionQueryInterface is a mock of IngestionQueryInterface interface.
+type MockIngestionQueryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockIngestionQueryInterfaceMockRecorder
+}
+
+// MockIngestionQueryInterfaceMockRecorder is the mock recorder for MockIngestionQueryInterface.
+type MockIngestionQueryInterfaceMockRecorder struct {
+	mock *MockIngestionQueryInterface
+}
+
+// NewMockIngestionQueryInterface creates a new mock instance.
+func NewMockIngestionQueryInterface(ctrl *gomock.Controller) *MockIngestionQueryInterface {
+	mock := &MockIngestionQueryInterface{ctrl: ctrl}
+	mock.recorder = &MockIngestionQueryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIngestionQueryInterface) EXPECT() *MockIngestionQueryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateIngestionQuery mocks base method.
+func (m *MockIngestionQueryInterface) CreateIngestionQuery(arg0 context.Context, arg1 *storage.IngestionQuery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIngestionQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIngestionQuery indicates an expected call of CreateIngestionQuery.
+func (mr *MockIngestionQueryInterfaceMockRecorder) CreateIngestionQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIngestionQuery", reflect.TypeOf((*MockIngestionQueryInterface)(nil).CreateIngestionQuery), arg0, arg1)
+}
+
+// DeleteIngestionQuery mocks base method.
+func (m *MockIngestionQueryInterface) DeleteIngestionQuery(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIngestionQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIngestionQuery indicates an expected call of DeleteIngestionQuery.
+func (mr *MockIngestionQueryInterfaceMockRecorder) DeleteIngestionQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIngestionQuery", reflect.TypeOf((*MockIngestionQueryInterface)(nil).DeleteIngestionQuery), arg0, arg1)
+}
+
+// ListIngestionQueries mocks base method.
+func (m *MockIngestionQueryInterface) ListIngestionQueries(arg0 context.Context) ([]*storage.IngestionQuery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIngestionQueries", arg0)
+	ret0, _ := ret[0].([]*storage.IngestionQuery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIngestionQueries indicates an expected call of ListIngestionQueries.
+func (mr *MockIngestionQueryInterfaceMockRecorder) ListIngestionQueries(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIngestionQueries", reflect.TypeOf((*MockIngestionQueryInterface)(nil).ListIngestionQueries), arg0)
+}
+
+// ReadIngestionQuery mocks base method.
+func (m *MockIngestionQueryInterface) ReadIngestionQuery(arg0 context.Context, arg1 string) (*storage.IngestionQuery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadIngestionQuery", arg0, arg1)
+	ret0, _ := ret[0].(*storage.IngestionQuery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadIngestionQuery indicates an expected call of ReadIngestionQuery.
+func (mr *MockIngestionQueryInterfaceMockRecorder) ReadIngestionQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadIngestionQuery", reflect.TypeOf((*MockIngestionQueryInterface)(nil).ReadIngestionQuery), arg0, arg1)
+}
+
+// UpdateIngestionQuery mocks base method.
+func (m *MockIngestionQueryInterface) UpdateIngestionQuery(arg0 context.Context, arg1 string, arg2 *storage.IngestionQuery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIngestionQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIngestionQuery indicates an expected call of UpdateIngestionQuery.
+func (mr *MockIngestionQueryInterfaceMockRecorder) UpdateIngestionQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIngestionQuery", reflect.TypeOf((*MockIngestionQueryInterface)(nil).UpdateIngestionQuery), arg0, arg1, arg2)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ashmeet13/YoutubeDataService/source/storage (interfaces: LeaseInterface)
+
+// Package mock_storage is a generated GoMock package.
+package mock_storage
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLeaseInterface is a mock of LeaseInterface interface.
+type MockLeaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaseInterfaceMockRecorder
+}
+
+// MockLeaseInterfaceMockRecorder is the mock recorder for MockLeaseInterface.
+type MockLeaseInterfaceMockRecorder struct {
+	mock *MockLeaseInterface
+}
+
+// NewMockLeaseInterface creates a new mock instance.
+func NewMockLeaseInterface(ctrl *gomock.Controller) *MockLeaseInterface {
+	mock := &MockLeaseInterface{ctrl: ctrl}
+	mock.recorder = &MockLeaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeaseInterface) EXPECT() *MockLeaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AcquireLease mocks base method.
+func (m *MockLeaseInterface) AcquireLease(arg0 context.Context, arg1, arg2 string, arg3 time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireLease", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireLease indicates an expected call of AcquireLease.
+func (mr *MockLeaseInterfaceMockRecorder) AcquireLease(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireLease", reflect.TypeOf((*MockLeaseInterface)(nil).AcquireLease), arg0, arg1, arg2, arg3)
+}
+
+// ReleaseLease mocks base method.
+func (m *MockLeaseInterface) ReleaseLease(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLease", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLease indicates an expected call of ReleaseLease.
+func (mr *MockLeaseInterfaceMockRecorder) ReleaseLease(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLease", reflect.TypeOf((*MockLeaseInterface)(nil).ReleaseLease), arg0, arg1, arg2)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ashmeet13/YoutubeDataService/source/storage (interfaces: SuggestionInterface)
+
+// Package mock_storage is a generated GoMock package.
+package mock_storage
+
+import (
+	context "context"
+	reflect "reflect"
+
+	storage "github.com/ashmeet13/YoutubeDataService/source/storage"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSuggestionInterface is a mock of SuggestionInterface interface.
+type MockSuggestionInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSuggestionInterfaceMockRecorder
+}
+
+// MockSuggestionInterfaceMockRecorder is the mock recorder for MockSuggestionInterface.
+type MockSuggestionInterfaceMockRecorder struct {
+	mock *MockSuggestionInterface
+}
+
+// NewMockSuggestionInterface creates a new mock instance.
+func NewMockSuggestionInterface(ctrl *gomock.Controller) *MockSuggestionInterface {
+	mock := &MockSuggestionInterface{ctrl: ctrl}
+	mock.recorder = &MockSuggestionInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSuggestionInterface) EXPECT() *MockSuggestionInterfaceMockRecorder {
+	return m.recorder
+}
+
+// IndexTitles mocks base method.
+func (m *MockSuggestionInterface) IndexTitles(arg0 context.Context, arg1 []*storage.VideoMetadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IndexTitles", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IndexTitles indicates an expected call of IndexTitles.
+func (mr *MockSuggestionInterfaceMockRecorder) IndexTitles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexTitles", reflect.TypeOf((*MockSuggestionInterface)(nil).IndexTitles), arg0, arg1)
+}
+
+// SuggestTitles mocks base method.
+func (m *MockSuggestionInterface) SuggestTitles(arg0 context.Context, arg1 string, arg2 int) ([]*storage.TitleSuggestion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuggestTitles", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*storage.TitleSuggestion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuggestTitles indicates an expected call of SuggestTitles.
+func (mr *MockSuggestionInterfaceMockRecorder) SuggestTitles(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestTitles", reflect.TypeOf((*MockSuggestionInterface)(nil).SuggestTitles), arg0, arg1, arg2)
+}
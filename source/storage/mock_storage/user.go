@@ -5,6 +5,7 @@
 package mock_storage
 
 import (
+	context "context"
 	reflect "reflect"
 
 	storage "github.com/ashmeet13/YoutubeDataService/source/storage"
@@ -35,44 +36,44 @@ func (m *MockUserInterface) EXPECT() *MockUserInterfaceMockRecorder {
 }
 
 // CreateUser mocks base method.
-func (m *MockUserInterface) CreateUser(arg0 *storage.User) error {
+func (m *MockUserInterface) CreateUser(arg0 context.Context, arg1 *storage.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0)
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserInterfaceMockRecorder) CreateUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserInterfaceMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserInterface)(nil).CreateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserInterface)(nil).CreateUser), arg0, arg1)
 }
 
 // ReadUser mocks base method.
-func (m *MockUserInterface) ReadUser(arg0 string) (*storage.User, error) {
+func (m *MockUserInterface) ReadUser(arg0 context.Context, arg1 string) (*storage.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadUser", arg0)
+	ret := m.ctrl.Call(m, "ReadUser", arg0, arg1)
 	ret0, _ := ret[0].(*storage.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ReadUser indicates an expected call of ReadUser.
-func (mr *MockUserInterfaceMockRecorder) ReadUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserInterfaceMockRecorder) ReadUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUser", reflect.TypeOf((*MockUserInterface)(nil).ReadUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUser", reflect.TypeOf((*MockUserInterface)(nil).ReadUser), arg0, arg1)
 }
 
 // UpdateUser mocks base method.
-func (m *MockUserInterface) UpdateUser(arg0 string, arg1 *storage.User) error {
+func (m *MockUserInterface) UpdateUser(arg0 context.Context, arg1 string, arg2 *storage.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1)
+	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockUserInterfaceMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockUserInterfaceMockRecorder) UpdateUser(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserInterface)(nil).UpdateUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserInterface)(nil).UpdateUser), arg0, arg1, arg2)
 }
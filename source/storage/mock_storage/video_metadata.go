@@ -5,6 +5,7 @@
 package mock_storage
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
@@ -36,74 +37,163 @@ func (m *MockVideoMetadataInterface) EXPECT() *MockVideoMetadataInterfaceMockRec
 }
 
 // BulkInsertMetadata mocks base method.
-func (m *MockVideoMetadataInterface) BulkInsertMetadata(arg0 []*storage.VideoMetadata) error {
+func (m *MockVideoMetadataInterface) BulkInsertMetadata(arg0 context.Context, arg1 []*storage.VideoMetadata) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BulkInsertMetadata", arg0)
+	ret := m.ctrl.Call(m, "BulkInsertMetadata", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // BulkInsertMetadata indicates an expected call of BulkInsertMetadata.
-func (mr *MockVideoMetadataInterfaceMockRecorder) BulkInsertMetadata(arg0 interface{}) *gomock.Call {
+func (mr *MockVideoMetadataInterfaceMockRecorder) BulkInsertMetadata(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkInsertMetadata", reflect.TypeOf((*MockVideoMetadataInterface)(nil).BulkInsertMetadata), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkInsertMetadata", reflect.TypeOf((*MockVideoMetadataInterface)(nil).BulkInsertMetadata), arg0, arg1)
+}
+
+// BulkUpsertMetadata mocks base method.
+func (m *MockVideoMetadataInterface) BulkUpsertMetadata(arg0 context.Context, arg1 []*storage.VideoMetadata) ([]*storage.UpsertResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkUpsertMetadata", arg0, arg1)
+	ret0, _ := ret[0].([]*storage.UpsertResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkUpsertMetadata indicates an expected call of BulkUpsertMetadata.
+func (mr *MockVideoMetadataInterfaceMockRecorder) BulkUpsertMetadata(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkUpsertMetadata", reflect.TypeOf((*MockVideoMetadataInterface)(nil).BulkUpsertMetadata), arg0, arg1)
+}
+
+// FetchMetadataPageByKey mocks base method.
+func (m *MockVideoMetadataInterface) FetchMetadataPageByKey(arg0 context.Context, arg1 time.Time, arg2 *storage.FeedKey, arg3 bool, arg4 int64) ([]*storage.VideoMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchMetadataPageByKey", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*storage.VideoMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchMetadataPageByKey indicates an expected call of FetchMetadataPageByKey.
+func (mr *MockVideoMetadataInterfaceMockRecorder) FetchMetadataPageByKey(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchMetadataPageByKey", reflect.TypeOf((*MockVideoMetadataInterface)(nil).FetchMetadataPageByKey), arg0, arg1, arg2, arg3, arg4)
 }
 
 // FetchPagedMetadata mocks base method.
-func (m *MockVideoMetadataInterface) FetchPagedMetadata(arg0 time.Time, arg1, arg2 int64) ([]*storage.VideoMetadata, error) {
+func (m *MockVideoMetadataInterface) FetchPagedMetadata(arg0 context.Context, arg1 time.Time, arg2, arg3 int64) ([]*storage.VideoMetadata, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FetchPagedMetadata", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "FetchPagedMetadata", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].([]*storage.VideoMetadata)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FetchPagedMetadata indicates an expected call of FetchPagedMetadata.
-func (mr *MockVideoMetadataInterfaceMockRecorder) FetchPagedMetadata(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockVideoMetadataInterfaceMockRecorder) FetchPagedMetadata(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchPagedMetadata", reflect.TypeOf((*MockVideoMetadataInterface)(nil).FetchPagedMetadata), arg0, arg1, arg2, arg3)
+}
+
+// FetchPagedMetadataWithChannelID mocks base method.
+func (m *MockVideoMetadataInterface) FetchPagedMetadataWithChannelID(arg0 context.Context, arg1 string, arg2 time.Time, arg3, arg4 int64) ([]*storage.VideoMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchPagedMetadataWithChannelID", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]*storage.VideoMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchPagedMetadataWithChannelID indicates an expected call of FetchPagedMetadataWithChannelID.
+func (mr *MockVideoMetadataInterfaceMockRecorder) FetchPagedMetadataWithChannelID(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchPagedMetadata", reflect.TypeOf((*MockVideoMetadataInterface)(nil).FetchPagedMetadata), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchPagedMetadataWithChannelID", reflect.TypeOf((*MockVideoMetadataInterface)(nil).FetchPagedMetadataWithChannelID), arg0, arg1, arg2, arg3, arg4)
+}
+
+// FindMetadataPublishedAfter mocks base method.
+func (m *MockVideoMetadataInterface) FindMetadataPublishedAfter(arg0 context.Context, arg1 time.Time) ([]*storage.VideoMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindMetadataPublishedAfter", arg0, arg1)
+	ret0, _ := ret[0].([]*storage.VideoMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindMetadataPublishedAfter indicates an expected call of FindMetadataPublishedAfter.
+func (mr *MockVideoMetadataInterfaceMockRecorder) FindMetadataPublishedAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMetadataPublishedAfter", reflect.TypeOf((*MockVideoMetadataInterface)(nil).FindMetadataPublishedAfter), arg0, arg1)
 }
 
 // FindMetadataTextSearch mocks base method.
-func (m *MockVideoMetadataInterface) FindMetadataTextSearch(arg0 string) ([]*storage.VideoMetadata, error) {
+func (m *MockVideoMetadataInterface) FindMetadataTextSearch(arg0 context.Context, arg1 string) ([]*storage.VideoMetadata, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindMetadataTextSearch", arg0)
+	ret := m.ctrl.Call(m, "FindMetadataTextSearch", arg0, arg1)
 	ret0, _ := ret[0].([]*storage.VideoMetadata)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindMetadataTextSearch indicates an expected call of FindMetadataTextSearch.
-func (mr *MockVideoMetadataInterfaceMockRecorder) FindMetadataTextSearch(arg0 interface{}) *gomock.Call {
+func (mr *MockVideoMetadataInterfaceMockRecorder) FindMetadataTextSearch(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMetadataTextSearch", reflect.TypeOf((*MockVideoMetadataInterface)(nil).FindMetadataTextSearch), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMetadataTextSearch", reflect.TypeOf((*MockVideoMetadataInterface)(nil).FindMetadataTextSearch), arg0, arg1)
 }
 
 // FindOneMetadataWithVideoID mocks base method.
-func (m *MockVideoMetadataInterface) FindOneMetadataWithVideoID(arg0 string) (*storage.VideoMetadata, error) {
+func (m *MockVideoMetadataInterface) FindOneMetadataWithVideoID(arg0 context.Context, arg1 string) (*storage.VideoMetadata, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindOneMetadataWithVideoID", arg0)
+	ret := m.ctrl.Call(m, "FindOneMetadataWithVideoID", arg0, arg1)
 	ret0, _ := ret[0].(*storage.VideoMetadata)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindOneMetadataWithVideoID indicates an expected call of FindOneMetadataWithVideoID.
-func (mr *MockVideoMetadataInterfaceMockRecorder) FindOneMetadataWithVideoID(arg0 interface{}) *gomock.Call {
+func (mr *MockVideoMetadataInterfaceMockRecorder) FindOneMetadataWithVideoID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneMetadataWithVideoID", reflect.TypeOf((*MockVideoMetadataInterface)(nil).FindOneMetadataWithVideoID), arg0, arg1)
+}
+
+// SearchMetadata mocks base method.
+func (m *MockVideoMetadataInterface) SearchMetadata(arg0 context.Context, arg1 *storage.SearchQuery) (*storage.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchMetadata", arg0, arg1)
+	ret0, _ := ret[0].(*storage.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchMetadata indicates an expected call of SearchMetadata.
+func (mr *MockVideoMetadataInterfaceMockRecorder) SearchMetadata(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMetadata", reflect.TypeOf((*MockVideoMetadataInterface)(nil).SearchMetadata), arg0, arg1)
+}
+
+// UpdateMetadataStatistics mocks base method.
+func (m *MockVideoMetadataInterface) UpdateMetadataStatistics(arg0 context.Context, arg1 *storage.VideoStatisticsSnapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMetadataStatistics", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMetadataStatistics indicates an expected call of UpdateMetadataStatistics.
+func (mr *MockVideoMetadataInterfaceMockRecorder) UpdateMetadataStatistics(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneMetadataWithVideoID", reflect.TypeOf((*MockVideoMetadataInterface)(nil).FindOneMetadataWithVideoID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMetadataStatistics", reflect.TypeOf((*MockVideoMetadataInterface)(nil).UpdateMetadataStatistics), arg0, arg1)
 }
 
 // UpdateOneMetadata mocks base method.
-func (m *MockVideoMetadataInterface) UpdateOneMetadata(arg0 string, arg1 *storage.VideoMetadata) error {
+func (m *MockVideoMetadataInterface) UpdateOneMetadata(arg0 context.Context, arg1 string, arg2 *storage.VideoMetadata) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateOneMetadata", arg0, arg1)
+	ret := m.ctrl.Call(m, "UpdateOneMetadata", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateOneMetadata indicates an expected call of UpdateOneMetadata.
-func (mr *MockVideoMetadataInterfaceMockRecorder) UpdateOneMetadata(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockVideoMetadataInterfaceMockRecorder) UpdateOneMetadata(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOneMetadata", reflect.TypeOf((*MockVideoMetadataInterface)(nil).UpdateOneMetadata), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOneMetadata", reflect.TypeOf((*MockVideoMetadataInterface)(nil).UpdateOneMetadata), arg0, arg1, arg2)
 }
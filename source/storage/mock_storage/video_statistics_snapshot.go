@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ashmeet13/YoutubeDataService/source/storage (interfaces: VideoStatisticsSnapshotInterface)
+
+// Package mock_storage is a generated GoMock package.
+package mock_storage
+
+import (
+	context "context"
+	reflect "reflect"
+
+	storage "github.com/ashmeet13/YoutubeDataService/source/storage"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockVideoStatisticsSnapshotInterface is a mock of VideoStatisticsSnapshotInterface interface.
+type MockVideoStatisticsSnapshotInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockVideoStatisticsSnapshotInterfaceMockRecorder
+}
+
+// MockVideoStatisticsSnapshotInterfaceMockRecorder is the mock recorder for MockVideoStatisticsSnapshotInterface.
+type MockVideoStatisticsSnapshotInterfaceMockRecorder struct {
+	mock *MockVideoStatisticsSnapshotInterface
+}
+
+// NewMockVideoStatisticsSnapshotInterface creates a new mock instance.
+func NewMockVideoStatisticsSnapshotInterface(ctrl *gomock.Controller) *MockVideoStatisticsSnapshotInterface {
+	mock := &MockVideoStatisticsSnapshotInterface{ctrl: ctrl}
+	mock.recorder = &MockVideoStatisticsSnapshotInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVideoStatisticsSnapshotInterface) EXPECT() *MockVideoStatisticsSnapshotInterfaceMockRecorder {
+	return m.recorder
+}
+
+// BulkInsertSnapshots mocks base method.
+func (m *MockVideoStatisticsSnapshotInterface) BulkInsertSnapshots(arg0 context.Context, arg1 []*storage.VideoStatisticsSnapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkInsertSnapshots", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BulkInsertSnapshots indicates an expected call of BulkInsertSnapshots.
+func (mr *MockVideoStatisticsSnapshotInterfaceMockRecorder) BulkInsertSnapshots(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkInsertSnapshots", reflect.TypeOf((*MockVideoStatisticsSnapshotInterface)(nil).BulkInsertSnapshots), arg0, arg1)
+}
+
+// FindSnapshotsWithVideoID mocks base method.
+func (m *MockVideoStatisticsSnapshotInterface) FindSnapshotsWithVideoID(arg0 context.Context, arg1 string) ([]*storage.VideoStatisticsSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSnapshotsWithVideoID", arg0, arg1)
+	ret0, _ := ret[0].([]*storage.VideoStatisticsSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSnapshotsWithVideoID indicates an expected call of FindSnapshotsWithVideoID.
+func (mr *MockVideoStatisticsSnapshotInterfaceMockRecorder) FindSnapshotsWithVideoID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSnapshotsWithVideoID", reflect.TypeOf((*MockVideoStatisticsSnapshotInterface)(nil).FindSnapshotsWithVideoID), arg0, arg1)
+}
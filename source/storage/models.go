@@ -14,6 +14,22 @@ type VideoMetadata struct {
 	MediumThumbnailURL   string    `bson:"medium_thumbnail_url"`
 	StandardThumbnailURL string    `bson:"standard_thumbnail_url"`
 	PublishedAt          time.Time `bson:"published_at"`
+	ChannelID            string    `bson:"channel_id"`
+	ChannelTitle         string    `bson:"channel_title"`
+
+	// Tags of the ingestion queries that found the video
+	QueryTags []string `bson:"query_tags"`
+
+	// Details from videos.list, filled in by the enrichment stage. EnrichedAt is
+	// zero until the video is enriched.
+	ViewCount       int64         `bson:"view_count"`
+	LikeCount       int64         `bson:"like_count"`
+	CommentCount    int64         `bson:"comment_count"`
+	Duration        time.Duration `bson:"duration"`
+	Tags            []string      `bson:"tags"`
+	CategoryID      string        `bson:"category_id"`
+	DefaultLanguage string        `bson:"default_language"`
+	EnrichedAt      time.Time     `bson:"enriched_at"`
 }
 
 const UserC = "users"
@@ -23,3 +39,80 @@ type User struct {
 	PageSize  int       `bson:"page_size"`
 	Timestamp time.Time `bson:"timestamp"`
 }
+
+const CheckpointC = "checkpoints"
+
+// Checkpoint is the saved position of the worker for one ingestion query.
+// Completed is only set by runs that have an end, like a backfill.
+type Checkpoint struct {
+	QueryID               string    `bson:"query_id"`
+	CurrentPublishedTime  time.Time `bson:"current_published_time"`
+	PreviousPublishedTime time.Time `bson:"previous_published_time"`
+	NextPageToken         string    `bson:"next_page_token"`
+	Completed             bool      `bson:"completed"`
+	UpdatedAt             time.Time `bson:"updated_at"`
+}
+
+const IngestionQueryC = "ingestion_queries"
+
+// IngestionQuery is the definition of a query the worker polls, managed at runtime through the admin API.
+// Source is the kind of source it polls, queries stored before sources existed have none and are searches.
+type IngestionQuery struct {
+	Name         string        `bson:"name"`
+	Source       string        `bson:"source"`
+	Query        string        `bson:"query"`
+	Location     string        `bson:"location"`
+	Tag          string        `bson:"tag"`
+	PollInterval time.Duration `bson:"poll_interval"`
+	Paused       bool          `bson:"paused"`
+	CreatedAt    time.Time     `bson:"created_at"`
+	UpdatedAt    time.Time     `bson:"updated_at"`
+}
+
+const VideoStatisticsSnapshotC = "video_statistics_snapshots"
+
+// VideoStatisticsSnapshot is the statistics of a video observed at Timestamp.
+// Snapshots are keyed by VideoID and Timestamp.
+type VideoStatisticsSnapshot struct {
+	VideoID      string    `bson:"video_id"`
+	Timestamp    time.Time `bson:"timestamp"`
+	ViewCount    int64     `bson:"view_count"`
+	LikeCount    int64     `bson:"like_count"`
+	CommentCount int64     `bson:"comment_count"`
+}
+
+const ChannelC = "channels"
+
+// Channel is a Youtube channel that published one of the stored videos, with
+// the details of channels.list as of UpdatedAt
+type Channel struct {
+	ChannelID           string    `bson:"channel_id"`
+	Title               string    `bson:"title"`
+	Description         string    `bson:"description"`
+	Country             string    `bson:"country"`
+	SubscriberCount     int64     `bson:"subscriber_count"`
+	DefaultThumbnailURL string    `bson:"default_thumbnail_url"`
+	MediumThumbnailURL  string    `bson:"medium_thumbnail_url"`
+	HighThumbnailURL    string    `bson:"high_thumbnail_url"`
+	UpdatedAt           time.Time `bson:"updated_at"`
+}
+
+const LeaseC = "leases"
+
+// Lease gives Holder the right to run the loop called Name until ExpiresAt
+type Lease struct {
+	Name      string    `bson:"name"`
+	Holder    string    `bson:"holder"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+const TitleSuggestionC = "title_suggestions"
+
+// TitleSuggestion is the title of a stored video indexed for suggestions, Grams
+// are the trigrams of its words it is looked up by, see titleGrams
+type TitleSuggestion struct {
+	VideoID     string    `bson:"video_id"`
+	Title       string    `bson:"title"`
+	PublishedAt time.Time `bson:"published_at"`
+	Grams       []string  `bson:"grams" json:"-"`
+}
@@ -10,16 +10,16 @@ import (
 
 var timeout = 5 * time.Second
 
-func InsertMany(collectionName string, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+func InsertMany(ctx context.Context, collectionName string, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
 	collection := GetCollection(collectionName)
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	return collection.InsertMany(ctx, documents, opts...)
 }
 
-func InsertOne(collectionName string, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+func InsertOne(ctx context.Context, collectionName string, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
 	collection := GetCollection(collectionName)
 
 	doc, err := convertToBsonM(document)
@@ -27,13 +27,13 @@ func InsertOne(collectionName string, document interface{}, opts ...*options.Ins
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	return collection.InsertOne(ctx, doc, opts...)
 }
 
-func FindOne(collectionName string, document interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+func FindOne(ctx context.Context, collectionName string, document interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
 	collection := GetCollection(collectionName)
 
 	doc, err := convertToBsonM(document)
@@ -41,13 +41,13 @@ func FindOne(collectionName string, document interface{}, opts ...*options.FindO
 		return nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	return collection.FindOne(ctx, doc, opts...)
 }
 
-func Find(collectionName string, document interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+func Find(ctx context.Context, collectionName string, document interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
 	collection := GetCollection(collectionName)
 
 	doc, err := convertToBsonM(document)
@@ -55,13 +55,13 @@ func Find(collectionName string, document interface{}, opts ...*options.FindOpti
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	return collection.Find(ctx, doc, opts...)
 }
 
-func UpdateOne(collectionName string, filters interface{}, modifier interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+func UpdateOne(ctx context.Context, collectionName string, filters interface{}, modifier interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
 	collection := GetCollection(collectionName)
 
 	f, err := convertToBsonM(filters)
@@ -74,8 +74,54 @@ func UpdateOne(collectionName string, filters interface{}, modifier interface{},
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	return collection.UpdateOne(ctx, f, m, opts...)
 }
+
+func DeleteOne(ctx context.Context, collectionName string, filters interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	collection := GetCollection(collectionName)
+
+	f, err := convertToBsonM(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return collection.DeleteOne(ctx, f, opts...)
+}
+
+func BulkWrite(ctx context.Context, collectionName string, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	collection := GetCollection(collectionName)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return collection.BulkWrite(ctx, models, opts...)
+}
+
+func CountDocuments(ctx context.Context, collectionName string, filters interface{}, opts ...*options.CountOptions) (int64, error) {
+	collection := GetCollection(collectionName)
+
+	f, err := convertToBsonM(filters)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return collection.CountDocuments(ctx, f, opts...)
+}
+
+func Aggregate(ctx context.Context, collectionName string, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	collection := GetCollection(collectionName)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return collection.Aggregate(ctx, pipeline, opts...)
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// SearchSort is the order of the results of a search
+type SearchSort string
+
+const (
+	// Most relevant first, requires a text
+	SortByRelevance SearchSort = "relevance"
+	// Most recent first
+	SortByDate SearchSort = "date"
+)
+
+// SearchQuery is a text search narrowed down by filters, see VideoMetadataInterface.SearchMetadata.
+// Results are ordered on Sort, then on the video id.
+type SearchQuery struct {
+	// Searched in the title and description like FindMetadataTextSearch, every
+	// video matching the filters is a result without any text
+	Text string
+
+	// Searched in the title or the description only. A result matches every
+	// text that is set, and is scored on all of their terms.
+	Title       string
+	Description string
+
+	// Bounds of the publish time, inclusive, unbounded when zero
+	PublishedAfter  time.Time
+	PublishedBefore time.Time
+
+	// A result is published by one of ChannelIDs and was found by one of the
+	// queries tagged QueryTags, any when empty
+	ChannelIDs []string
+	QueryTags  []string
+
+	Sort SearchSort
+
+	// The page starts right after this result, from the top when nil
+	After *SearchKey
+	Limit int64
+}
+
+// SearchKey is the position of a result in the order of a search
+type SearchKey struct {
+	Score       float64
+	PublishedAt time.Time
+	VideoID     string
+}
+
+// SearchHit is a video matching a search, Score is its relevance to the text
+type SearchHit struct {
+	Metadata *VideoMetadata
+	Score    float64
+}
+
+// SearchResult is a page of results, Total counts every video matching the query
+// before paging. Videos are ingested while the pages are read, so it is an estimate.
+type SearchResult struct {
+	Hits  []*SearchHit
+	Total int64
+}
+
+func NewSearchKey(hit *SearchHit) *SearchKey {
+	return &SearchKey{
+		Score:       hit.Score,
+		PublishedAt: hit.Metadata.PublishedAt,
+		VideoID:     hit.Metadata.VideoID,
+	}
+}
+
+// Whether the hit comes after the key in the order of searchSort
+func (k *SearchKey) precedes(hit *SearchHit, searchSort SearchSort) bool {
+	if searchSort == SortByRelevance && hit.Score != k.Score {
+		return hit.Score < k.Score
+	}
+	if searchSort == SortByDate && !hit.Metadata.PublishedAt.Equal(k.PublishedAt) {
+		return hit.Metadata.PublishedAt.Before(k.PublishedAt)
+	}
+	return hit.Metadata.VideoID > k.VideoID
+}
+
+// Whether the query has a text to match and score
+func (q *SearchQuery) hasText() bool {
+	return q.Text != "" || q.Title != "" || q.Description != ""
+}
+
+// The terms of every text of the query, the score of a result is computed on them
+func (q *SearchQuery) allText() string {
+	return strings.Join([]string{q.Text, q.Title, q.Description}, " ")
+}
+
+// Whether the video passes the filters of the query, the text aside
+func (q *SearchQuery) matchesFilters(metadata *VideoMetadata) bool {
+	if !q.PublishedAfter.IsZero() && metadata.PublishedAt.Before(q.PublishedAfter) {
+		return false
+	}
+	if !q.PublishedBefore.IsZero() && metadata.PublishedAt.After(q.PublishedBefore) {
+		return false
+	}
+	if len(q.ChannelIDs) > 0 && !containsAny(q.ChannelIDs, metadata.ChannelID) {
+		return false
+	}
+	if len(q.QueryTags) > 0 && !containsAny(q.QueryTags, metadata.QueryTags...) {
+		return false
+	}
+	return true
+}
+
+func containsAny(values []string, candidates ...string) bool {
+	for _, value := range values {
+		for _, candidate := range candidates {
+			if value == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortSearchHits orders hits on searchSort, ties are broken on the video id
+func sortSearchHits(hits []*SearchHit, searchSort SearchSort) {
+	sort.Slice(hits, func(i, j int) bool {
+		return NewSearchKey(hits[i]).precedes(hits[j], searchSort)
+	})
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+
+	_ "modernc.org/sqlite"
+)
+
+var sqliteDB *sql.DB
+
+func GetSqliteDB() *sql.DB {
+	if sqliteDB == nil {
+		config := common.GetConfiguration()
+		initaliseSqliteDB(config.SqlitePath)
+	}
+	return sqliteDB
+}
+
+func initaliseSqliteDB(path string) {
+	db, err := OpenSqliteDB(path)
+	if err != nil {
+		panic("Unable to open sqlite database")
+	}
+	sqliteDB = db
+}
+
+// OpenSqliteDB opens the sqlite database at path, ":memory:" opens a private in memory database
+func OpenSqliteDB(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite allows a single writer, funnel everything through one connection
+	// so concurrent writes from the server and the worker queue up instead of
+	// failing with SQLITE_BUSY. This also keeps ":memory:" databases alive.
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("PRAGMA journal_mode = WAL; PRAGMA busy_timeout = 5000;")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Times are stored as unix nanoseconds so they sort correctly as integers.
+// The zero time does not fit in nanoseconds and is stored as 0.
+func toSqliteTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromSqliteTime(t int64) time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, t).UTC()
+}
+
+// sqlitePlaceholders returns count comma separated parameters, for an IN list
+func sqlitePlaceholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+// Lists of strings are stored as JSON arrays
+func toSqliteStrings(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+func fromSqliteStrings(encoded string) ([]string, error) {
+	var values []string
+	err := json.Unmarshal([]byte(encoded), &values)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values, nil
+}
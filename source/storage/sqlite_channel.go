@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+func NewSqliteChannelImpl() *SqliteChannelImpl {
+	return NewSqliteChannelImplWithDB(GetSqliteDB())
+}
+
+func NewSqliteChannelImplWithDB(db *sql.DB) *SqliteChannelImpl {
+	return &SqliteChannelImpl{
+		db: db,
+	}
+}
+
+// SqliteChannelImpl implements ChannelInterface on top of sqlite
+type SqliteChannelImpl struct {
+	db *sql.DB
+}
+
+func (c *SqliteChannelImpl) UpsertChannel(ctx context.Context, channel *Channel) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := c.db.ExecContext(ctx, `INSERT INTO channels (channel_id, title, description, country, subscriber_count,
+			default_thumbnail_url, medium_thumbnail_url, high_thumbnail_url, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (channel_id) DO UPDATE SET
+			title = excluded.title,
+			description = excluded.description,
+			country = excluded.country,
+			subscriber_count = excluded.subscriber_count,
+			default_thumbnail_url = excluded.default_thumbnail_url,
+			medium_thumbnail_url = excluded.medium_thumbnail_url,
+			high_thumbnail_url = excluded.high_thumbnail_url,
+			updated_at = excluded.updated_at`,
+		channel.ChannelID,
+		channel.Title,
+		channel.Description,
+		channel.Country,
+		channel.SubscriberCount,
+		channel.DefaultThumbnailURL,
+		channel.MediumThumbnailURL,
+		channel.HighThumbnailURL,
+		toSqliteTime(channel.UpdatedAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *SqliteChannelImpl) ReadChannel(ctx context.Context, id string) (*Channel, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	row := c.db.QueryRowContext(ctx, `SELECT channel_id, title, description, country, subscriber_count,
+			default_thumbnail_url, medium_thumbnail_url, high_thumbnail_url, updated_at
+		FROM channels WHERE channel_id = ?`, id)
+
+	var channel Channel
+	var updatedAt int64
+	err := row.Scan(
+		&channel.ChannelID,
+		&channel.Title,
+		&channel.Description,
+		&channel.Country,
+		&channel.SubscriberCount,
+		&channel.DefaultThumbnailURL,
+		&channel.MediumThumbnailURL,
+		&channel.HighThumbnailURL,
+		&updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	channel.UpdatedAt = fromSqliteTime(updatedAt)
+	return &channel, nil
+}
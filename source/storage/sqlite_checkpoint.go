@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+func NewSqliteCheckpointImpl() *SqliteCheckpointImpl {
+	return NewSqliteCheckpointImplWithDB(GetSqliteDB())
+}
+
+func NewSqliteCheckpointImplWithDB(db *sql.DB) *SqliteCheckpointImpl {
+	return &SqliteCheckpointImpl{
+		db: db,
+	}
+}
+
+// SqliteCheckpointImpl implements CheckpointInterface on top of sqlite
+type SqliteCheckpointImpl struct {
+	db *sql.DB
+}
+
+func (c *SqliteCheckpointImpl) ReadCheckpoint(ctx context.Context, queryID string) (*Checkpoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var checkpoint Checkpoint
+	var currentPublishedTime, previousPublishedTime, updatedAt int64
+
+	err := c.db.QueryRowContext(ctx, `SELECT query_id, current_published_time, previous_published_time, next_page_token, completed, updated_at
+		FROM checkpoints WHERE query_id = ?`, queryID).
+		Scan(&checkpoint.QueryID, &currentPublishedTime, &previousPublishedTime, &checkpoint.NextPageToken, &checkpoint.Completed, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	checkpoint.CurrentPublishedTime = fromSqliteTime(currentPublishedTime)
+	checkpoint.PreviousPublishedTime = fromSqliteTime(previousPublishedTime)
+	checkpoint.UpdatedAt = fromSqliteTime(updatedAt)
+	return &checkpoint, nil
+}
+
+func (c *SqliteCheckpointImpl) SaveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := c.db.ExecContext(ctx, `INSERT INTO checkpoints (query_id, current_published_time, previous_published_time, next_page_token, completed, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (query_id) DO UPDATE SET
+			current_published_time = excluded.current_published_time,
+			previous_published_time = excluded.previous_published_time,
+			next_page_token = excluded.next_page_token,
+			completed = excluded.completed,
+			updated_at = excluded.updated_at`,
+		checkpoint.QueryID,
+		toSqliteTime(checkpoint.CurrentPublishedTime),
+		toSqliteTime(checkpoint.PreviousPublishedTime),
+		checkpoint.NextPageToken,
+		checkpoint.Completed,
+		toSqliteTime(checkpoint.UpdatedAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
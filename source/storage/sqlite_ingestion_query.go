@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+func NewSqliteIngestionQueryImpl() *SqliteIngestionQueryImpl {
+	return NewSqliteIngestionQueryImplWithDB(GetSqliteDB())
+}
+
+func NewSqliteIngestionQueryImplWithDB(db *sql.DB) *SqliteIngestionQueryImpl {
+	return &SqliteIngestionQueryImpl{
+		db: db,
+	}
+}
+
+// SqliteIngestionQueryImpl implements IngestionQueryInterface on top of sqlite
+type SqliteIngestionQueryImpl struct {
+	db *sql.DB
+}
+
+const sqliteIngestionQueryColumns = "name, source, query, location, tag, poll_interval, paused, created_at, updated_at"
+
+func (q *SqliteIngestionQueryImpl) CreateIngestionQuery(ctx context.Context, ingestionQuery *IngestionQuery) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := q.db.ExecContext(ctx, "INSERT INTO ingestion_queries ("+sqliteIngestionQueryColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		ingestionQuery.Name,
+		ingestionQuery.Source,
+		ingestionQuery.Query,
+		ingestionQuery.Location,
+		ingestionQuery.Tag,
+		int64(ingestionQuery.PollInterval),
+		ingestionQuery.Paused,
+		toSqliteTime(ingestionQuery.CreatedAt),
+		toSqliteTime(ingestionQuery.UpdatedAt),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return ErrIngestionQueryExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (q *SqliteIngestionQueryImpl) ReadIngestionQuery(ctx context.Context, name string) (*IngestionQuery, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	row := q.db.QueryRowContext(ctx, "SELECT "+sqliteIngestionQueryColumns+" FROM ingestion_queries WHERE name = ?", name)
+
+	ingestionQuery, err := scanSqliteIngestionQuery(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return ingestionQuery, nil
+}
+
+func (q *SqliteIngestionQueryImpl) ListIngestionQueries(ctx context.Context) ([]*IngestionQuery, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := q.db.QueryContext(ctx, "SELECT "+sqliteIngestionQueryColumns+" FROM ingestion_queries ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ingestionQueries []*IngestionQuery
+	for rows.Next() {
+		ingestionQuery, err := scanSqliteIngestionQuery(rows)
+		if err != nil {
+			return nil, err
+		}
+		ingestionQueries = append(ingestionQueries, ingestionQuery)
+	}
+
+	return ingestionQueries, rows.Err()
+}
+
+func (q *SqliteIngestionQueryImpl) UpdateIngestionQuery(ctx context.Context, name string, ingestionQuery *IngestionQuery) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := q.db.ExecContext(ctx, `UPDATE ingestion_queries SET
+			source = ?, query = ?, location = ?, tag = ?, poll_interval = ?, paused = ?, created_at = ?, updated_at = ?
+		WHERE name = ?`,
+		ingestionQuery.Source,
+		ingestionQuery.Query,
+		ingestionQuery.Location,
+		ingestionQuery.Tag,
+		int64(ingestionQuery.PollInterval),
+		ingestionQuery.Paused,
+		toSqliteTime(ingestionQuery.CreatedAt),
+		toSqliteTime(ingestionQuery.UpdatedAt),
+		name,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *SqliteIngestionQueryImpl) DeleteIngestionQuery(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := q.db.ExecContext(ctx, "DELETE FROM ingestion_queries WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanSqliteIngestionQuery(row sqliteScanner) (*IngestionQuery, error) {
+	var ingestionQuery IngestionQuery
+	var pollInterval, createdAt, updatedAt int64
+
+	err := row.Scan(&ingestionQuery.Name, &ingestionQuery.Source, &ingestionQuery.Query, &ingestionQuery.Location, &ingestionQuery.Tag, &pollInterval, &ingestionQuery.Paused, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	ingestionQuery.PollInterval = time.Duration(pollInterval)
+	ingestionQuery.CreatedAt = fromSqliteTime(createdAt)
+	ingestionQuery.UpdatedAt = fromSqliteTime(updatedAt)
+	return &ingestionQuery, nil
+}
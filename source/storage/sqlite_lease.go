@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+func NewSqliteLeaseImpl() *SqliteLeaseImpl {
+	return NewSqliteLeaseImplWithDB(GetSqliteDB())
+}
+
+func NewSqliteLeaseImplWithDB(db *sql.DB) *SqliteLeaseImpl {
+	return &SqliteLeaseImpl{
+		db: db,
+	}
+}
+
+// SqliteLeaseImpl implements LeaseInterface on top of sqlite
+type SqliteLeaseImpl struct {
+	db *sql.DB
+}
+
+func (l *SqliteLeaseImpl) AcquireLease(ctx context.Context, name string, holder string, duration time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	// A lease held by another holder is left as it is, which changes no row
+	result, err := l.db.ExecContext(ctx, `INSERT INTO leases (name, holder, expires_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			holder = excluded.holder,
+			expires_at = excluded.expires_at
+		WHERE leases.holder = excluded.holder OR leases.expires_at <= ?`,
+		name, holder, toSqliteTime(now.Add(duration)), toSqliteTime(now))
+	if err != nil {
+		return false, err
+	}
+
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return changed > 0, nil
+}
+
+func (l *SqliteLeaseImpl) ReleaseLease(ctx context.Context, name string, holder string) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := l.db.ExecContext(ctx, "DELETE FROM leases WHERE name = ? AND holder = ?", name, holder)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
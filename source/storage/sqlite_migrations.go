@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+)
+
+/*
+Migrations for the sqlite driver. They take the place of BuildIndexes, since the
+sqlite schema (tables, indexes and the FTS5 search table) has to exist before
+anything can be stored.
+
+Every migration runs once, in order of version, inside its own transaction.
+Applied versions are recorded in the schema_migrations table.
+
+New migrations must be appended with the next version, never edit an applied one.
+*/
+type sqliteMigration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+var sqliteMigrations = []sqliteMigration{
+	{
+		version:     1,
+		description: "create users and video_metadata",
+		statements: []string{
+			`CREATE TABLE users (
+				user_id   TEXT PRIMARY KEY,
+				page_size INTEGER NOT NULL,
+				timestamp INTEGER NOT NULL
+			)`,
+			`CREATE TABLE video_metadata (
+				id                     INTEGER PRIMARY KEY,
+				video_id               TEXT NOT NULL UNIQUE,
+				title                  TEXT NOT NULL,
+				description            TEXT NOT NULL,
+				default_thumbnail_url  TEXT NOT NULL,
+				high_thumbnail_url     TEXT NOT NULL,
+				maxres_thumbnail_url   TEXT NOT NULL,
+				medium_thumbnail_url   TEXT NOT NULL,
+				standard_thumbnail_url TEXT NOT NULL,
+				published_at           INTEGER NOT NULL
+			)`,
+			`CREATE INDEX video_metadata_published_at ON video_metadata (published_at DESC)`,
+		},
+	},
+	{
+		version:     2,
+		description: "full text search on title and description",
+		statements: []string{
+			`CREATE VIRTUAL TABLE video_metadata_fts USING fts5(
+				title,
+				description,
+				content = 'video_metadata',
+				content_rowid = 'id',
+				tokenize = 'porter unicode61'
+			)`,
+			`CREATE TRIGGER video_metadata_fts_insert AFTER INSERT ON video_metadata BEGIN
+				INSERT INTO video_metadata_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+			END`,
+			`CREATE TRIGGER video_metadata_fts_delete AFTER DELETE ON video_metadata BEGIN
+				INSERT INTO video_metadata_fts (video_metadata_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+			END`,
+			`CREATE TRIGGER video_metadata_fts_update AFTER UPDATE ON video_metadata BEGIN
+				INSERT INTO video_metadata_fts (video_metadata_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+				INSERT INTO video_metadata_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+			END`,
+		},
+	},
+	{
+		version:     3,
+		description: "create checkpoints",
+		statements: []string{
+			`CREATE TABLE checkpoints (
+				query_id                TEXT PRIMARY KEY,
+				current_published_time  INTEGER NOT NULL,
+				previous_published_time INTEGER NOT NULL,
+				next_page_token         TEXT NOT NULL,
+				updated_at              INTEGER NOT NULL
+			)`,
+		},
+	},
+	{
+		version:     4,
+		description: "track completed checkpoints",
+		statements: []string{
+			`ALTER TABLE checkpoints ADD COLUMN completed INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version:     5,
+		description: "tag videos with the ingestion queries that found them",
+		statements: []string{
+			`ALTER TABLE video_metadata ADD COLUMN query_tags TEXT NOT NULL DEFAULT '[]'`,
+		},
+	},
+	{
+		version:     6,
+		description: "ingestion queries managed at runtime",
+		statements: []string{
+			`CREATE TABLE ingestion_queries (
+				name          TEXT PRIMARY KEY,
+				query         TEXT NOT NULL,
+				tag           TEXT NOT NULL,
+				poll_interval INTEGER NOT NULL,
+				paused        INTEGER NOT NULL DEFAULT 0,
+				created_at    INTEGER NOT NULL,
+				updated_at    INTEGER NOT NULL
+			)`,
+		},
+	},
+	{
+		version:     7,
+		description: "enrich videos with the details of videos.list",
+		statements: []string{
+			`ALTER TABLE video_metadata ADD COLUMN channel_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE video_metadata ADD COLUMN view_count INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE video_metadata ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE video_metadata ADD COLUMN comment_count INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE video_metadata ADD COLUMN duration INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE video_metadata ADD COLUMN tags TEXT NOT NULL DEFAULT '[]'`,
+			`ALTER TABLE video_metadata ADD COLUMN category_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE video_metadata ADD COLUMN default_language TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE video_metadata ADD COLUMN enriched_at INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version:     8,
+		description: "time series of video statistics",
+		statements: []string{
+			`CREATE TABLE video_statistics_snapshots (
+				video_id      TEXT NOT NULL,
+				timestamp     INTEGER NOT NULL,
+				view_count    INTEGER NOT NULL,
+				like_count    INTEGER NOT NULL,
+				comment_count INTEGER NOT NULL,
+				PRIMARY KEY (video_id, timestamp)
+			)`,
+		},
+	},
+	{
+		version:     9,
+		description: "channels and channel scoped feeds",
+		statements: []string{
+			`CREATE TABLE channels (
+				channel_id            TEXT PRIMARY KEY,
+				title                 TEXT NOT NULL,
+				description           TEXT NOT NULL,
+				country               TEXT NOT NULL,
+				subscriber_count      INTEGER NOT NULL,
+				default_thumbnail_url TEXT NOT NULL,
+				medium_thumbnail_url  TEXT NOT NULL,
+				high_thumbnail_url    TEXT NOT NULL,
+				updated_at            INTEGER NOT NULL
+			)`,
+			`ALTER TABLE video_metadata ADD COLUMN channel_title TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX video_metadata_channel_id_published_at ON video_metadata (channel_id, published_at DESC)`,
+		},
+	},
+	{
+		version:     10,
+		description: "sources of ingestion queries",
+		statements: []string{
+			`ALTER TABLE ingestion_queries ADD COLUMN source TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE ingestion_queries ADD COLUMN location TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version:     11,
+		description: "create leases",
+		statements: []string{
+			`CREATE TABLE leases (
+				name       TEXT PRIMARY KEY,
+				holder     TEXT NOT NULL,
+				expires_at INTEGER NOT NULL
+			)`,
+		},
+	},
+	{
+		version:     12,
+		description: "keyset pages of the feed",
+		statements: []string{
+			`CREATE INDEX video_metadata_published_at_video_id ON video_metadata (published_at DESC, video_id ASC)`,
+		},
+	},
+	{
+		version:     13,
+		description: "title suggestions",
+		statements: []string{
+			`CREATE TABLE title_suggestions (
+				video_id     TEXT PRIMARY KEY,
+				title        TEXT NOT NULL,
+				published_at INTEGER NOT NULL
+			)`,
+			`CREATE TABLE title_suggestion_grams (
+				gram     TEXT NOT NULL,
+				video_id TEXT NOT NULL,
+				PRIMARY KEY (gram, video_id)
+			) WITHOUT ROWID`,
+			`CREATE INDEX title_suggestion_grams_video_id ON title_suggestion_grams (video_id)`,
+		},
+	},
+}
+
+// MigrateSqlite brings the schema of db up to the latest migration
+func MigrateSqlite(ctx context.Context, db *sql.DB) error {
+	logger := common.GetLogger()
+
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version     INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at  INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	var currentVersion int
+	err = db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&currentVersion)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range sqliteMigrations {
+		if migration.version <= currentVersion {
+			continue
+		}
+
+		logger.WithField("Version", migration.version).
+			WithField("Description", migration.description).
+			Info("Applying sqlite migration")
+
+		err = applySqliteMigration(ctx, db, migration)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applySqliteMigration(ctx context.Context, db *sql.DB, migration sqliteMigration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range migration.statements {
+		_, err = tx.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)",
+		migration.version, migration.description, toSqliteTime(time.Now().UTC()))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+func NewSqliteSuggestionImpl() *SqliteSuggestionImpl {
+	return NewSqliteSuggestionImplWithDB(GetSqliteDB())
+}
+
+func NewSqliteSuggestionImplWithDB(db *sql.DB) *SqliteSuggestionImpl {
+	return &SqliteSuggestionImpl{
+		db: db,
+	}
+}
+
+// SqliteSuggestionImpl implements SuggestionInterface on top of sqlite, the grams
+// of every title are rows of title_suggestion_grams
+type SqliteSuggestionImpl struct {
+	db *sql.DB
+}
+
+func (s *SqliteSuggestionImpl) IndexTitles(ctx context.Context, videoMetadatas []*VideoMetadata) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, metadata := range videoMetadatas {
+		// A title published earlier than the indexed one changes no row
+		result, err := tx.ExecContext(ctx, `INSERT INTO title_suggestions (video_id, title, published_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT (video_id) DO UPDATE SET
+				title = excluded.title,
+				published_at = excluded.published_at
+			WHERE title_suggestions.published_at < excluded.published_at`,
+			metadata.VideoID, metadata.Title, toSqliteTime(metadata.PublishedAt))
+		if err != nil {
+			return err
+		}
+
+		changed, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if changed == 0 {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM title_suggestion_grams WHERE video_id = ?", metadata.VideoID)
+		if err != nil {
+			return err
+		}
+
+		for _, gram := range titleGrams(metadata.Title) {
+			_, err = tx.ExecContext(ctx, "INSERT INTO title_suggestion_grams (gram, video_id) VALUES (?, ?)", gram, metadata.VideoID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SqliteSuggestionImpl) SuggestTitles(ctx context.Context, text string, limit int) ([]*TitleSuggestion, error) {
+	grams := titleGrams(text)
+	if len(grams) == 0 {
+		return []*TitleSuggestion{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	args := []interface{}{}
+	for _, gram := range grams {
+		args = append(args, gram)
+	}
+	args = append(args, maxSuggestionCandidates)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT title_suggestions.video_id, title, published_at FROM title_suggestion_grams
+		JOIN title_suggestions ON title_suggestions.video_id = title_suggestion_grams.video_id
+		WHERE gram IN (`+sqlitePlaceholders(len(grams))+`)
+		GROUP BY title_suggestions.video_id
+		ORDER BY COUNT(*) DESC, title_suggestions.video_id ASC
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var candidates []*TitleSuggestion
+	for rows.Next() {
+		var candidate TitleSuggestion
+		var publishedAt int64
+
+		err = rows.Scan(&candidate.VideoID, &candidate.Title, &publishedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		candidate.PublishedAt = fromSqliteTime(publishedAt)
+		candidates = append(candidates, &candidate)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return rankSuggestions(text, candidates, limit), nil
+}
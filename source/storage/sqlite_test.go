@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateSqlite_Idempotent(t *testing.T) {
+	db, err := OpenSqliteDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, MigrateSqlite(context.Background(), db))
+	require.NoError(t, MigrateSqlite(context.Background(), db))
+
+	var version, count int
+	err = db.QueryRow("SELECT MAX(version), COUNT(*) FROM schema_migrations").Scan(&version, &count)
+	require.NoError(t, err)
+	require.Equal(t, sqliteMigrations[len(sqliteMigrations)-1].version, version)
+	require.Equal(t, len(sqliteMigrations), count)
+}
+
+func TestFtsQuery(t *testing.T) {
+	testCases := map[string]string{
+		"cricket":                 `("cricket")`,
+		"cricket football":        `("cricket" OR "football")`,
+		"the":                     ``,
+		"news -transfer":          `(("news") NOT "transfer")`,
+		"\"world cup\" highlight": `(("world" OR "cup" OR "highlight") AND "world cup")`,
+	}
+
+	for searchText, expected := range testCases {
+		require.Equal(t, expected, newTextSearch(searchText).ftsQuery(), searchText)
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+func NewSqliteUserImpl() *SqliteUserImpl {
+	return NewSqliteUserImplWithDB(GetSqliteDB())
+}
+
+func NewSqliteUserImplWithDB(db *sql.DB) *SqliteUserImpl {
+	return &SqliteUserImpl{
+		db: db,
+	}
+}
+
+// SqliteUserImpl implements UserInterface on top of sqlite
+type SqliteUserImpl struct {
+	db *sql.DB
+}
+
+func (u *SqliteUserImpl) CreateUser(ctx context.Context, user *User) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// user_id is unique, an existing user is left untouched
+	_, err := u.db.ExecContext(ctx, "INSERT OR IGNORE INTO users (user_id, page_size, timestamp) VALUES (?, ?, ?)",
+		user.UserID, user.PageSize, toSqliteTime(user.Timestamp))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *SqliteUserImpl) ReadUser(ctx context.Context, userID string) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var user User
+	var timestamp int64
+
+	err := u.db.QueryRowContext(ctx, "SELECT user_id, page_size, timestamp FROM users WHERE user_id = ?", userID).
+		Scan(&user.UserID, &user.PageSize, &timestamp)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	user.Timestamp = fromSqliteTime(timestamp)
+	return &user, nil
+}
+
+func (u *SqliteUserImpl) UpdateUser(ctx context.Context, id string, user *User) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := u.db.ExecContext(ctx, "UPDATE users SET user_id = ?, page_size = ?, timestamp = ? WHERE user_id = ?",
+		user.UserID, user.PageSize, toSqliteTime(user.Timestamp), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,581 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const sqliteVideoMetadataColumns = `video_id, title, description, default_thumbnail_url, high_thumbnail_url,
+	maxres_thumbnail_url, medium_thumbnail_url, standard_thumbnail_url, published_at, query_tags,
+	channel_id, view_count, like_count, comment_count, duration, tags, category_id, default_language, enriched_at,
+	channel_title`
+
+func NewSqliteVideoMetadataImpl() *SqliteVideoMetadataImpl {
+	return NewSqliteVideoMetadataImplWithDB(GetSqliteDB())
+}
+
+func NewSqliteVideoMetadataImplWithDB(db *sql.DB) *SqliteVideoMetadataImpl {
+	return &SqliteVideoMetadataImpl{
+		db: db,
+	}
+}
+
+// SqliteVideoMetadataImpl implements VideoMetadataInterface on top of sqlite
+type SqliteVideoMetadataImpl struct {
+	db *sql.DB
+}
+
+func (m *SqliteVideoMetadataImpl) BulkInsertMetadata(ctx context.Context, videoMetadatas []*VideoMetadata) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statement := `INSERT INTO video_metadata (` + sqliteVideoMetadataColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	for _, metadata := range videoMetadatas {
+		_, err = tx.ExecContext(ctx, statement, sqliteVideoMetadataValues(metadata)...)
+		if err != nil {
+			if !strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return err
+			}
+
+			// Like an ordered InsertMany, the videos before the duplicate are kept
+			err = tx.Commit()
+			if err != nil {
+				return err
+			}
+			return ErrVideoMetadataExists
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *SqliteVideoMetadataImpl) FindOneMetadataWithVideoID(ctx context.Context, id string) (*VideoMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	row := m.db.QueryRowContext(ctx, "SELECT "+sqliteVideoMetadataColumns+" FROM video_metadata WHERE video_id = ?", id)
+
+	metadata, err := scanSqliteVideoMetadata(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func (m *SqliteVideoMetadataImpl) UpdateOneMetadata(ctx context.Context, id string, videoMetadata *VideoMetadata) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	statement := `UPDATE video_metadata SET
+			video_id = ?,
+			title = ?,
+			description = ?,
+			default_thumbnail_url = ?,
+			high_thumbnail_url = ?,
+			maxres_thumbnail_url = ?,
+			medium_thumbnail_url = ?,
+			standard_thumbnail_url = ?,
+			published_at = ?,
+			query_tags = ?,
+			channel_id = ?,
+			view_count = ?,
+			like_count = ?,
+			comment_count = ?,
+			duration = ?,
+			tags = ?,
+			category_id = ?,
+			default_language = ?,
+			enriched_at = ?,
+			channel_title = ?
+		WHERE video_id = ?`
+
+	args := append(sqliteVideoMetadataValues(videoMetadata), id)
+	_, err := m.db.ExecContext(ctx, statement, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SqliteVideoMetadataImpl) UpdateMetadataStatistics(ctx context.Context, snapshot *VideoStatisticsSnapshot) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	statement := `UPDATE video_metadata SET view_count = ?, like_count = ?, comment_count = ? WHERE video_id = ?`
+
+	_, err := m.db.ExecContext(ctx, statement, snapshot.ViewCount, snapshot.LikeCount, snapshot.CommentCount, snapshot.VideoID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Sqlite has a single writer, the transaction makes reading and writing every video atomic
+func (m *SqliteVideoMetadataImpl) BulkUpsertMetadata(ctx context.Context, videoMetadatas []*VideoMetadata) ([]*UpsertResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := []*UpsertResult{}
+	for _, metadata := range videoMetadatas {
+		outcome, err := sqliteUpsertVideoMetadata(ctx, tx, metadata)
+
+		result := &UpsertResult{VideoID: metadata.VideoID, Outcome: outcome}
+		if err != nil {
+			result.Outcome = UpsertFailed
+			result.Err = err
+		}
+		results = append(results, result)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func sqliteUpsertVideoMetadata(ctx context.Context, tx *sql.Tx, metadata *VideoMetadata) (UpsertOutcome, error) {
+	var publishedAt int64
+	var encodedQueryTags string
+	err := tx.QueryRowContext(ctx, "SELECT published_at, query_tags FROM video_metadata WHERE video_id = ?", metadata.VideoID).
+		Scan(&publishedAt, &encodedQueryTags)
+	if err == sql.ErrNoRows {
+		_, err = tx.ExecContext(ctx, `INSERT INTO video_metadata (`+sqliteVideoMetadataColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, sqliteVideoMetadataValues(metadata)...)
+		return UpsertInserted, err
+	}
+	if err != nil {
+		return UpsertFailed, err
+	}
+
+	queryTags, err := fromSqliteStrings(encodedQueryTags)
+	if err != nil {
+		return UpsertFailed, err
+	}
+	mergedQueryTags := mergeQueryTags(queryTags, metadata.QueryTags)
+
+	if fromSqliteTime(publishedAt).Before(metadata.PublishedAt) {
+		replacement := *metadata
+		replacement.QueryTags = mergedQueryTags
+		_, err = tx.ExecContext(ctx, `UPDATE video_metadata SET
+				title = ?,
+				description = ?,
+				default_thumbnail_url = ?,
+				high_thumbnail_url = ?,
+				maxres_thumbnail_url = ?,
+				medium_thumbnail_url = ?,
+				standard_thumbnail_url = ?,
+				published_at = ?,
+				query_tags = ?,
+				channel_id = ?,
+				view_count = ?,
+				like_count = ?,
+				comment_count = ?,
+				duration = ?,
+				tags = ?,
+				category_id = ?,
+				default_language = ?,
+				enriched_at = ?,
+				channel_title = ?
+			WHERE video_id = ?`, append(sqliteVideoMetadataValues(&replacement)[1:], metadata.VideoID)...)
+		return UpsertMatched, err
+	}
+
+	if len(mergedQueryTags) != len(queryTags) {
+		_, err = tx.ExecContext(ctx, "UPDATE video_metadata SET query_tags = ? WHERE video_id = ?",
+			toSqliteStrings(mergedQueryTags), metadata.VideoID)
+	}
+	return UpsertMatched, err
+}
+
+func (m *SqliteVideoMetadataImpl) FetchPagedMetadata(ctx context.Context, timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// A negative limit is no limit in sqlite, zero is no limit in mongo
+	if limit == 0 {
+		limit = -1
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT `+sqliteVideoMetadataColumns+` FROM video_metadata
+		WHERE published_at <= ?
+		ORDER BY published_at DESC, video_id ASC
+		LIMIT ? OFFSET ?`, toSqliteTime(timestamp), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSqliteVideoMetadataRows(rows)
+}
+
+func (m *SqliteVideoMetadataImpl) FetchMetadataPageByKey(ctx context.Context, timestamp time.Time, key *FeedKey, before bool, limit int64) ([]*VideoMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if limit == 0 {
+		limit = -1
+	}
+
+	// Pages before the key are read in reverse, from the key upwards
+	publishedAtOperator, videoIDOperator := "<", ">"
+	order := "published_at DESC, video_id ASC"
+	if before {
+		publishedAtOperator, videoIDOperator = ">", "<"
+		order = "published_at ASC, video_id DESC"
+	}
+
+	where := "published_at <= ?"
+	args := []interface{}{toSqliteTime(timestamp)}
+	if key != nil {
+		where += " AND (published_at " + publishedAtOperator + " ? OR (published_at = ? AND video_id " + videoIDOperator + " ?))"
+		args = append(args, toSqliteTime(key.PublishedAt), toSqliteTime(key.PublishedAt), key.VideoID)
+	}
+	args = append(args, limit)
+
+	rows, err := m.db.QueryContext(ctx, `SELECT `+sqliteVideoMetadataColumns+` FROM video_metadata
+		WHERE `+where+`
+		ORDER BY `+order+`
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := scanSqliteVideoMetadataRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if before {
+		reverseVideoMetadata(metadata)
+	}
+	return metadata, nil
+}
+
+func (m *SqliteVideoMetadataImpl) FetchPagedMetadataWithChannelID(ctx context.Context, channelID string, timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if limit == 0 {
+		limit = -1
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT `+sqliteVideoMetadataColumns+` FROM video_metadata
+		WHERE channel_id = ? AND published_at <= ?
+		ORDER BY published_at DESC, video_id ASC
+		LIMIT ? OFFSET ?`, channelID, toSqliteTime(timestamp), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSqliteVideoMetadataRows(rows)
+}
+
+func (m *SqliteVideoMetadataImpl) FindMetadataTextSearch(ctx context.Context, searchText string) ([]*VideoMetadata, error) {
+	matchQuery := newTextSearch(searchText).ftsQuery()
+	if matchQuery == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, `SELECT `+sqliteVideoMetadataColumns+` FROM video_metadata
+		WHERE id IN (SELECT rowid FROM video_metadata_fts WHERE video_metadata_fts MATCH ?)
+		ORDER BY published_at DESC, video_id ASC`, matchQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSqliteVideoMetadataRows(rows)
+}
+
+// Searches the fts table when there is a text, its bm25 rank is the score of a result
+func (m *SqliteVideoMetadataImpl) SearchMetadata(ctx context.Context, query *SearchQuery) (*SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	from := "video_metadata"
+	score := "0.0"
+	where := []string{"1 = 1"}
+	args := []interface{}{}
+
+	if query.hasText() {
+		matchQuery, ok := query.ftsQuery()
+		if !ok {
+			return &SearchResult{}, nil
+		}
+
+		// bm25 is lower for better matches, its weights follow the columns of the fts table
+		from = "video_metadata JOIN video_metadata_fts ON video_metadata_fts.rowid = video_metadata.id"
+		score = fmt.Sprintf("-bm25(video_metadata_fts, %d.0, %d.0)", titleWeight, descriptionWeight)
+		where = append(where, "video_metadata_fts MATCH ?")
+		args = append(args, matchQuery)
+	}
+
+	if !query.PublishedAfter.IsZero() {
+		where = append(where, "published_at >= ?")
+		args = append(args, toSqliteTime(query.PublishedAfter))
+	}
+	if !query.PublishedBefore.IsZero() {
+		where = append(where, "published_at <= ?")
+		args = append(args, toSqliteTime(query.PublishedBefore))
+	}
+	if len(query.ChannelIDs) > 0 {
+		where = append(where, "channel_id IN ("+sqlitePlaceholders(len(query.ChannelIDs))+")")
+		for _, channelID := range query.ChannelIDs {
+			args = append(args, channelID)
+		}
+	}
+	if len(query.QueryTags) > 0 {
+		where = append(where, "EXISTS (SELECT 1 FROM json_each(query_tags) WHERE value IN ("+sqlitePlaceholders(len(query.QueryTags))+"))")
+		for _, tag := range query.QueryTags {
+			args = append(args, tag)
+		}
+	}
+
+	matches := `SELECT video_metadata.*, ` + score + ` AS score FROM ` + from + ` WHERE ` + strings.Join(where, " AND ")
+
+	result := &SearchResult{}
+	err := m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM (`+matches+`)`, args...).Scan(&result.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	sortColumn := "published_at"
+	if query.Sort == SortByRelevance {
+		sortColumn = "score"
+	}
+
+	after := "1 = 1"
+	if query.After != nil {
+		var key interface{} = toSqliteTime(query.After.PublishedAt)
+		if query.Sort == SortByRelevance {
+			key = query.After.Score
+		}
+
+		after = "(" + sortColumn + " < ? OR (" + sortColumn + " = ? AND video_id > ?))"
+		args = append(args, key, key, query.After.VideoID)
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = -1
+	}
+	args = append(args, limit)
+
+	rows, err := m.db.QueryContext(ctx, `SELECT `+sqliteVideoMetadataColumns+`, score FROM (`+matches+`)
+		WHERE `+after+`
+		ORDER BY `+sortColumn+` DESC, video_id ASC
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		hit := &SearchHit{}
+		hit.Metadata, err = scanSqliteVideoMetadata(scoredSqliteRow{row: rows, score: &hit.Score})
+		if err != nil {
+			return nil, err
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (m *SqliteVideoMetadataImpl) FindMetadataPublishedAfter(ctx context.Context, timestamp time.Time) ([]*VideoMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, `SELECT `+sqliteVideoMetadataColumns+` FROM video_metadata
+		WHERE published_at >= ?
+		ORDER BY published_at DESC, video_id ASC`, toSqliteTime(timestamp))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSqliteVideoMetadataRows(rows)
+}
+
+// ftsQuery turns a parsed search into an FTS5 MATCH expression
+func (s *textSearch) ftsQuery() string {
+	if len(s.terms) == 0 {
+		return ""
+	}
+
+	quote := func(text string) string {
+		return "\"" + strings.ReplaceAll(text, "\"", "\"\"") + "\""
+	}
+
+	var terms []string
+	for _, term := range s.terms {
+		terms = append(terms, quote(term))
+	}
+	query := "(" + strings.Join(terms, " OR ") + ")"
+
+	for _, phrase := range s.phrases {
+		query = "(" + query + " AND " + quote(phrase) + ")"
+	}
+
+	for _, term := range s.negatedTerms {
+		query = "(" + query + " NOT " + quote(term) + ")"
+	}
+
+	return query
+}
+
+// ftsQuery combines the texts of a search into one MATCH expression, the title
+// and description texts are restricted to their column. Not ok when one of the
+// texts has no term, nothing can match it.
+func (q *SearchQuery) ftsQuery() (string, bool) {
+	var expressions []string
+	for _, scoped := range []struct {
+		column string
+		text   string
+	}{{"", q.Text}, {"title", q.Title}, {"description", q.Description}} {
+		if scoped.text == "" {
+			continue
+		}
+
+		expression := newTextSearch(scoped.text).ftsQuery()
+		if expression == "" {
+			return "", false
+		}
+		if scoped.column != "" {
+			expression = scoped.column + " : " + expression
+		}
+		expressions = append(expressions, expression)
+	}
+
+	return strings.Join(expressions, " AND "), true
+}
+
+func sqliteVideoMetadataValues(metadata *VideoMetadata) []interface{} {
+	return []interface{}{
+		metadata.VideoID,
+		metadata.Title,
+		metadata.Description,
+		metadata.DefaultThumbnailURL,
+		metadata.HighThumbnailURL,
+		metadata.MaxresThumbnailURL,
+		metadata.MediumThumbnailURL,
+		metadata.StandardThumbnailURL,
+		toSqliteTime(metadata.PublishedAt),
+		toSqliteStrings(metadata.QueryTags),
+		metadata.ChannelID,
+		metadata.ViewCount,
+		metadata.LikeCount,
+		metadata.CommentCount,
+		int64(metadata.Duration),
+		toSqliteStrings(metadata.Tags),
+		metadata.CategoryID,
+		metadata.DefaultLanguage,
+		toSqliteTime(metadata.EnrichedAt),
+		metadata.ChannelTitle,
+	}
+}
+
+type sqliteScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSqliteVideoMetadata(row sqliteScanner) (*VideoMetadata, error) {
+	var metadata VideoMetadata
+	var publishedAt, duration, enrichedAt int64
+	var queryTags, tags string
+
+	err := row.Scan(
+		&metadata.VideoID,
+		&metadata.Title,
+		&metadata.Description,
+		&metadata.DefaultThumbnailURL,
+		&metadata.HighThumbnailURL,
+		&metadata.MaxresThumbnailURL,
+		&metadata.MediumThumbnailURL,
+		&metadata.StandardThumbnailURL,
+		&publishedAt,
+		&queryTags,
+		&metadata.ChannelID,
+		&metadata.ViewCount,
+		&metadata.LikeCount,
+		&metadata.CommentCount,
+		&duration,
+		&tags,
+		&metadata.CategoryID,
+		&metadata.DefaultLanguage,
+		&enrichedAt,
+		&metadata.ChannelTitle,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.PublishedAt = fromSqliteTime(publishedAt)
+	metadata.Duration = time.Duration(duration)
+	metadata.EnrichedAt = fromSqliteTime(enrichedAt)
+	metadata.QueryTags, err = fromSqliteStrings(queryTags)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Tags, err = fromSqliteStrings(tags)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// scoredSqliteRow scans the score selected after the columns of a video
+type scoredSqliteRow struct {
+	row   sqliteScanner
+	score *float64
+}
+
+func (r scoredSqliteRow) Scan(dest ...interface{}) error {
+	return r.row.Scan(append(dest, r.score)...)
+}
+
+func scanSqliteVideoMetadataRows(rows *sql.Rows) ([]*VideoMetadata, error) {
+	defer rows.Close()
+
+	var metadata []*VideoMetadata
+	for rows.Next() {
+		videoMetadata, err := scanSqliteVideoMetadata(rows)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, videoMetadata)
+	}
+
+	return metadata, rows.Err()
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+func NewSqliteVideoStatisticsSnapshotImpl() *SqliteVideoStatisticsSnapshotImpl {
+	return NewSqliteVideoStatisticsSnapshotImplWithDB(GetSqliteDB())
+}
+
+func NewSqliteVideoStatisticsSnapshotImplWithDB(db *sql.DB) *SqliteVideoStatisticsSnapshotImpl {
+	return &SqliteVideoStatisticsSnapshotImpl{
+		db: db,
+	}
+}
+
+// SqliteVideoStatisticsSnapshotImpl implements VideoStatisticsSnapshotInterface on top of sqlite
+type SqliteVideoStatisticsSnapshotImpl struct {
+	db *sql.DB
+}
+
+func (m *SqliteVideoStatisticsSnapshotImpl) BulkInsertSnapshots(ctx context.Context, snapshots []*VideoStatisticsSnapshot) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, snapshot := range snapshots {
+		_, err = tx.ExecContext(ctx, `INSERT INTO video_statistics_snapshots (video_id, timestamp, view_count, like_count, comment_count)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (video_id, timestamp) DO NOTHING`,
+			snapshot.VideoID,
+			toSqliteTime(snapshot.Timestamp),
+			snapshot.ViewCount,
+			snapshot.LikeCount,
+			snapshot.CommentCount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *SqliteVideoStatisticsSnapshotImpl) FindSnapshotsWithVideoID(ctx context.Context, id string) ([]*VideoStatisticsSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, `SELECT video_id, timestamp, view_count, like_count, comment_count
+		FROM video_statistics_snapshots WHERE video_id = ? ORDER BY timestamp ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []*VideoStatisticsSnapshot
+	for rows.Next() {
+		var snapshot VideoStatisticsSnapshot
+		var timestamp int64
+
+		err := rows.Scan(&snapshot.VideoID, &timestamp, &snapshot.ViewCount, &snapshot.LikeCount, &snapshot.CommentCount)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot.Timestamp = fromSqliteTime(timestamp)
+		snapshots = append(snapshots, &snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
@@ -33,7 +33,40 @@ func GetDatabase() *mongo.Database {
 
 func Init() {
 	config := common.GetConfiguration()
-	initaliseMongoClient(config.MongoBaseURL)
+	switch config.StorageDriver {
+	case common.MemoryStorageDriver:
+		return
+	case common.SqliteStorageDriver:
+		initaliseSqliteDB(config.SqlitePath)
+	default:
+		initaliseMongoClient(config.MongoBaseURL)
+	}
+}
+
+// NewVideoMetadataHandler returns the VideoMetadataInterface for the configured storage driver
+func NewVideoMetadataHandler() VideoMetadataInterface {
+	config := common.GetConfiguration()
+	switch config.StorageDriver {
+	case common.MemoryStorageDriver:
+		return NewMemoryVideoMetadataImpl()
+	case common.SqliteStorageDriver:
+		return NewSqliteVideoMetadataImpl()
+	default:
+		return NewVideoMetadataImpl()
+	}
+}
+
+// NewUserHandler returns the UserInterface for the configured storage driver
+func NewUserHandler() UserInterface {
+	config := common.GetConfiguration()
+	switch config.StorageDriver {
+	case common.MemoryStorageDriver:
+		return NewMemoryUserImpl()
+	case common.SqliteStorageDriver:
+		return NewSqliteUserImpl()
+	default:
+		return NewUserImpl()
+	}
 }
 
 func initaliseMongoClient(mongoBaseURL string) {
@@ -60,3 +93,81 @@ func initaliseMongoClient(mongoBaseURL string) {
 	}
 
 }
+
+// NewCheckpointHandler returns the CheckpointInterface for the configured storage driver
+func NewCheckpointHandler() CheckpointInterface {
+	config := common.GetConfiguration()
+	switch config.StorageDriver {
+	case common.MemoryStorageDriver:
+		return NewMemoryCheckpointImpl()
+	case common.SqliteStorageDriver:
+		return NewSqliteCheckpointImpl()
+	default:
+		return NewCheckpointImpl()
+	}
+}
+
+// NewIngestionQueryHandler returns the IngestionQueryInterface for the configured storage driver
+func NewIngestionQueryHandler() IngestionQueryInterface {
+	config := common.GetConfiguration()
+	switch config.StorageDriver {
+	case common.MemoryStorageDriver:
+		return NewMemoryIngestionQueryImpl()
+	case common.SqliteStorageDriver:
+		return NewSqliteIngestionQueryImpl()
+	default:
+		return NewIngestionQueryImpl()
+	}
+}
+
+// NewVideoStatisticsSnapshotHandler returns the VideoStatisticsSnapshotInterface for the configured storage driver
+func NewVideoStatisticsSnapshotHandler() VideoStatisticsSnapshotInterface {
+	config := common.GetConfiguration()
+	switch config.StorageDriver {
+	case common.MemoryStorageDriver:
+		return NewMemoryVideoStatisticsSnapshotImpl()
+	case common.SqliteStorageDriver:
+		return NewSqliteVideoStatisticsSnapshotImpl()
+	default:
+		return NewVideoStatisticsSnapshotImpl()
+	}
+}
+
+// NewChannelHandler returns the ChannelInterface for the configured storage driver
+func NewChannelHandler() ChannelInterface {
+	config := common.GetConfiguration()
+	switch config.StorageDriver {
+	case common.MemoryStorageDriver:
+		return NewMemoryChannelImpl()
+	case common.SqliteStorageDriver:
+		return NewSqliteChannelImpl()
+	default:
+		return NewChannelImpl()
+	}
+}
+
+// NewLeaseHandler returns the LeaseInterface for the configured storage driver
+func NewLeaseHandler() LeaseInterface {
+	config := common.GetConfiguration()
+	switch config.StorageDriver {
+	case common.MemoryStorageDriver:
+		return NewMemoryLeaseImpl()
+	case common.SqliteStorageDriver:
+		return NewSqliteLeaseImpl()
+	default:
+		return NewLeaseImpl()
+	}
+}
+
+// NewSuggestionHandler returns the SuggestionInterface for the configured storage driver
+func NewSuggestionHandler() SuggestionInterface {
+	config := common.GetConfiguration()
+	switch config.StorageDriver {
+	case common.MemoryStorageDriver:
+		return NewMemorySuggestionImpl()
+	case common.SqliteStorageDriver:
+		return NewSqliteSuggestionImpl()
+	default:
+		return NewSuggestionImpl()
+	}
+}
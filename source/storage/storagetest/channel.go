@@ -0,0 +1,69 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// ChannelSuite holds the contract of storage.ChannelInterface
+type ChannelSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	newBackend BackendFactory
+	ctx        context.Context
+	handler    storage.ChannelInterface
+
+	now time.Time
+}
+
+func (s *ChannelSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctx = context.Background()
+	s.handler = s.newBackend(s.T()).Channel
+	s.now = time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func (s *ChannelSuite) TestReadChannel_Missing() {
+	channel, err := s.handler.ReadChannel(s.ctx, "missing")
+	s.NoError(err)
+	s.Nil(channel)
+}
+
+func (s *ChannelSuite) TestUpsertChannel_RoundTrip() {
+	expected := &storage.Channel{
+		ChannelID:           "channel",
+		Title:               "title",
+		Description:         "description",
+		Country:             "IN",
+		SubscriberCount:     1000,
+		DefaultThumbnailURL: "default",
+		MediumThumbnailURL:  "medium",
+		HighThumbnailURL:    "high",
+		UpdatedAt:           s.now,
+	}
+	s.NoError(s.handler.UpsertChannel(s.ctx, expected))
+
+	channel, err := s.handler.ReadChannel(s.ctx, "channel")
+	s.NoError(err)
+	s.NotNil(channel)
+	s.True(s.now.Equal(channel.UpdatedAt))
+
+	channel.UpdatedAt = expected.UpdatedAt
+	s.Equal(expected, channel)
+}
+
+func (s *ChannelSuite) TestUpsertChannel_Overwrites() {
+	s.NoError(s.handler.UpsertChannel(s.ctx, &storage.Channel{ChannelID: "channel", Title: "old", SubscriberCount: 10, UpdatedAt: s.now}))
+	s.NoError(s.handler.UpsertChannel(s.ctx, &storage.Channel{ChannelID: "channel", Title: "new", SubscriberCount: 20, UpdatedAt: s.now.Add(time.Hour)}))
+
+	channel, err := s.handler.ReadChannel(s.ctx, "channel")
+	s.NoError(err)
+	s.Equal("new", channel.Title)
+	s.Equal(int64(20), channel.SubscriberCount)
+	s.True(s.now.Add(time.Hour).Equal(channel.UpdatedAt))
+}
@@ -0,0 +1,77 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// CheckpointSuite holds the contract of storage.CheckpointInterface
+type CheckpointSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	newBackend BackendFactory
+	ctx        context.Context
+	handler    storage.CheckpointInterface
+
+	now time.Time
+}
+
+func (s *CheckpointSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctx = context.Background()
+	s.handler = s.newBackend(s.T()).Checkpoint
+	s.now = time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func (s *CheckpointSuite) TestReadCheckpoint_Missing() {
+	checkpoint, err := s.handler.ReadCheckpoint(s.ctx, "missing")
+	s.NoError(err)
+	s.Nil(checkpoint)
+}
+
+func (s *CheckpointSuite) TestSaveCheckpoint_RoundTrip() {
+	s.NoError(s.handler.SaveCheckpoint(s.ctx, &storage.Checkpoint{
+		QueryID:              "query",
+		CurrentPublishedTime: s.now,
+		NextPageToken:        "token",
+		Completed:            true,
+		UpdatedAt:            s.now,
+	}))
+
+	checkpoint, err := s.handler.ReadCheckpoint(s.ctx, "query")
+	s.NoError(err)
+	s.Equal("query", checkpoint.QueryID)
+	s.Equal("token", checkpoint.NextPageToken)
+	s.True(checkpoint.Completed)
+	s.True(s.now.Equal(checkpoint.CurrentPublishedTime))
+	s.True(s.now.Equal(checkpoint.UpdatedAt))
+	s.True(checkpoint.PreviousPublishedTime.IsZero())
+}
+
+func (s *CheckpointSuite) TestSaveCheckpoint_Overwrites() {
+	s.NoError(s.handler.SaveCheckpoint(s.ctx, &storage.Checkpoint{QueryID: "query", CurrentPublishedTime: s.now, NextPageToken: "token"}))
+	s.NoError(s.handler.SaveCheckpoint(s.ctx, &storage.Checkpoint{QueryID: "other", CurrentPublishedTime: s.now}))
+
+	later := s.now.Add(time.Minute)
+	s.NoError(s.handler.SaveCheckpoint(s.ctx, &storage.Checkpoint{
+		QueryID:               "query",
+		CurrentPublishedTime:  later,
+		PreviousPublishedTime: s.now,
+	}))
+
+	checkpoint, err := s.handler.ReadCheckpoint(s.ctx, "query")
+	s.NoError(err)
+	s.Equal("", checkpoint.NextPageToken)
+	s.True(later.Equal(checkpoint.CurrentPublishedTime))
+	s.True(s.now.Equal(checkpoint.PreviousPublishedTime))
+
+	// Checkpoints of other queries are left alone
+	checkpoint, err = s.handler.ReadCheckpoint(s.ctx, "other")
+	s.NoError(err)
+	s.True(s.now.Equal(checkpoint.CurrentPublishedTime))
+}
@@ -0,0 +1,146 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// IngestionQuerySuite holds the contract of storage.IngestionQueryInterface
+type IngestionQuerySuite struct {
+	suite.Suite
+	*require.Assertions
+
+	newBackend BackendFactory
+	ctx        context.Context
+	handler    storage.IngestionQueryInterface
+
+	now time.Time
+}
+
+func (s *IngestionQuerySuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctx = context.Background()
+	s.handler = s.newBackend(s.T()).IngestionQuery
+	s.now = time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func ingestionQueryNames(ingestionQueries []*storage.IngestionQuery) []string {
+	var names []string
+	for _, ingestionQuery := range ingestionQueries {
+		names = append(names, ingestionQuery.Name)
+	}
+	return names
+}
+
+func (s *IngestionQuerySuite) TestReadIngestionQuery_Missing() {
+	ingestionQuery, err := s.handler.ReadIngestionQuery(s.ctx, "missing")
+	s.NoError(err)
+	s.Nil(ingestionQuery)
+}
+
+func (s *IngestionQuerySuite) TestCreateIngestionQuery_RoundTrip() {
+	expected := &storage.IngestionQuery{
+		Name:         "cricket",
+		Source:       "search",
+		Query:        "cricket world cup",
+		Tag:          "sports",
+		PollInterval: 30 * time.Second,
+		Paused:       true,
+		CreatedAt:    s.now,
+		UpdatedAt:    s.now,
+	}
+	s.NoError(s.handler.CreateIngestionQuery(s.ctx, expected))
+
+	ingestionQuery, err := s.handler.ReadIngestionQuery(s.ctx, "cricket")
+	s.NoError(err)
+	s.NotNil(ingestionQuery)
+	s.True(s.now.Equal(ingestionQuery.CreatedAt))
+	s.True(s.now.Equal(ingestionQuery.UpdatedAt))
+
+	ingestionQuery.CreatedAt = expected.CreatedAt
+	ingestionQuery.UpdatedAt = expected.UpdatedAt
+	s.Equal(expected, ingestionQuery)
+}
+
+func (s *IngestionQuerySuite) TestCreateIngestionQuery_Duplicate() {
+	s.NoError(s.handler.CreateIngestionQuery(s.ctx, &storage.IngestionQuery{Name: "cricket", Query: "cricket", CreatedAt: s.now}))
+
+	err := s.handler.CreateIngestionQuery(s.ctx, &storage.IngestionQuery{Name: "cricket", Query: "other", CreatedAt: s.now})
+	s.ErrorIs(err, storage.ErrIngestionQueryExists)
+
+	ingestionQuery, err := s.handler.ReadIngestionQuery(s.ctx, "cricket")
+	s.NoError(err)
+	s.Equal("cricket", ingestionQuery.Query)
+}
+
+func (s *IngestionQuerySuite) TestListIngestionQueries_OrderedByName() {
+	ingestionQueries, err := s.handler.ListIngestionQueries(s.ctx)
+	s.NoError(err)
+	s.Empty(ingestionQueries)
+
+	for _, name := range []string{"tennis", "cricket", "football"} {
+		s.NoError(s.handler.CreateIngestionQuery(s.ctx, &storage.IngestionQuery{Name: name, Query: name, CreatedAt: s.now}))
+	}
+
+	ingestionQueries, err = s.handler.ListIngestionQueries(s.ctx)
+	s.NoError(err)
+	s.Equal([]string{"cricket", "football", "tennis"}, ingestionQueryNames(ingestionQueries))
+}
+
+func (s *IngestionQuerySuite) TestUpdateIngestionQuery_Overwrites() {
+	s.NoError(s.handler.CreateIngestionQuery(s.ctx, &storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute, CreatedAt: s.now}))
+	s.NoError(s.handler.CreateIngestionQuery(s.ctx, &storage.IngestionQuery{Name: "tennis", Query: "tennis", CreatedAt: s.now}))
+
+	later := s.now.Add(time.Minute)
+	s.NoError(s.handler.UpdateIngestionQuery(s.ctx, "cricket", &storage.IngestionQuery{
+		Name:         "cricket",
+		Source:       "feed",
+		Query:        "cricket highlights",
+		Location:     "https://example.com/cricket.rss",
+		Tag:          "highlights",
+		PollInterval: time.Hour,
+		Paused:       true,
+		CreatedAt:    s.now,
+		UpdatedAt:    later,
+	}))
+
+	ingestionQuery, err := s.handler.ReadIngestionQuery(s.ctx, "cricket")
+	s.NoError(err)
+	s.Equal("feed", ingestionQuery.Source)
+	s.Equal("cricket highlights", ingestionQuery.Query)
+	s.Equal("https://example.com/cricket.rss", ingestionQuery.Location)
+	s.Equal("highlights", ingestionQuery.Tag)
+	s.Equal(time.Hour, ingestionQuery.PollInterval)
+	s.True(ingestionQuery.Paused)
+	s.True(later.Equal(ingestionQuery.UpdatedAt))
+
+	// Other queries are left alone
+	ingestionQuery, err = s.handler.ReadIngestionQuery(s.ctx, "tennis")
+	s.NoError(err)
+	s.Equal("tennis", ingestionQuery.Query)
+	s.False(ingestionQuery.Paused)
+}
+
+func (s *IngestionQuerySuite) TestUpdateIngestionQuery_MissingIsNoop() {
+	s.NoError(s.handler.UpdateIngestionQuery(s.ctx, "missing", &storage.IngestionQuery{Name: "missing", Query: "missing", CreatedAt: s.now}))
+
+	ingestionQuery, err := s.handler.ReadIngestionQuery(s.ctx, "missing")
+	s.NoError(err)
+	s.Nil(ingestionQuery)
+}
+
+func (s *IngestionQuerySuite) TestDeleteIngestionQuery() {
+	s.NoError(s.handler.CreateIngestionQuery(s.ctx, &storage.IngestionQuery{Name: "cricket", Query: "cricket", CreatedAt: s.now}))
+	s.NoError(s.handler.CreateIngestionQuery(s.ctx, &storage.IngestionQuery{Name: "tennis", Query: "tennis", CreatedAt: s.now}))
+
+	s.NoError(s.handler.DeleteIngestionQuery(s.ctx, "cricket"))
+	s.NoError(s.handler.DeleteIngestionQuery(s.ctx, "missing"))
+
+	ingestionQueries, err := s.handler.ListIngestionQueries(s.ctx)
+	s.NoError(err)
+	s.Equal([]string{"tennis"}, ingestionQueryNames(ingestionQueries))
+}
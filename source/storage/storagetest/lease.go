@@ -0,0 +1,66 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// LeaseSuite holds the contract of storage.LeaseInterface
+type LeaseSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	newBackend BackendFactory
+	ctx        context.Context
+	handler    storage.LeaseInterface
+}
+
+func (s *LeaseSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctx = context.Background()
+	s.handler = s.newBackend(s.T()).Lease
+}
+
+func (s *LeaseSuite) acquire(name string, holder string, duration time.Duration) bool {
+	acquired, err := s.handler.AcquireLease(s.ctx, name, holder, duration)
+	s.NoError(err)
+	return acquired
+}
+
+func (s *LeaseSuite) TestAcquireLease_OneHolderAtATime() {
+	s.True(s.acquire("query:cricket", "first", time.Minute))
+	s.False(s.acquire("query:cricket", "second", time.Minute))
+
+	// The holder renews its lease, other leases are independent
+	s.True(s.acquire("query:cricket", "first", time.Minute))
+	s.True(s.acquire("query:football", "second", time.Minute))
+	s.False(s.acquire("query:football", "first", time.Minute))
+}
+
+func (s *LeaseSuite) TestAcquireLease_Expired() {
+	s.True(s.acquire("query:cricket", "first", 50*time.Millisecond))
+	s.False(s.acquire("query:cricket", "second", time.Minute))
+
+	time.Sleep(100 * time.Millisecond)
+
+	// The holder that did not renew in time has lost the lease
+	s.True(s.acquire("query:cricket", "second", time.Minute))
+	s.False(s.acquire("query:cricket", "first", time.Minute))
+}
+
+func (s *LeaseSuite) TestReleaseLease() {
+	s.True(s.acquire("query:cricket", "first", time.Minute))
+
+	// Only the holder can release a lease
+	s.NoError(s.handler.ReleaseLease(s.ctx, "query:cricket", "second"))
+	s.False(s.acquire("query:cricket", "second", time.Minute))
+
+	s.NoError(s.handler.ReleaseLease(s.ctx, "query:cricket", "first"))
+	s.True(s.acquire("query:cricket", "second", time.Minute))
+
+	s.NoError(s.handler.ReleaseLease(s.ctx, "missing", "first"))
+}
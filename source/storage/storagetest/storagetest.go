@@ -0,0 +1,77 @@
+/*
+Package storagetest is a conformance suite for storage backends.
+
+Every storage driver must behave the same way from the point of view of the
+server and the worker. A driver proves that by running the suite against
+itself from its own tests -
+
+	storagetest.RunConformanceSuite(t, func(t *testing.T) *storagetest.Backend {
+		return &storagetest.Backend{
+			VideoMetadata:  newEmptyVideoMetadataHandler(t),
+			User:           newEmptyUserHandler(t),
+			Checkpoint:     newEmptyCheckpointHandler(t),
+			IngestionQuery: newEmptyIngestionQueryHandler(t),
+
+			VideoStatisticsSnapshot: newEmptyVideoStatisticsSnapshotHandler(t),
+			Channel:                 newEmptyChannelHandler(t),
+			Lease:                   newEmptyLeaseHandler(t),
+			Suggestion:              newEmptySuggestionHandler(t),
+		}
+	})
+
+The factory is called before every test and must return handlers on top of
+empty storage.
+*/
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/stretchr/testify/suite"
+)
+
+// Backend groups the handlers of one storage driver
+type Backend struct {
+	VideoMetadata storage.VideoMetadataInterface
+	User          storage.UserInterface
+	Checkpoint    storage.CheckpointInterface
+
+	IngestionQuery storage.IngestionQueryInterface
+
+	VideoStatisticsSnapshot storage.VideoStatisticsSnapshotInterface
+	Channel                 storage.ChannelInterface
+	Lease                   storage.LeaseInterface
+	Suggestion              storage.SuggestionInterface
+}
+
+// BackendFactory returns a Backend on top of empty storage
+type BackendFactory func(t *testing.T) *Backend
+
+// RunConformanceSuite runs every conformance test against the backends built by newBackend
+func RunConformanceSuite(t *testing.T, newBackend BackendFactory) {
+	t.Run("VideoMetadata", func(t *testing.T) {
+		suite.Run(t, &VideoMetadataSuite{newBackend: newBackend})
+	})
+	t.Run("User", func(t *testing.T) {
+		suite.Run(t, &UserSuite{newBackend: newBackend})
+	})
+	t.Run("Checkpoint", func(t *testing.T) {
+		suite.Run(t, &CheckpointSuite{newBackend: newBackend})
+	})
+	t.Run("IngestionQuery", func(t *testing.T) {
+		suite.Run(t, &IngestionQuerySuite{newBackend: newBackend})
+	})
+	t.Run("VideoStatisticsSnapshot", func(t *testing.T) {
+		suite.Run(t, &VideoStatisticsSnapshotSuite{newBackend: newBackend})
+	})
+	t.Run("Channel", func(t *testing.T) {
+		suite.Run(t, &ChannelSuite{newBackend: newBackend})
+	})
+	t.Run("Lease", func(t *testing.T) {
+		suite.Run(t, &LeaseSuite{newBackend: newBackend})
+	})
+	t.Run("Suggestion", func(t *testing.T) {
+		suite.Run(t, &SuggestionSuite{newBackend: newBackend})
+	})
+}
@@ -0,0 +1,131 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// SuggestionSuite holds the contract of storage.SuggestionInterface
+type SuggestionSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	newBackend BackendFactory
+	ctx        context.Context
+	backend    *Backend
+	handler    storage.SuggestionInterface
+
+	now time.Time
+}
+
+func (s *SuggestionSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctx = context.Background()
+	s.backend = s.newBackend(s.T())
+	s.handler = s.backend.Suggestion
+	s.now = time.Now().UTC().Truncate(time.Millisecond)
+
+	err := s.handler.IndexTitles(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "1", Title: "Cricket World Cup final", PublishedAt: s.now.Add(-4 * time.Minute)},
+		{VideoID: "2", Title: "Football transfer news", PublishedAt: s.now.Add(-3 * time.Minute)},
+		{VideoID: "3", Title: "Cricket highlights", PublishedAt: s.now.Add(-2 * time.Minute)},
+		{VideoID: "4", Title: "Tennis at the open", PublishedAt: s.now.Add(-1 * time.Minute)},
+	})
+	s.NoError(err)
+}
+
+func (s *SuggestionSuite) suggest(text string, limit int) []string {
+	suggestions, err := s.handler.SuggestTitles(s.ctx, text, limit)
+	s.NoError(err)
+
+	titles := []string{}
+	for _, suggestion := range suggestions {
+		titles = append(titles, suggestion.Title)
+	}
+	return titles
+}
+
+func (s *SuggestionSuite) TestSuggestTitles_Prefix() {
+	// The most recent first among equally close titles
+	s.Equal([]string{"Cricket highlights", "Cricket World Cup final"}, s.suggest("crick", 10))
+	s.Equal([]string{"Cricket highlights"}, s.suggest("crick", 1))
+	s.Equal([]string{"Cricket World Cup final"}, s.suggest("cricket world c", 10))
+	s.Equal([]string{"Tennis at the open"}, s.suggest("tennis at", 10))
+}
+
+func (s *SuggestionSuite) TestSuggestTitles_Typos() {
+	s.Equal([]string{"Football transfer news"}, s.suggest("footbal", 10))
+	s.Equal([]string{"Football transfer news"}, s.suggest("fotball tranfer", 10))
+
+	// A swap is one edit, every typed word must match
+	s.Equal([]string{"Cricket highlights", "Cricket World Cup final"}, s.suggest("crikcet", 10))
+	s.Equal([]string{"Cricket World Cup final"}, s.suggest("crikcet wrold", 10))
+
+	// Short words must be exact
+	s.Equal([]string{"Tennis at the open"}, s.suggest("tannis at", 10))
+	s.Empty(s.suggest("tan", 10))
+	s.Empty(s.suggest("xyz", 10))
+	s.Empty(s.suggest("c", 10))
+}
+
+func (s *SuggestionSuite) TestIndexTitles_Replaces() {
+	// An older title is ignored, a newer one replaces the indexed one
+	err := s.handler.IndexTitles(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "2", Title: "Football old title", PublishedAt: s.now.Add(-time.Hour)},
+	})
+	s.NoError(err)
+	s.Equal([]string{"Football transfer news"}, s.suggest("football", 10))
+
+	err = s.handler.IndexTitles(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "2", Title: "Basketball finals", PublishedAt: s.now},
+	})
+	s.NoError(err)
+	s.Empty(s.suggest("football", 10))
+	s.Equal([]string{"Basketball finals"}, s.suggest("basketb", 10))
+
+	s.NoError(s.handler.IndexTitles(s.ctx, nil))
+}
+
+func (s *SuggestionSuite) TestSuggestTitles_SameTitle() {
+	err := s.handler.IndexTitles(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "5", Title: "cricket highlights", PublishedAt: s.now},
+	})
+	s.NoError(err)
+
+	suggestions, err := s.handler.SuggestTitles(s.ctx, "cricket high", 10)
+	s.NoError(err)
+	s.Len(suggestions, 1)
+	s.Equal("5", suggestions[0].VideoID)
+	s.Equal(s.now, suggestions[0].PublishedAt)
+}
+
+func (s *SuggestionSuite) TestBackfillTitleSuggestions() {
+	err := s.backend.VideoMetadata.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "5", Title: "Golf masters recap", PublishedAt: s.now.Add(-time.Hour)},
+		{VideoID: "6", Title: "Golf swing lessons", PublishedAt: s.now.Add(-time.Hour)},
+		{VideoID: "7", Title: "Rugby sevens", PublishedAt: s.now.Add(-2 * time.Hour)},
+	})
+	s.NoError(err)
+
+	err = storage.BackfillTitleSuggestions(s.ctx, s.backend.VideoMetadata, s.handler, s.backend.Checkpoint)
+	s.NoError(err)
+	s.Equal([]string{"Golf masters recap", "Golf swing lessons"}, s.suggest("golf", 10))
+	s.Equal([]string{"Rugby sevens"}, s.suggest("rugb", 10))
+
+	// The titles already indexed are kept
+	s.Equal([]string{"Tennis at the open"}, s.suggest("tennis", 10))
+
+	// The backfill runs once, later videos are indexed by ingestion
+	err = s.backend.VideoMetadata.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "8", Title: "Rowing regatta", PublishedAt: s.now.Add(-time.Hour)},
+	})
+	s.NoError(err)
+
+	err = storage.BackfillTitleSuggestions(s.ctx, s.backend.VideoMetadata, s.handler, s.backend.Checkpoint)
+	s.NoError(err)
+	s.Empty(s.suggest("rowing", 10))
+}
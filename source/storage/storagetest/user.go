@@ -0,0 +1,75 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// UserSuite holds the contract of storage.UserInterface
+type UserSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	newBackend BackendFactory
+	ctx        context.Context
+	handler    storage.UserInterface
+
+	now time.Time
+}
+
+func (s *UserSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctx = context.Background()
+	s.handler = s.newBackend(s.T()).User
+	s.now = time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func (s *UserSuite) TestReadUser_Missing() {
+	user, err := s.handler.ReadUser(s.ctx, "missing")
+	s.NoError(err)
+	s.Nil(user)
+}
+
+func (s *UserSuite) TestCreateUser_RoundTrip() {
+	s.NoError(s.handler.CreateUser(s.ctx, &storage.User{UserID: "user", PageSize: 5, Timestamp: s.now}))
+
+	user, err := s.handler.ReadUser(s.ctx, "user")
+	s.NoError(err)
+	s.Equal("user", user.UserID)
+	s.Equal(5, user.PageSize)
+	s.True(s.now.Equal(user.Timestamp))
+}
+
+func (s *UserSuite) TestCreateUser_ExistingIsUntouched() {
+	s.NoError(s.handler.CreateUser(s.ctx, &storage.User{UserID: "user", PageSize: 5, Timestamp: s.now}))
+	s.NoError(s.handler.CreateUser(s.ctx, &storage.User{UserID: "user", PageSize: 10, Timestamp: s.now.Add(time.Hour)}))
+
+	user, err := s.handler.ReadUser(s.ctx, "user")
+	s.NoError(err)
+	s.Equal(5, user.PageSize)
+	s.True(s.now.Equal(user.Timestamp))
+}
+
+func (s *UserSuite) TestUpdateUser_Overwrites() {
+	s.NoError(s.handler.CreateUser(s.ctx, &storage.User{UserID: "user", PageSize: 5, Timestamp: s.now}))
+
+	later := s.now.Add(time.Hour)
+	s.NoError(s.handler.UpdateUser(s.ctx, "user", &storage.User{UserID: "user", PageSize: 10, Timestamp: later}))
+
+	user, err := s.handler.ReadUser(s.ctx, "user")
+	s.NoError(err)
+	s.Equal(10, user.PageSize)
+	s.True(later.Equal(user.Timestamp))
+}
+
+func (s *UserSuite) TestUpdateUser_MissingIsNoop() {
+	s.NoError(s.handler.UpdateUser(s.ctx, "missing", &storage.User{UserID: "missing", PageSize: 10, Timestamp: s.now}))
+
+	user, err := s.handler.ReadUser(s.ctx, "missing")
+	s.NoError(err)
+	s.Nil(user)
+}
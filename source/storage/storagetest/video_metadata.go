@@ -0,0 +1,547 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// VideoMetadataSuite holds the contract of storage.VideoMetadataInterface
+type VideoMetadataSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	newBackend BackendFactory
+	ctx        context.Context
+	handler    storage.VideoMetadataInterface
+
+	// Mongo keeps times at millisecond precision, so does the suite
+	now time.Time
+}
+
+func (s *VideoMetadataSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctx = context.Background()
+	s.handler = s.newBackend(s.T()).VideoMetadata
+	s.now = time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func (s *VideoMetadataSuite) insertVideos() {
+	err := s.handler.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "1", Title: "Cricket World Cup", Description: "Highlights of the final", PublishedAt: s.now.Add(-3 * time.Minute)},
+		{VideoID: "2", Title: "Football news", Description: "Transfer window updates", PublishedAt: s.now.Add(-2 * time.Minute)},
+		{VideoID: "3", Title: "Tennis", Description: "Matches played at the open", PublishedAt: s.now.Add(-1 * time.Minute)},
+	})
+	s.NoError(err)
+}
+
+func videoIDs(metadata []*storage.VideoMetadata) []string {
+	var ids []string
+	for _, videoMetadata := range metadata {
+		ids = append(ids, videoMetadata.VideoID)
+	}
+	return ids
+}
+
+func (s *VideoMetadataSuite) TestFindOneMetadataWithVideoID_Missing() {
+	metadata, err := s.handler.FindOneMetadataWithVideoID(s.ctx, "missing")
+	s.NoError(err)
+	s.Nil(metadata)
+}
+
+func (s *VideoMetadataSuite) TestBulkInsertMetadata_RoundTrip() {
+	expected := &storage.VideoMetadata{
+		VideoID:              "video",
+		Title:                "title",
+		Description:          "description",
+		DefaultThumbnailURL:  "default",
+		HighThumbnailURL:     "high",
+		MaxresThumbnailURL:   "maxres",
+		MediumThumbnailURL:   "medium",
+		StandardThumbnailURL: "standard",
+		PublishedAt:          s.now,
+		ChannelID:            "channel",
+		ChannelTitle:         "channel title",
+		QueryTags:            []string{"sports", "news"},
+		ViewCount:            1000,
+		LikeCount:            100,
+		CommentCount:         10,
+		Duration:             4*time.Minute + 13*time.Second,
+		Tags:                 []string{"cricket", "highlights"},
+		CategoryID:           "17",
+		DefaultLanguage:      "en",
+		EnrichedAt:           s.now.Add(time.Minute),
+	}
+
+	s.NoError(s.handler.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{expected}))
+
+	metadata, err := s.handler.FindOneMetadataWithVideoID(s.ctx, "video")
+	s.NoError(err)
+	s.NotNil(metadata)
+	s.True(expected.PublishedAt.Equal(metadata.PublishedAt))
+	s.True(expected.EnrichedAt.Equal(metadata.EnrichedAt))
+
+	metadata.PublishedAt = expected.PublishedAt
+	metadata.EnrichedAt = expected.EnrichedAt
+	s.Equal(expected, metadata)
+}
+
+func (s *VideoMetadataSuite) TestBulkInsertMetadata_Duplicate() {
+	s.insertVideos()
+
+	err := s.handler.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "4", Title: "Golf", PublishedAt: s.now},
+		{VideoID: "2", Title: "Basketball news", PublishedAt: s.now},
+		{VideoID: "5", Title: "Rugby", PublishedAt: s.now},
+	})
+	s.ErrorIs(err, storage.ErrVideoMetadataExists)
+
+	// The videos before the duplicate are stored, the stored one is left as it was
+	metadata, err := s.handler.FindOneMetadataWithVideoID(s.ctx, "4")
+	s.NoError(err)
+	s.Equal("Golf", metadata.Title)
+
+	metadata, err = s.handler.FindOneMetadataWithVideoID(s.ctx, "2")
+	s.NoError(err)
+	s.Equal("Football news", metadata.Title)
+
+	metadata, err = s.handler.FindOneMetadataWithVideoID(s.ctx, "5")
+	s.NoError(err)
+	s.Nil(metadata)
+}
+
+func (s *VideoMetadataSuite) TestUpdateOneMetadata_Overwrites() {
+	s.insertVideos()
+
+	err := s.handler.UpdateOneMetadata(s.ctx, "2", &storage.VideoMetadata{
+		VideoID:     "2",
+		Title:       "Basketball news",
+		Description: "Draft picks",
+		PublishedAt: s.now,
+		QueryTags:   []string{"sports"},
+	})
+	s.NoError(err)
+
+	metadata, err := s.handler.FindOneMetadataWithVideoID(s.ctx, "2")
+	s.NoError(err)
+	s.Equal("Basketball news", metadata.Title)
+	s.Equal("Draft picks", metadata.Description)
+	s.Equal([]string{"sports"}, metadata.QueryTags)
+	s.True(s.now.Equal(metadata.PublishedAt))
+
+	// The text search follows the update
+	matched, err := s.handler.FindMetadataTextSearch(s.ctx, "basketball")
+	s.NoError(err)
+	s.Equal([]string{"2"}, videoIDs(matched))
+
+	matched, err = s.handler.FindMetadataTextSearch(s.ctx, "transfer")
+	s.NoError(err)
+	s.Empty(matched)
+}
+
+func (s *VideoMetadataSuite) TestUpdateOneMetadata_MissingIsNoop() {
+	err := s.handler.UpdateOneMetadata(s.ctx, "missing", &storage.VideoMetadata{VideoID: "missing", PublishedAt: s.now})
+	s.NoError(err)
+
+	metadata, err := s.handler.FindOneMetadataWithVideoID(s.ctx, "missing")
+	s.NoError(err)
+	s.Nil(metadata)
+}
+
+func (s *VideoMetadataSuite) TestUpdateMetadataStatistics() {
+	s.insertVideos()
+
+	// Tags added by ingestion since the statistics were requested are kept
+	_, err := s.handler.BulkUpsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "2", Title: "Football news", PublishedAt: s.now.Add(-2 * time.Minute), QueryTags: []string{"sports"}},
+	})
+	s.NoError(err)
+
+	err = s.handler.UpdateMetadataStatistics(s.ctx, &storage.VideoStatisticsSnapshot{
+		VideoID:      "2",
+		ViewCount:    100,
+		LikeCount:    10,
+		CommentCount: 1,
+	})
+	s.NoError(err)
+
+	metadata, err := s.handler.FindOneMetadataWithVideoID(s.ctx, "2")
+	s.NoError(err)
+	s.Equal(int64(100), metadata.ViewCount)
+	s.Equal(int64(10), metadata.LikeCount)
+	s.Equal(int64(1), metadata.CommentCount)
+	s.Equal("Football news", metadata.Title)
+	s.Equal("Transfer window updates", metadata.Description)
+	s.Equal([]string{"sports"}, metadata.QueryTags)
+
+	// Other videos are untouched
+	metadata, err = s.handler.FindOneMetadataWithVideoID(s.ctx, "1")
+	s.NoError(err)
+	s.Zero(metadata.ViewCount)
+}
+
+func (s *VideoMetadataSuite) TestUpdateMetadataStatistics_MissingIsNoop() {
+	err := s.handler.UpdateMetadataStatistics(s.ctx, &storage.VideoStatisticsSnapshot{VideoID: "missing", ViewCount: 100})
+	s.NoError(err)
+
+	metadata, err := s.handler.FindOneMetadataWithVideoID(s.ctx, "missing")
+	s.NoError(err)
+	s.Nil(metadata)
+}
+
+func (s *VideoMetadataSuite) TestBulkUpsertMetadata_Outcomes() {
+	s.insertVideos()
+
+	results, err := s.handler.BulkUpsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "4", Title: "Golf", PublishedAt: s.now, QueryTags: []string{"sports"}},
+		{VideoID: "2", Title: "Football news", PublishedAt: s.now.Add(-2 * time.Minute), QueryTags: []string{"sports"}},
+	})
+	s.NoError(err)
+	s.Len(results, 2)
+	s.Equal(&storage.UpsertResult{VideoID: "4", Outcome: storage.UpsertInserted}, results[0])
+	s.Equal(&storage.UpsertResult{VideoID: "2", Outcome: storage.UpsertMatched}, results[1])
+
+	metadata, err := s.handler.FindOneMetadataWithVideoID(s.ctx, "4")
+	s.NoError(err)
+	s.Equal("Golf", metadata.Title)
+	s.Equal([]string{"sports"}, metadata.QueryTags)
+
+	// Upserting again stores nothing new
+	results, err = s.handler.BulkUpsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "4", Title: "Golf", PublishedAt: s.now, QueryTags: []string{"sports"}},
+	})
+	s.NoError(err)
+	s.Equal(storage.UpsertMatched, results[0].Outcome)
+
+	metadata, err = s.handler.FindOneMetadataWithVideoID(s.ctx, "4")
+	s.NoError(err)
+	s.Equal([]string{"sports"}, metadata.QueryTags)
+
+	all, err := s.handler.FetchPagedMetadata(s.ctx, s.now, 0, 0)
+	s.NoError(err)
+	s.Equal([]string{"4", "3", "2", "1"}, videoIDs(all))
+}
+
+func (s *VideoMetadataSuite) TestBulkUpsertMetadata_ReplacesOlder() {
+	err := s.handler.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "1", Title: "Cricket World Cup", PublishedAt: s.now.Add(-time.Minute), QueryTags: []string{"sports"}, ViewCount: 10},
+	})
+	s.NoError(err)
+
+	// The same publish time only adds the tags
+	_, err = s.handler.BulkUpsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "1", Title: "Cricket", PublishedAt: s.now.Add(-time.Minute), QueryTags: []string{"cricket"}, ViewCount: 20},
+	})
+	s.NoError(err)
+
+	metadata, err := s.handler.FindOneMetadataWithVideoID(s.ctx, "1")
+	s.NoError(err)
+	s.Equal("Cricket World Cup", metadata.Title)
+	s.Equal(int64(10), metadata.ViewCount)
+	s.Equal([]string{"sports", "cricket"}, metadata.QueryTags)
+
+	// A later publish time replaces the video but keeps its tags
+	results, err := s.handler.BulkUpsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "1", Title: "Cricket World Cup final", Description: "Reuploaded", PublishedAt: s.now, QueryTags: []string{"news"}, ViewCount: 30},
+	})
+	s.NoError(err)
+	s.Equal(storage.UpsertMatched, results[0].Outcome)
+
+	metadata, err = s.handler.FindOneMetadataWithVideoID(s.ctx, "1")
+	s.NoError(err)
+	s.Equal("Cricket World Cup final", metadata.Title)
+	s.Equal(int64(30), metadata.ViewCount)
+	s.True(s.now.Equal(metadata.PublishedAt))
+	s.Equal([]string{"sports", "cricket", "news"}, metadata.QueryTags)
+
+	// The text search follows the replacement
+	matched, err := s.handler.FindMetadataTextSearch(s.ctx, "reuploaded")
+	s.NoError(err)
+	s.Equal([]string{"1"}, videoIDs(matched))
+}
+
+func (s *VideoMetadataSuite) TestBulkUpsertMetadata_Empty() {
+	results, err := s.handler.BulkUpsertMetadata(s.ctx, nil)
+	s.NoError(err)
+	s.Empty(results)
+}
+
+func (s *VideoMetadataSuite) TestFetchPagedMetadata_Paging() {
+	s.insertVideos()
+
+	metadata, err := s.handler.FetchPagedMetadata(s.ctx, s.now, 0, 2)
+	s.NoError(err)
+	s.Equal([]string{"3", "2"}, videoIDs(metadata))
+
+	metadata, err = s.handler.FetchPagedMetadata(s.ctx, s.now, 2, 2)
+	s.NoError(err)
+	s.Equal([]string{"1"}, videoIDs(metadata))
+
+	metadata, err = s.handler.FetchPagedMetadata(s.ctx, s.now, 4, 2)
+	s.NoError(err)
+	s.Empty(metadata)
+}
+
+func (s *VideoMetadataSuite) TestFetchPagedMetadata_TimestampCutoff() {
+	s.insertVideos()
+
+	// Videos published after the timestamp are not part of the feed
+	metadata, err := s.handler.FetchPagedMetadata(s.ctx, s.now.Add(-150*time.Second), 0, 5)
+	s.NoError(err)
+	s.Equal([]string{"1"}, videoIDs(metadata))
+
+	// The cutoff is inclusive
+	metadata, err = s.handler.FetchPagedMetadata(s.ctx, s.now.Add(-2*time.Minute), 0, 5)
+	s.NoError(err)
+	s.Equal([]string{"2", "1"}, videoIDs(metadata))
+
+	metadata, err = s.handler.FetchPagedMetadata(s.ctx, s.now.Add(-time.Hour), 0, 5)
+	s.NoError(err)
+	s.Empty(metadata)
+}
+
+func (s *VideoMetadataSuite) TestFetchMetadataPageByKey() {
+	// b, c and d share a publish time, ties are ordered on the video id
+	err := s.handler.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "a", PublishedAt: s.now.Add(-1 * time.Minute)},
+		{VideoID: "d", PublishedAt: s.now.Add(-2 * time.Minute)},
+		{VideoID: "b", PublishedAt: s.now.Add(-2 * time.Minute)},
+		{VideoID: "c", PublishedAt: s.now.Add(-2 * time.Minute)},
+		{VideoID: "e", PublishedAt: s.now.Add(-3 * time.Minute)},
+		{VideoID: "new", PublishedAt: s.now.Add(time.Minute)},
+	})
+	s.NoError(err)
+
+	page, err := s.handler.FetchMetadataPageByKey(s.ctx, s.now, nil, false, 2)
+	s.NoError(err)
+	s.Equal([]string{"a", "b"}, videoIDs(page))
+
+	page, err = s.handler.FetchMetadataPageByKey(s.ctx, s.now, storage.NewFeedKey(page[1]), false, 2)
+	s.NoError(err)
+	s.Equal([]string{"c", "d"}, videoIDs(page))
+
+	last, err := s.handler.FetchMetadataPageByKey(s.ctx, s.now, storage.NewFeedKey(page[1]), false, 2)
+	s.NoError(err)
+	s.Equal([]string{"e"}, videoIDs(last))
+
+	// Back from the first video of a page
+	page, err = s.handler.FetchMetadataPageByKey(s.ctx, s.now, storage.NewFeedKey(page[0]), true, 2)
+	s.NoError(err)
+	s.Equal([]string{"a", "b"}, videoIDs(page))
+
+	page, err = s.handler.FetchMetadataPageByKey(s.ctx, s.now, storage.NewFeedKey(last[0]), true, 2)
+	s.NoError(err)
+	s.Equal([]string{"c", "d"}, videoIDs(page))
+
+	page, err = s.handler.FetchMetadataPageByKey(s.ctx, s.now, storage.NewFeedKey(page[0]), true, 5)
+	s.NoError(err)
+	s.Equal([]string{"a", "b"}, videoIDs(page))
+
+	// Without a key before is the bottom of the feed
+	page, err = s.handler.FetchMetadataPageByKey(s.ctx, s.now, nil, true, 2)
+	s.NoError(err)
+	s.Equal([]string{"d", "e"}, videoIDs(page))
+
+	page, err = s.handler.FetchMetadataPageByKey(s.ctx, s.now, &storage.FeedKey{PublishedAt: s.now.Add(-3 * time.Minute), VideoID: "e"}, false, 2)
+	s.NoError(err)
+	s.Empty(page)
+}
+
+func (s *VideoMetadataSuite) TestFetchPagedMetadataWithChannelID() {
+	err := s.handler.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "1", ChannelID: "sports", PublishedAt: s.now.Add(-3 * time.Minute)},
+		{VideoID: "2", ChannelID: "news", PublishedAt: s.now.Add(-2 * time.Minute)},
+		{VideoID: "3", ChannelID: "sports", PublishedAt: s.now.Add(-1 * time.Minute)},
+		{VideoID: "4", ChannelID: "sports", PublishedAt: s.now.Add(time.Minute)},
+	})
+	s.NoError(err)
+
+	metadata, err := s.handler.FetchPagedMetadataWithChannelID(s.ctx, "sports", s.now, 0, 1)
+	s.NoError(err)
+	s.Equal([]string{"3"}, videoIDs(metadata))
+
+	metadata, err = s.handler.FetchPagedMetadataWithChannelID(s.ctx, "sports", s.now, 1, 1)
+	s.NoError(err)
+	s.Equal([]string{"1"}, videoIDs(metadata))
+
+	metadata, err = s.handler.FetchPagedMetadataWithChannelID(s.ctx, "missing", s.now, 0, 5)
+	s.NoError(err)
+	s.Empty(metadata)
+}
+
+func (s *VideoMetadataSuite) TestFindMetadataPublishedAfter() {
+	s.insertVideos()
+
+	// The cutoff is inclusive
+	metadata, err := s.handler.FindMetadataPublishedAfter(s.ctx, s.now.Add(-2*time.Minute))
+	s.NoError(err)
+	s.Equal([]string{"3", "2"}, videoIDs(metadata))
+
+	metadata, err = s.handler.FindMetadataPublishedAfter(s.ctx, s.now)
+	s.NoError(err)
+	s.Empty(metadata)
+}
+
+func (s *VideoMetadataSuite) TestFindMetadataTextSearch() {
+	s.insertVideos()
+
+	testCases := []struct {
+		searchText string
+		expected   []string
+	}{
+		{"cricket", []string{"1"}},
+		{"CRICKET football", []string{"1", "2"}},
+		{"match", []string{"3"}},
+		{"the", nil},
+		{"news -transfer", nil},
+		{"\"world cup\"", []string{"1"}},
+		{"\"cup world\"", nil},
+		{"basketball", nil},
+	}
+
+	for _, testCase := range testCases {
+		metadata, err := s.handler.FindMetadataTextSearch(s.ctx, testCase.searchText)
+		s.NoError(err)
+
+		// Text search makes no promise on the order of results
+		s.ElementsMatch(testCase.expected, videoIDs(metadata), testCase.searchText)
+	}
+}
+
+func searchHitIDs(hits []*storage.SearchHit) []string {
+	var ids []string
+	for _, hit := range hits {
+		ids = append(ids, hit.Metadata.VideoID)
+	}
+	return ids
+}
+
+func (s *VideoMetadataSuite) insertSearchVideos() {
+	err := s.handler.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "a", Title: "Cricket highlights", ChannelID: "sports", QueryTags: []string{"cricket"}, PublishedAt: s.now.Add(-4 * time.Minute)},
+		{VideoID: "b", Title: "Cricket", Description: "Post match interview", ChannelID: "news", QueryTags: []string{"cricket", "news"}, PublishedAt: s.now.Add(-3 * time.Minute)},
+		{VideoID: "c", Title: "Football", Description: "Highlights of the derby", ChannelID: "sports", QueryTags: []string{"football"}, PublishedAt: s.now.Add(-2 * time.Minute)},
+		{VideoID: "d", Title: "Tennis", ChannelID: "sports", QueryTags: []string{"tennis"}, PublishedAt: s.now.Add(-2 * time.Minute)},
+	})
+	s.NoError(err)
+}
+
+func (s *VideoMetadataSuite) TestSearchMetadata_Filters() {
+	s.insertSearchVideos()
+
+	testCases := []struct {
+		name     string
+		query    storage.SearchQuery
+		expected []string
+	}{
+		{"everything", storage.SearchQuery{}, []string{"c", "d", "b", "a"}},
+		{"text", storage.SearchQuery{Text: "highlights"}, []string{"c", "a"}},
+		{"published after", storage.SearchQuery{PublishedAfter: s.now.Add(-3 * time.Minute)}, []string{"c", "d", "b"}},
+		{"published before", storage.SearchQuery{PublishedBefore: s.now.Add(-3 * time.Minute)}, []string{"b", "a"}},
+		{"channels", storage.SearchQuery{ChannelIDs: []string{"news", "missing"}}, []string{"b"}},
+		{"query tags", storage.SearchQuery{QueryTags: []string{"news", "tennis"}}, []string{"d", "b"}},
+		{"combined", storage.SearchQuery{Text: "cricket", ChannelIDs: []string{"sports"}, QueryTags: []string{"cricket"}}, []string{"a"}},
+		{"no match", storage.SearchQuery{Text: "basketball"}, nil},
+	}
+
+	for _, testCase := range testCases {
+		testCase.query.Sort = storage.SortByDate
+		result, err := s.handler.SearchMetadata(s.ctx, &testCase.query)
+		s.NoError(err)
+		s.Equal(testCase.expected, searchHitIDs(result.Hits), testCase.name)
+		s.Equal(int64(len(testCase.expected)), result.Total, testCase.name)
+	}
+}
+
+func (s *VideoMetadataSuite) TestSearchMetadata_Relevance() {
+	s.insertSearchVideos()
+
+	// a matches both terms, each backend scores it above the videos matching one
+	result, err := s.handler.SearchMetadata(s.ctx, &storage.SearchQuery{Text: "cricket highlights", Sort: storage.SortByRelevance})
+	s.NoError(err)
+	s.Len(result.Hits, 3)
+	s.Equal("a", result.Hits[0].Metadata.VideoID)
+	s.Greater(result.Hits[0].Score, result.Hits[1].Score)
+	s.GreaterOrEqual(result.Hits[1].Score, result.Hits[2].Score)
+	s.Greater(result.Hits[2].Score, 0.0)
+
+	// Without a text every result scores the same
+	result, err = s.handler.SearchMetadata(s.ctx, &storage.SearchQuery{Sort: storage.SortByDate, Limit: 1})
+	s.NoError(err)
+	s.Equal(0.0, result.Hits[0].Score)
+}
+
+func (s *VideoMetadataSuite) TestSearchMetadata_Paging() {
+	s.insertSearchVideos()
+
+	for _, searchSort := range []storage.SearchSort{storage.SortByDate, storage.SortByRelevance} {
+		query := &storage.SearchQuery{Text: "cricket highlights tennis", Sort: searchSort}
+
+		all, err := s.handler.SearchMetadata(s.ctx, query)
+		s.NoError(err)
+		s.Len(all.Hits, 4)
+
+		// Reading the results two at a time gives the same results in the same order
+		var paged []string
+		query.Limit = 2
+		for {
+			page, err := s.handler.SearchMetadata(s.ctx, query)
+			s.NoError(err)
+			s.Equal(int64(4), page.Total)
+			if len(page.Hits) == 0 {
+				break
+			}
+
+			paged = append(paged, searchHitIDs(page.Hits)...)
+			query.After = storage.NewSearchKey(page.Hits[len(page.Hits)-1])
+		}
+		s.Equal(searchHitIDs(all.Hits), paged, searchSort)
+	}
+}
+
+func (s *VideoMetadataSuite) TestSearchMetadata_Fields() {
+	err := s.handler.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "1", Title: "Final replay", Description: "Full match", PublishedAt: s.now.Add(-5 * time.Minute)},
+		{VideoID: "2", Title: "Press conference", Description: "Before the final", PublishedAt: s.now.Add(-4 * time.Minute)},
+		{VideoID: "3", Title: "Training", Description: "Nets session", PublishedAt: s.now.Add(-3 * time.Minute)},
+		{VideoID: "4", Title: "Interview", Description: "Captain speaks", PublishedAt: s.now.Add(-2 * time.Minute)},
+		{VideoID: "5", Title: "Preview", Description: "Team news", PublishedAt: s.now.Add(-1 * time.Minute)},
+	})
+	s.NoError(err)
+
+	testCases := []struct {
+		name     string
+		query    storage.SearchQuery
+		expected []string
+	}{
+		{"title", storage.SearchQuery{Title: "final"}, []string{"1"}},
+		{"description", storage.SearchQuery{Description: "final"}, []string{"2"}},
+		{"stemmed", storage.SearchQuery{Description: "matches"}, []string{"1"}},
+		{"title misses description", storage.SearchQuery{Title: "matches"}, nil},
+		{"both fields", storage.SearchQuery{Title: "final", Description: "match"}, []string{"1"}},
+		{"both fields miss", storage.SearchQuery{Title: "final", Description: "nets"}, nil},
+		{"text and title", storage.SearchQuery{Text: "nets", Title: "training"}, []string{"3"}},
+		{"text misses", storage.SearchQuery{Text: "final", Title: "training"}, nil},
+		{"negated text", storage.SearchQuery{Text: "final -replay"}, []string{"2"}},
+		{"negated title term", storage.SearchQuery{Title: "press -final"}, []string{"2"}},
+		{"negated title term matches", storage.SearchQuery{Title: "press -conference"}, nil},
+		{"negated description term", storage.SearchQuery{Description: "match -replay"}, []string{"1"}},
+		{"negated description term matches", storage.SearchQuery{Description: "match -full"}, nil},
+	}
+
+	for _, testCase := range testCases {
+		testCase.query.Sort = storage.SortByDate
+		result, err := s.handler.SearchMetadata(s.ctx, &testCase.query)
+		s.NoError(err)
+		s.Equal(testCase.expected, searchHitIDs(result.Hits), testCase.name)
+	}
+
+	// A title hit ranks above a description hit
+	result, err := s.handler.SearchMetadata(s.ctx, &storage.SearchQuery{Text: "final", Sort: storage.SortByRelevance})
+	s.NoError(err)
+	s.Equal([]string{"1", "2"}, searchHitIDs(result.Hits))
+	s.Greater(result.Hits[0].Score, result.Hits[1].Score)
+}
@@ -0,0 +1,78 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// VideoStatisticsSnapshotSuite holds the contract of storage.VideoStatisticsSnapshotInterface
+type VideoStatisticsSnapshotSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	newBackend BackendFactory
+	ctx        context.Context
+	handler    storage.VideoStatisticsSnapshotInterface
+
+	now time.Time
+}
+
+func (s *VideoStatisticsSnapshotSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctx = context.Background()
+	s.handler = s.newBackend(s.T()).VideoStatisticsSnapshot
+	s.now = time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func (s *VideoStatisticsSnapshotSuite) TestFindSnapshotsWithVideoID_Missing() {
+	snapshots, err := s.handler.FindSnapshotsWithVideoID(s.ctx, "missing")
+	s.NoError(err)
+	s.Empty(snapshots)
+}
+
+func (s *VideoStatisticsSnapshotSuite) TestBulkInsertSnapshots_OldestFirst() {
+	s.NoError(s.handler.BulkInsertSnapshots(s.ctx, []*storage.VideoStatisticsSnapshot{
+		{VideoID: "video", Timestamp: s.now, ViewCount: 300, LikeCount: 30, CommentCount: 3},
+		{VideoID: "video", Timestamp: s.now.Add(-time.Hour), ViewCount: 100, LikeCount: 10, CommentCount: 1},
+		{VideoID: "other", Timestamp: s.now, ViewCount: 5},
+	}))
+
+	snapshots, err := s.handler.FindSnapshotsWithVideoID(s.ctx, "video")
+	s.NoError(err)
+	s.Len(snapshots, 2)
+
+	s.Equal("video", snapshots[0].VideoID)
+	s.True(s.now.Add(-time.Hour).Equal(snapshots[0].Timestamp))
+	s.Equal(int64(100), snapshots[0].ViewCount)
+	s.Equal(int64(10), snapshots[0].LikeCount)
+	s.Equal(int64(1), snapshots[0].CommentCount)
+
+	s.True(s.now.Equal(snapshots[1].Timestamp))
+	s.Equal(int64(300), snapshots[1].ViewCount)
+}
+
+func (s *VideoStatisticsSnapshotSuite) TestBulkInsertSnapshots_SkipsDuplicates() {
+	s.NoError(s.handler.BulkInsertSnapshots(s.ctx, []*storage.VideoStatisticsSnapshot{
+		{VideoID: "video", Timestamp: s.now, ViewCount: 100},
+	}))
+
+	// The stored snapshot is kept, the rest of the batch is still inserted
+	s.NoError(s.handler.BulkInsertSnapshots(s.ctx, []*storage.VideoStatisticsSnapshot{
+		{VideoID: "video", Timestamp: s.now, ViewCount: 200},
+		{VideoID: "video", Timestamp: s.now.Add(time.Hour), ViewCount: 300},
+	}))
+
+	snapshots, err := s.handler.FindSnapshotsWithVideoID(s.ctx, "video")
+	s.NoError(err)
+	s.Len(snapshots, 2)
+	s.Equal(int64(100), snapshots[0].ViewCount)
+	s.Equal(int64(300), snapshots[1].ViewCount)
+}
+
+func (s *VideoStatisticsSnapshotSuite) TestBulkInsertSnapshots_Empty() {
+	s.NoError(s.handler.BulkInsertSnapshots(s.ctx, nil))
+}
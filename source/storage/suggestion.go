@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The titles are indexed by the ingestion worker as it stores videos, see title_index.go
+//
+//go:generate mockgen --destination=./mock_storage/suggestion.go github.com/ashmeet13/YoutubeDataService/source/storage SuggestionInterface
+type SuggestionInterface interface {
+	// Indexes the titles of the videos. A video already indexed is replaced when the
+	// new one was published later, like BulkUpsertMetadata does.
+	IndexTitles(ctx context.Context, videoMetadatas []*VideoMetadata) error
+	// Returns up to limit titles completing text, the closest first
+	SuggestTitles(ctx context.Context, text string, limit int) ([]*TitleSuggestion, error)
+}
+
+func NewSuggestionImpl() *SuggestionImpl {
+	return &SuggestionImpl{
+		collection: TitleSuggestionC,
+	}
+}
+
+type SuggestionImpl struct {
+	collection string
+}
+
+func (s *SuggestionImpl) IndexTitles(ctx context.Context, videoMetadatas []*VideoMetadata) error {
+	if len(videoMetadatas) == 0 {
+		return nil
+	}
+
+	models := []mongo.WriteModel{}
+	for _, metadata := range videoMetadatas {
+		doc, err := convertToBsonM(newTitleSuggestion(metadata))
+		if err != nil {
+			return err
+		}
+
+		models = append(models,
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{
+					"video_id":     metadata.VideoID,
+					"published_at": bson.M{"$lt": metadata.PublishedAt},
+				}).
+				SetUpdate(bson.M{"$set": doc}),
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"video_id": metadata.VideoID}).
+				SetUpdate(bson.M{"$setOnInsert": doc}).
+				SetUpsert(true),
+		)
+	}
+
+	_, err := BulkWrite(ctx, s.collection, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// The candidates are the titles sharing the most grams with text, on the multikey grams index
+func (s *SuggestionImpl) SuggestTitles(ctx context.Context, text string, limit int) ([]*TitleSuggestion, error) {
+	grams := titleGrams(text)
+	if len(grams) == 0 {
+		return []*TitleSuggestion{}, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"grams": bson.M{"$in": grams}}}},
+		{{Key: "$addFields", Value: bson.M{"_shared": bson.M{"$size": bson.M{"$setIntersection": bson.A{"$grams", grams}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_shared", Value: -1}, {Key: "video_id", Value: 1}}}},
+		{{Key: "$limit", Value: maxSuggestionCandidates}},
+		{{Key: "$project", Value: bson.M{"grams": 0, "_shared": 0}}},
+	}
+
+	cur, err := Aggregate(ctx, s.collection, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cur.Close(ctx)
+
+	var candidates []*TitleSuggestion
+	err = cur.All(ctx, &candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	return rankSuggestions(text, candidates, limit), nil
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+textSearch approximates the behaviour of a MongoDB $text query for backends
+that do not have a text index of their own.
+
+1. The search string is split into terms on anything that is not a letter or a digit
+2. Terms are lower cased and stop words are dropped
+3. Words are compared after a light suffix stemming
+4. A document matches if any term matches a word in the title or the description
+5. "quoted phrases" must all be present in the document
+6. -negated terms exclude the document
+7. A matching document is scored like the textScore of MongoDB, see score. A
+   term found in the title weighs titleWeight times a term found in the description
+
+The sqlite driver reuses the parsed search to build its FTS5 query.
+*/
+type textSearch struct {
+	terms        []string
+	phrases      []string
+	negatedTerms []string
+}
+
+// Weights of the text fields in the score of a search, the same in every backend
+const (
+	titleWeight       = 10
+	descriptionWeight = 1
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "with": true,
+}
+
+func newTextSearch(searchText string) *textSearch {
+	search := &textSearch{}
+
+	// Pull out the quoted phrases first, whatever remains is plain terms
+	parts := strings.Split(searchText, "\"")
+	var remaining []string
+	for i, part := range parts {
+		if i%2 == 1 {
+			words := splitWords(part)
+			if len(words) > 0 {
+				search.phrases = append(search.phrases, strings.Join(words, " "))
+				search.terms = append(search.terms, words...)
+			}
+			continue
+		}
+		remaining = append(remaining, part)
+	}
+
+	for _, word := range strings.Fields(strings.Join(remaining, " ")) {
+		if strings.HasPrefix(word, "-") {
+			search.negatedTerms = append(search.negatedTerms, splitWords(word)...)
+			continue
+		}
+		search.terms = append(search.terms, splitWords(word)...)
+	}
+
+	return search
+}
+
+// matches reports if the text of a document satisfies the search
+func (s *textSearch) matches(texts ...string) bool {
+	if len(s.terms) == 0 {
+		return false
+	}
+
+	words := map[string]bool{}
+	var joined []string
+	for _, text := range texts {
+		tokens := tokenize(text)
+		for _, token := range tokens {
+			words[token] = true
+		}
+		joined = append(joined, strings.Join(tokens, " "))
+	}
+
+	for _, term := range s.negatedTerms {
+		if words[stem(term)] {
+			return false
+		}
+	}
+
+	// Pad with spaces so phrases only match on word boundaries
+	document := " " + strings.Join(joined, " ") + " "
+	for _, phrase := range s.phrases {
+		if !strings.Contains(document, " "+strings.Join(tokenize(phrase), " ")+" ") {
+			return false
+		}
+	}
+
+	for _, term := range s.terms {
+		if words[stem(term)] {
+			return true
+		}
+	}
+	return false
+}
+
+// score is the relevance of a matching document. Every term found in a text adds
+// between 0.5 and 1, more as it makes up more of the text, so a term repeated in
+// a short title weighs more than a word lost in a long description.
+func (s *textSearch) score(texts ...string) float64 {
+	terms := map[string]bool{}
+	for _, term := range s.terms {
+		terms[stem(term)] = true
+	}
+
+	score := 0.0
+	for _, text := range texts {
+		tokens := tokenize(text)
+
+		counts := map[string]int{}
+		for _, token := range tokens {
+			if terms[token] {
+				counts[token]++
+			}
+		}
+
+		for _, count := range counts {
+			score += 0.5 + 0.5*float64(count)/float64(len(tokens))
+		}
+	}
+	return score
+}
+
+// fieldScore is the score of a matching video, its title weighs more than its description
+func (s *textSearch) fieldScore(metadata *VideoMetadata) float64 {
+	return titleWeight*s.score(metadata.Title) + descriptionWeight*s.score(metadata.Description)
+}
+
+// splitWords splits text into lower cased words without stop words
+func splitWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := []string{}
+	for _, field := range fields {
+		if stopWords[field] {
+			continue
+		}
+		words = append(words, field)
+	}
+	return words
+}
+
+// tokenize splits text into stemmed words without stop words
+func tokenize(text string) []string {
+	tokens := []string{}
+	for _, word := range splitWords(text) {
+		tokens = append(tokens, stem(word))
+	}
+	return tokens
+}
+
+// stem strips a few common English suffixes so "matches" finds "match"
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "es", "ed", "s"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 3 {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
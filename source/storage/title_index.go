@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+)
+
+/*
+The title index finds titles completing a search as it is typed, like "crick" or
+a misspelled "footbal". Every backend stores the trigrams of the words of each
+title, and looks up the titles sharing the most trigrams with the typed text.
+Those candidates are then ranked here, the same for every backend.
+
+1. Words are split on anything that is not a letter or a digit and lower cased
+2. The trigrams of a word are taken with a leading space, so " cr", "cri", "ric"...
+   for "cricket". The leading gram makes the first letters of a word count most,
+   and a single letter has no gram, so a suggestion needs 2 letters typed.
+3. A candidate matches if every typed word is within maxEdits of one of its words.
+   The last typed word is still being typed, it only needs to be within maxEdits
+   of the start of a word.
+4. Matches are ranked on the total number of edits, then the most recent first.
+
+Ingestion indexes the titles of the videos it stores. The videos stored before the
+index existed are indexed by BackfillTitleSuggestions on startup.
+*/
+
+// The most candidates read from the index for one suggestion
+const maxSuggestionCandidates = 500
+
+// The videos read and indexed at once by BackfillTitleSuggestions
+const titleBackfillBatchSize = 500
+
+// ID of the checkpoint completed once the titles of the stored videos are indexed
+const titleBackfillCheckpointID = "index:title_suggestions"
+
+// BackfillTitleSuggestions indexes the titles of every stored video, once per
+// database, for the videos stored before the index existed. Ingestion keeps the
+// index up to date from then on. A backfill that fails is started over on the
+// next call, indexing a title twice changes nothing.
+func BackfillTitleSuggestions(ctx context.Context, videoMetadataHandler VideoMetadataInterface, suggestionHandler SuggestionInterface, checkpointHandler CheckpointInterface) error {
+	checkpoint, err := checkpointHandler.ReadCheckpoint(ctx, titleBackfillCheckpointID)
+	if err != nil {
+		return err
+	}
+	if checkpoint != nil && checkpoint.Completed {
+		return nil
+	}
+
+	// Pages through the feed as of now, a video ingested meanwhile is indexed by ingestion
+	timestamp := time.Now().UTC()
+	var key *FeedKey
+	indexed := 0
+	for {
+		page, err := videoMetadataHandler.FetchMetadataPageByKey(ctx, timestamp, key, false, titleBackfillBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		err = suggestionHandler.IndexTitles(ctx, page)
+		if err != nil {
+			return err
+		}
+		indexed += len(page)
+
+		last := page[len(page)-1]
+		key = &FeedKey{PublishedAt: last.PublishedAt, VideoID: last.VideoID}
+	}
+
+	common.GetLogger().WithField("Videos", indexed).Info("Indexed the titles of stored videos")
+
+	return checkpointHandler.SaveCheckpoint(ctx, &Checkpoint{
+		QueryID:              titleBackfillCheckpointID,
+		CurrentPublishedTime: timestamp,
+		Completed:            true,
+		UpdatedAt:            time.Now().UTC(),
+	})
+}
+
+// suggestionWords splits text into lower cased words, stop words are kept since
+// they are part of the titles being completed
+func suggestionWords(text string) [][]rune {
+	var words [][]rune
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words = append(words, []rune(word))
+	}
+	return words
+}
+
+// titleGrams returns the distinct trigrams of the words of text
+func titleGrams(text string) []string {
+	seen := map[string]bool{}
+	grams := []string{}
+	for _, word := range suggestionWords(text) {
+		padded := append([]rune{' '}, word...)
+		if len(padded) < 3 {
+			continue
+		}
+
+		for i := 0; i+3 <= len(padded); i++ {
+			gram := string(padded[i : i+3])
+			if !seen[gram] {
+				seen[gram] = true
+				grams = append(grams, gram)
+			}
+		}
+	}
+	return grams
+}
+
+// How many edits a typed word tolerates, none for short words which would match anything
+func maxEdits(word []rune) int {
+	switch {
+	case len(word) <= 3:
+		return 0
+	case len(word) <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the edits turning a into b, or into the closest start of b
+// with prefix set. An edit inserts, deletes or replaces a letter, or swaps two.
+func editDistance(a, b []rune, prefix bool) int {
+	// distances[i][j] is the distance between a[:i] and b[:j]
+	distances := make([][]int, len(a)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(b)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			distance := distances[i-1][j-1] + cost
+			if deletion := distances[i-1][j] + 1; deletion < distance {
+				distance = deletion
+			}
+			if insertion := distances[i][j-1] + 1; insertion < distance {
+				distance = insertion
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && distances[i-2][j-2]+1 < distance {
+				distance = distances[i-2][j-2] + 1
+			}
+			distances[i][j] = distance
+		}
+	}
+
+	if !prefix {
+		return distances[len(a)][len(b)]
+	}
+
+	closest := distances[len(a)][0]
+	for _, distance := range distances[len(a)] {
+		if distance < closest {
+			closest = distance
+		}
+	}
+	return closest
+}
+
+// Edits needed for the title to complete the typed words, false when it does not
+func suggestionDistance(typed [][]rune, title string) (int, bool) {
+	words := suggestionWords(title)
+
+	total := 0
+	for i, typedWord := range typed {
+		last := i == len(typed)-1
+
+		closest := -1
+		for _, word := range words {
+			distance := editDistance(typedWord, word, last)
+			if closest == -1 || distance < closest {
+				closest = distance
+			}
+		}
+
+		if closest == -1 || closest > maxEdits(typedWord) {
+			return 0, false
+		}
+		total += closest
+	}
+	return total, true
+}
+
+// rankSuggestions returns up to limit of the candidates completing text, the closest
+// first. Videos sharing a title are suggested once.
+func rankSuggestions(text string, candidates []*TitleSuggestion, limit int) []*TitleSuggestion {
+	typed := suggestionWords(text)
+	if len(typed) == 0 {
+		return []*TitleSuggestion{}
+	}
+
+	type rankedSuggestion struct {
+		suggestion *TitleSuggestion
+		distance   int
+	}
+
+	var ranked []rankedSuggestion
+	for _, candidate := range candidates {
+		distance, ok := suggestionDistance(typed, candidate.Title)
+		if ok {
+			ranked = append(ranked, rankedSuggestion{suggestion: candidate, distance: distance})
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].distance != ranked[j].distance {
+			return ranked[i].distance < ranked[j].distance
+		}
+		if !ranked[i].suggestion.PublishedAt.Equal(ranked[j].suggestion.PublishedAt) {
+			return ranked[i].suggestion.PublishedAt.After(ranked[j].suggestion.PublishedAt)
+		}
+		return ranked[i].suggestion.VideoID < ranked[j].suggestion.VideoID
+	})
+
+	suggestions := []*TitleSuggestion{}
+	seen := map[string]bool{}
+	for _, candidate := range ranked {
+		if len(suggestions) == limit {
+			break
+		}
+
+		title := strings.ToLower(strings.TrimSpace(candidate.suggestion.Title))
+		if seen[title] {
+			continue
+		}
+		seen[title] = true
+
+		suggestions = append(suggestions, candidate.suggestion)
+	}
+	return suggestions
+}
+
+func newTitleSuggestion(metadata *VideoMetadata) *TitleSuggestion {
+	return &TitleSuggestion{
+		VideoID:     metadata.VideoID,
+		Title:       metadata.Title,
+		PublishedAt: metadata.PublishedAt,
+		Grams:       titleGrams(metadata.Title),
+	}
+}
@@ -1,15 +1,16 @@
 package storage
 
 import (
+	"context"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 //go:generate mockgen --destination=./mock_storage/user.go github.com/ashmeet13/YoutubeDataService/source/storage UserInterface
 type UserInterface interface {
-	CreateUser(user *User) error
-	ReadUser(userID string) (*User, error)
-	UpdateUser(id string, user *User) error
+	CreateUser(ctx context.Context, user *User) error
+	ReadUser(ctx context.Context, userID string) (*User, error)
+	UpdateUser(ctx context.Context, id string, user *User) error
 }
 
 func NewUserImpl() *UserImpl {
@@ -22,22 +23,26 @@ type UserImpl struct {
 	collection string
 }
 
-func (u *UserImpl) CreateUser(user *User) error {
-	_, err := InsertOne(u.collection, user)
+func (u *UserImpl) CreateUser(ctx context.Context, user *User) error {
+	_, err := InsertOne(ctx, u.collection, user)
 
 	if err != nil {
-		return nil
+		// user_id is unique, an existing user is left untouched
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return err
 	}
 
 	return nil
 }
 
-func (u *UserImpl) ReadUser(userID string) (*User, error) {
+func (u *UserImpl) ReadUser(ctx context.Context, userID string) (*User, error) {
 	query := bson.M{
 		"user_id": bson.M{"$eq": userID},
 	}
 
-	result := FindOne(u.collection, query)
+	result := FindOne(ctx, u.collection, query)
 
 	var decodedResult User
 	err := result.Decode(&decodedResult)
@@ -51,7 +56,7 @@ func (u *UserImpl) ReadUser(userID string) (*User, error) {
 	return &decodedResult, nil
 }
 
-func (u *UserImpl) UpdateUser(id string, user *User) error {
+func (u *UserImpl) UpdateUser(ctx context.Context, id string, user *User) error {
 	filters := bson.M{
 		"user_id": bson.M{"$eq": id},
 	}
@@ -60,7 +65,7 @@ func (u *UserImpl) UpdateUser(id string, user *User) error {
 		"$set": user,
 	}
 
-	_, err := UpdateOne(u.collection, filters, modifier)
+	_, err := UpdateOne(ctx, u.collection, filters, modifier)
 	if err != nil {
 		return err
 	}
@@ -2,20 +2,117 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrVideoMetadataExists is returned when inserting a video whose id is already stored
+var ErrVideoMetadataExists = errors.New("video already exists")
+
 //go:generate mockgen --destination=./mock_storage/video_metadata.go github.com/ashmeet13/YoutubeDataService/source/storage VideoMetadataInterface
 type VideoMetadataInterface interface {
-	BulkInsertMetadata(videoMetadatas []*VideoMetadata) error
-	FindOneMetadataWithVideoID(id string) (*VideoMetadata, error)
-	UpdateOneMetadata(id string, videoMetadata *VideoMetadata) error
-	FetchPagedMetadata(timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error)
-	FindMetadataTextSearch(searchText string) ([]*VideoMetadata, error)
+	// Inserts the videos in order. Stops with ErrVideoMetadataExists at the first one
+	// already stored, the videos before it are kept. BulkUpsertMetadata stores
+	// videos that may be known.
+	BulkInsertMetadata(ctx context.Context, videoMetadatas []*VideoMetadata) error
+	FindOneMetadataWithVideoID(ctx context.Context, id string) (*VideoMetadata, error)
+	UpdateOneMetadata(ctx context.Context, id string, videoMetadata *VideoMetadata) error
+	// Sets the view, like and comment counts of the video of snapshot, leaving the rest
+	// of it as stored. Does nothing when the video is not stored.
+	UpdateMetadataStatistics(ctx context.Context, snapshot *VideoStatisticsSnapshot) error
+	FetchPagedMetadata(ctx context.Context, timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error)
+	// Same as FetchPagedMetadata, paged on the position of a video instead of an offset. Returns
+	// up to limit videos right after key in the feed, or right before it with before set. Without
+	// a key the page is the top of the feed, or its bottom with before set. Pages are in feed order.
+	FetchMetadataPageByKey(ctx context.Context, timestamp time.Time, key *FeedKey, before bool, limit int64) ([]*VideoMetadata, error)
+	// Same as FetchPagedMetadata, limited to the videos of one channel
+	FetchPagedMetadataWithChannelID(ctx context.Context, channelID string, timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error)
+	FindMetadataTextSearch(ctx context.Context, searchText string) ([]*VideoMetadata, error)
+	// Returns the page of the videos matching the query that follows query.After,
+	// along with the number of videos matching it
+	SearchMetadata(ctx context.Context, query *SearchQuery) (*SearchResult, error)
+	// Returns every video published at or after timestamp, most recent first
+	FindMetadataPublishedAfter(ctx context.Context, timestamp time.Time) ([]*VideoMetadata, error)
+	// Stores every video in one round trip, reporting the outcome of each one in order.
+	// An unknown video is inserted. A stored video is replaced when the new one was
+	// published later, and in any case gains the QueryTags of the new one.
+	BulkUpsertMetadata(ctx context.Context, videoMetadatas []*VideoMetadata) ([]*UpsertResult, error)
+}
+
+// FeedKey is the position of a video in the feed, which is ordered on the publish
+// time, most recent first, then on the video id
+type FeedKey struct {
+	PublishedAt time.Time
+	VideoID     string
+}
+
+func NewFeedKey(metadata *VideoMetadata) *FeedKey {
+	return &FeedKey{
+		PublishedAt: metadata.PublishedAt,
+		VideoID:     metadata.VideoID,
+	}
+}
+
+// Whether the video comes after the key in the feed
+func (k *FeedKey) precedes(metadata *VideoMetadata) bool {
+	if metadata.PublishedAt.Equal(k.PublishedAt) {
+		return metadata.VideoID > k.VideoID
+	}
+	return metadata.PublishedAt.Before(k.PublishedAt)
+}
+
+// UpsertOutcome is what BulkUpsertMetadata did with one video
+type UpsertOutcome int
+
+const (
+	// The video was not stored yet and has been inserted
+	UpsertInserted UpsertOutcome = iota
+	// The video was already stored, it is replaced or tagged as needed
+	UpsertMatched
+	// The video could not be stored, Err holds the reason
+	UpsertFailed
+)
+
+func (o UpsertOutcome) String() string {
+	switch o {
+	case UpsertInserted:
+		return "inserted"
+	case UpsertMatched:
+		return "matched"
+	default:
+		return "failed"
+	}
+}
+
+type UpsertResult struct {
+	VideoID string
+	Outcome UpsertOutcome
+	Err     error
+}
+
+// mergeQueryTags adds the tags missing from queryTags, like $addToSet
+func mergeQueryTags(queryTags []string, tags []string) []string {
+	merged := append([]string{}, queryTags...)
+	for _, tag := range tags {
+		found := false
+		for _, queryTag := range merged {
+			if queryTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
 }
 
 func NewVideoMetadataImpl() *VideoMetadataImpl {
@@ -28,7 +125,7 @@ type VideoMetadataImpl struct {
 	collection string
 }
 
-func (m *VideoMetadataImpl) BulkInsertMetadata(videoMetadatas []*VideoMetadata) error {
+func (m *VideoMetadataImpl) BulkInsertMetadata(ctx context.Context, videoMetadatas []*VideoMetadata) error {
 	insertDocs := bson.A{}
 
 	for _, metadata := range videoMetadatas {
@@ -40,20 +137,23 @@ func (m *VideoMetadataImpl) BulkInsertMetadata(videoMetadatas []*VideoMetadata)
 		insertDocs = append(insertDocs, doc)
 	}
 
-	_, err := InsertMany(m.collection, insertDocs)
+	_, err := InsertMany(ctx, m.collection, insertDocs)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrVideoMetadataExists
+		}
 		return err
 	}
 
 	return nil
 }
 
-func (m *VideoMetadataImpl) FindOneMetadataWithVideoID(id string) (*VideoMetadata, error) {
+func (m *VideoMetadataImpl) FindOneMetadataWithVideoID(ctx context.Context, id string) (*VideoMetadata, error) {
 	query := bson.M{
 		"video_id": bson.M{"$eq": id},
 	}
 
-	result := FindOne(m.collection, query)
+	result := FindOne(ctx, m.collection, query)
 
 	var decodedResult VideoMetadata
 	err := result.Decode(&decodedResult)
@@ -66,7 +166,7 @@ func (m *VideoMetadataImpl) FindOneMetadataWithVideoID(id string) (*VideoMetadat
 	return &decodedResult, err
 }
 
-func (m *VideoMetadataImpl) UpdateOneMetadata(id string, videoMetadata *VideoMetadata) error {
+func (m *VideoMetadataImpl) UpdateOneMetadata(ctx context.Context, id string, videoMetadata *VideoMetadata) error {
 	filters := bson.M{
 		"video_id": bson.M{"$eq": id},
 	}
@@ -75,7 +175,7 @@ func (m *VideoMetadataImpl) UpdateOneMetadata(id string, videoMetadata *VideoMet
 		"$set": videoMetadata,
 	}
 
-	_, err := UpdateOne(m.collection, filters, modifier)
+	_, err := UpdateOne(ctx, m.collection, filters, modifier)
 	if err != nil {
 		return err
 	}
@@ -83,7 +183,96 @@ func (m *VideoMetadataImpl) UpdateOneMetadata(id string, videoMetadata *VideoMet
 	return nil
 }
 
-func (m *VideoMetadataImpl) FetchPagedMetadata(timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error) {
+func (m *VideoMetadataImpl) UpdateMetadataStatistics(ctx context.Context, snapshot *VideoStatisticsSnapshot) error {
+	filters := bson.M{
+		"video_id": bson.M{"$eq": snapshot.VideoID},
+	}
+
+	modifier := bson.M{
+		"$set": bson.M{
+			"view_count":    snapshot.ViewCount,
+			"like_count":    snapshot.LikeCount,
+			"comment_count": snapshot.CommentCount,
+		},
+	}
+
+	_, err := UpdateOne(ctx, m.collection, filters, modifier)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/*
+BulkUpsertMetadata sends two updates per video in one unordered BulkWrite:
+
+  - the first replaces the stored video if the new one was published later
+  - the second inserts the video if it is not stored yet
+
+Both add the query tags, so they can run in any order. The unique index on
+video_id makes the insert safe against concurrent workers: the losing insert
+fails with a duplicate key error, reported as the outcome of that video.
+*/
+func (m *VideoMetadataImpl) BulkUpsertMetadata(ctx context.Context, videoMetadatas []*VideoMetadata) ([]*UpsertResult, error) {
+	if len(videoMetadatas) == 0 {
+		return nil, nil
+	}
+
+	models := []mongo.WriteModel{}
+	for _, metadata := range videoMetadatas {
+		doc, err := convertToBsonM(metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		// The tags are merged, never overwritten
+		delete(*doc, "query_tags")
+		addTags := bson.M{
+			"query_tags": bson.M{"$each": append([]string{}, metadata.QueryTags...)},
+		}
+
+		models = append(models,
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{
+					"video_id":     metadata.VideoID,
+					"published_at": bson.M{"$lt": metadata.PublishedAt},
+				}).
+				SetUpdate(bson.M{"$set": doc, "$addToSet": addTags}),
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"video_id": metadata.VideoID}).
+				SetUpdate(bson.M{"$setOnInsert": doc, "$addToSet": addTags}).
+				SetUpsert(true),
+		)
+	}
+
+	result, err := BulkWrite(ctx, m.collection, models, options.BulkWrite().SetOrdered(false))
+
+	var bulkWriteException mongo.BulkWriteException
+	if err != nil && (!errors.As(err, &bulkWriteException) || bulkWriteException.WriteConcernError != nil) {
+		return nil, err
+	}
+
+	results := []*UpsertResult{}
+	for _, metadata := range videoMetadatas {
+		results = append(results, &UpsertResult{VideoID: metadata.VideoID, Outcome: UpsertMatched})
+	}
+
+	if result != nil {
+		for index := range result.UpsertedIDs {
+			results[index/2].Outcome = UpsertInserted
+		}
+	}
+
+	for _, writeError := range bulkWriteException.WriteErrors {
+		results[writeError.Index/2].Outcome = UpsertFailed
+		results[writeError.Index/2].Err = writeError
+	}
+
+	return results, nil
+}
+
+func (m *VideoMetadataImpl) FetchPagedMetadata(ctx context.Context, timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error) {
 	query := bson.M{
 		"published_at": bson.M{"$lte": timestamp},
 	}
@@ -94,14 +283,16 @@ func (m *VideoMetadataImpl) FetchPagedMetadata(timestamp time.Time, offset, limi
 		Skip:  &offset,
 	}
 
-	cur, err := Find(m.collection, query, queryOpts)
+	cur, err := Find(ctx, m.collection, query, queryOpts)
+	if err != nil {
+		return nil, err
+	}
 
 	err = cur.Err()
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := context.Background()
 	defer cur.Close(ctx)
 
 	var metadata []*VideoMetadata
@@ -117,19 +308,327 @@ func (m *VideoMetadataImpl) FetchPagedMetadata(timestamp time.Time, offset, limi
 	return metadata, nil
 }
 
-func (m *VideoMetadataImpl) FindMetadataTextSearch(searchText string) ([]*VideoMetadata, error) {
+func (m *VideoMetadataImpl) FetchPagedMetadataWithChannelID(ctx context.Context, channelID string, timestamp time.Time, offset, limit int64) ([]*VideoMetadata, error) {
+	query := bson.M{
+		"channel_id":   bson.M{"$eq": channelID},
+		"published_at": bson.M{"$lte": timestamp},
+	}
+
+	queryOpts := &options.FindOptions{
+		Sort:  bson.M{"published_at": -1},
+		Limit: &limit,
+		Skip:  &offset,
+	}
+
+	cur, err := Find(ctx, m.collection, query, queryOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cur.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	defer cur.Close(ctx)
+
+	var metadata []*VideoMetadata
+	for cur.Next(ctx) {
+		var videoMetadata VideoMetadata
+		err := cur.Decode(&videoMetadata)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, &videoMetadata)
+	}
+
+	return metadata, nil
+}
+
+func (m *VideoMetadataImpl) FetchMetadataPageByKey(ctx context.Context, timestamp time.Time, key *FeedKey, before bool, limit int64) ([]*VideoMetadata, error) {
+	conditions := bson.A{
+		bson.M{"published_at": bson.M{"$lte": timestamp}},
+	}
+
+	// Pages before the key are read in reverse, from the key upwards
+	publishedAtOperator, videoIDOperator := "$lt", "$gt"
+	sort := bson.D{{Key: "published_at", Value: -1}, {Key: "video_id", Value: 1}}
+	if before {
+		publishedAtOperator, videoIDOperator = "$gt", "$lt"
+		sort = bson.D{{Key: "published_at", Value: 1}, {Key: "video_id", Value: -1}}
+	}
+
+	if key != nil {
+		conditions = append(conditions, bson.M{
+			"$or": bson.A{
+				bson.M{"published_at": bson.M{publishedAtOperator: key.PublishedAt}},
+				bson.M{"published_at": key.PublishedAt, "video_id": bson.M{videoIDOperator: key.VideoID}},
+			},
+		})
+	}
+
+	queryOpts := &options.FindOptions{
+		Sort:  sort,
+		Limit: &limit,
+	}
+
+	cur, err := Find(ctx, m.collection, bson.M{"$and": conditions}, queryOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cur.Close(ctx)
+
+	var metadata []*VideoMetadata
+	for cur.Next(ctx) {
+		var videoMetadata VideoMetadata
+		err := cur.Decode(&videoMetadata)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, &videoMetadata)
+	}
+
+	err = cur.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if before {
+		reverseVideoMetadata(metadata)
+	}
+	return metadata, nil
+}
+
+func reverseVideoMetadata(metadata []*VideoMetadata) {
+	for i, j := 0, len(metadata)-1; i < j; i, j = i+1, j-1 {
+		metadata[i], metadata[j] = metadata[j], metadata[i]
+	}
+}
+
+func (m *VideoMetadataImpl) FindMetadataTextSearch(ctx context.Context, searchText string) ([]*VideoMetadata, error) {
 	query := bson.M{
 		"$text": bson.M{"$search": searchText},
 	}
 
-	cur, err := Find(m.collection, query)
+	cur, err := Find(ctx, m.collection, query)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cur.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	defer cur.Close(ctx)
+
+	var metadata []*VideoMetadata
+	for cur.Next(ctx) {
+		var videoMetadata VideoMetadata
+		err := cur.Decode(&videoMetadata)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, &videoMetadata)
+	}
+
+	return metadata, nil
+}
+
+// Searches with an aggregation, so the page can start after a key on the textScore.
+// A collection has a single $text query over the weighted text index, it finds and
+// scores the candidates on every term. The title and description texts are then
+// held to their field by regular expressions, which also apply their negated terms,
+// see mongoSearchText and mongoFilter.
+func (m *VideoMetadataImpl) SearchMetadata(ctx context.Context, query *SearchQuery) (*SearchResult, error) {
+	filters := bson.M{}
+	if query.hasText() {
+		filters["$text"] = bson.M{"$search": mongoSearchText(query)}
+	}
+
+	if query.Title != "" || query.Description != "" {
+		var fieldFilters bson.A
+
+		// $text matches any term of any text, each text must match on its own
+		if query.Text != "" {
+			fieldFilters = append(fieldFilters, newTextSearch(query.Text).mongoFilter("title", "description"))
+		}
+		if query.Title != "" {
+			fieldFilters = append(fieldFilters, newTextSearch(query.Title).mongoFilter("title"))
+		}
+		if query.Description != "" {
+			fieldFilters = append(fieldFilters, newTextSearch(query.Description).mongoFilter("description"))
+		}
+		filters["$and"] = fieldFilters
+	}
+
+	publishedAt := bson.M{}
+	if !query.PublishedAfter.IsZero() {
+		publishedAt["$gte"] = query.PublishedAfter
+	}
+	if !query.PublishedBefore.IsZero() {
+		publishedAt["$lte"] = query.PublishedBefore
+	}
+	if len(publishedAt) > 0 {
+		filters["published_at"] = publishedAt
+	}
+
+	if len(query.ChannelIDs) > 0 {
+		filters["channel_id"] = bson.M{"$in": query.ChannelIDs}
+	}
+	if len(query.QueryTags) > 0 {
+		filters["query_tags"] = bson.M{"$in": query.QueryTags}
+	}
+
+	total, err := CountDocuments(ctx, m.collection, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	score := bson.M{"$literal": 0.0}
+	if query.hasText() {
+		score = bson.M{"$meta": "textScore"}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filters}},
+		{{Key: "$addFields", Value: bson.M{"_score": score}}},
+	}
+
+	sortField := "published_at"
+	if query.Sort == SortByRelevance {
+		sortField = "_score"
+	}
+
+	if query.After != nil {
+		var after interface{} = query.After.PublishedAt
+		if query.Sort == SortByRelevance {
+			after = query.After.Score
+		}
+
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{
+			"$or": bson.A{
+				bson.M{sortField: bson.M{"$lt": after}},
+				bson.M{sortField: after, "video_id": bson.M{"$gt": query.After.VideoID}},
+			},
+		}}})
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: sortField, Value: -1}, {Key: "video_id", Value: 1}}}},
+		bson.D{{Key: "$limit", Value: query.Limit}},
+	)
+
+	cur, err := Aggregate(ctx, m.collection, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cur.Close(ctx)
+
+	result := &SearchResult{Total: total}
+	for cur.Next(ctx) {
+		var scored struct {
+			VideoMetadata `bson:",inline"`
+			Score         float64 `bson:"_score"`
+		}
+		err := cur.Decode(&scored)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata := scored.VideoMetadata
+		result.Hits = append(result.Hits, &SearchHit{Metadata: &metadata, Score: scored.Score})
+	}
+
+	err = cur.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// mongoSearchText is the $text search of query. The negated terms of the title and
+// description texts only exclude a video on their own field, they are left out of
+// $text, which would exclude on any field, for the regular expressions of mongoFilter.
+func mongoSearchText(query *SearchQuery) string {
+	texts := []string{query.Text}
+	for _, fieldText := range []string{query.Title, query.Description} {
+		search := newTextSearch(fieldText)
+		texts = append(texts, strings.Join(search.terms, " "))
+		for _, phrase := range search.phrases {
+			texts = append(texts, "\""+phrase+"\"")
+		}
+	}
+
+	searchText := strings.TrimSpace(strings.Join(texts, " "))
+	if searchText == "" {
+		// Only negated terms, which match nothing like the field filters
+		return query.allText()
+	}
+	return searchText
+}
+
+// mongoFilter approximates the search on some fields with regular expressions,
+// a term matches the start of a word so stemmed forms are found too
+func (s *textSearch) mongoFilter(fields ...string) bson.M {
+	anyField := func(pattern string) bson.M {
+		var matches bson.A
+		for _, field := range fields {
+			matches = append(matches, bson.M{field: primitive.Regex{Pattern: pattern, Options: "i"}})
+		}
+		return bson.M{"$or": matches}
+	}
+
+	var stems []string
+	for _, term := range s.terms {
+		stems = append(stems, regexp.QuoteMeta(stem(term)))
+	}
+	if len(stems) == 0 {
+		// Nothing to match, like a $text query without terms
+		return bson.M{"_id": bson.M{"$exists": false}}
+	}
+
+	conditions := bson.A{anyField(`\b(` + strings.Join(stems, "|") + `)`)}
+
+	for _, phrase := range s.phrases {
+		var words []string
+		for _, word := range tokenize(phrase) {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+		conditions = append(conditions, anyField(`\b`+strings.Join(words, `\w*\W+`)))
+	}
+
+	for _, term := range s.negatedTerms {
+		for _, field := range fields {
+			conditions = append(conditions, bson.M{field: bson.M{"$not": primitive.Regex{Pattern: `\b` + regexp.QuoteMeta(stem(term)), Options: "i"}}})
+		}
+	}
+
+	return bson.M{"$and": conditions}
+}
+
+func (m *VideoMetadataImpl) FindMetadataPublishedAfter(ctx context.Context, timestamp time.Time) ([]*VideoMetadata, error) {
+	query := bson.M{
+		"published_at": bson.M{"$gte": timestamp},
+	}
+
+	queryOpts := &options.FindOptions{
+		Sort: bson.M{"published_at": -1},
+	}
+
+	cur, err := Find(ctx, m.collection, query, queryOpts)
+	if err != nil {
+		return nil, err
+	}
 
 	err = cur.Err()
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := context.Background()
 	defer cur.Close(ctx)
 
 	var metadata []*VideoMetadata
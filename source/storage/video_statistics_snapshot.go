@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:generate mockgen --destination=./mock_storage/video_statistics_snapshot.go github.com/ashmeet13/YoutubeDataService/source/storage VideoStatisticsSnapshotInterface
+type VideoStatisticsSnapshotInterface interface {
+	// Snapshots with the VideoID and Timestamp of a stored one are skipped
+	BulkInsertSnapshots(ctx context.Context, snapshots []*VideoStatisticsSnapshot) error
+	// Returns the snapshots of a video, oldest first
+	FindSnapshotsWithVideoID(ctx context.Context, id string) ([]*VideoStatisticsSnapshot, error)
+}
+
+func NewVideoStatisticsSnapshotImpl() *VideoStatisticsSnapshotImpl {
+	return &VideoStatisticsSnapshotImpl{
+		collection: VideoStatisticsSnapshotC,
+	}
+}
+
+type VideoStatisticsSnapshotImpl struct {
+	collection string
+}
+
+func (m *VideoStatisticsSnapshotImpl) BulkInsertSnapshots(ctx context.Context, snapshots []*VideoStatisticsSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	insertDocs := bson.A{}
+	for _, snapshot := range snapshots {
+		doc, err := convertToBsonM(snapshot)
+		if err != nil {
+			return err
+		}
+
+		insertDocs = append(insertDocs, doc)
+	}
+
+	// Unordered, so a duplicate does not stop the rest from being inserted
+	_, err := InsertMany(ctx, m.collection, insertDocs, options.InsertMany().SetOrdered(false))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (m *VideoStatisticsSnapshotImpl) FindSnapshotsWithVideoID(ctx context.Context, id string) ([]*VideoStatisticsSnapshot, error) {
+	query := bson.M{
+		"video_id": bson.M{"$eq": id},
+	}
+
+	queryOpts := &options.FindOptions{
+		Sort: bson.M{"timestamp": 1},
+	}
+
+	cur, err := Find(ctx, m.collection, query, queryOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cur.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	defer cur.Close(ctx)
+
+	var snapshots []*VideoStatisticsSnapshot
+	for cur.Next(ctx) {
+		var snapshot VideoStatisticsSnapshot
+		err := cur.Decode(&snapshot)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, &snapshot)
+	}
+
+	return snapshots, nil
+}
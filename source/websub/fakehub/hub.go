@@ -0,0 +1,282 @@
+/*
+Package fakehub is a local fake of a WebSub hub, for tests that need the callback
+and the subscriptions of the service to talk to a real hub.
+
+Subscription requests are verified against the callback before the hub answers,
+the same way a hub does it asynchronously, so tests need not wait for it. Feeds
+published to a topic are pushed, signed, to its verified subscribers.
+
+	hub := fakehub.NewHub()
+	defer hub.Close()
+
+	client := websub.NewHubClient(hub.URL())
+	hub.Publish(websub.TopicURL("UCchannel"), fakehub.Feed(fakehub.Entry{VideoID: "abc"}))
+*/
+package fakehub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/websub"
+)
+
+// Subscription is a subscription the hub received
+type Subscription struct {
+	Callback     string
+	Topic        string
+	Secret       string
+	LeaseSeconds int
+
+	// Set once the callback confirmed the subscription
+	Verified   bool
+	VerifiedAt time.Time
+}
+
+// Hub is a fake hub serving on a local port
+type Hub struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+	requestCount  int
+	failures      []int
+}
+
+func NewHub() *Hub {
+	hub := &Hub{subscriptions: map[string]*Subscription{}}
+	hub.server = httptest.NewServer(http.HandlerFunc(hub.handle))
+	return hub
+}
+
+// URL is the subscribe endpoint of the hub
+func (h *Hub) URL() string {
+	return h.server.URL + "/subscribe"
+}
+
+func (h *Hub) Close() {
+	h.server.Close()
+}
+
+// Subscriptions returns the subscriptions of the hub, verified or not
+func (h *Hub) Subscriptions() []Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscriptions := []Subscription{}
+	for _, subscription := range h.subscriptions {
+		subscriptions = append(subscriptions, *subscription)
+	}
+	return subscriptions
+}
+
+// RequestCount returns the number of subscription requests the hub received
+func (h *Hub) RequestCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.requestCount
+}
+
+// FailNext answers the next subscription request with status instead of serving it
+func (h *Hub) FailNext(status int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.failures = append(h.failures, status)
+}
+
+func (h *Hub) handle(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requestCount++
+	if len(h.failures) > 0 {
+		status := h.failures[0]
+		h.failures = h.failures[1:]
+		h.mu.Unlock()
+
+		http.Error(w, "scripted failure", status)
+		return
+	}
+	h.mu.Unlock()
+
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		http.Error(w, "expected a form", http.StatusBadRequest)
+		return
+	}
+
+	mode := r.PostForm.Get("hub.mode")
+	callback := r.PostForm.Get("hub.callback")
+	topic := r.PostForm.Get("hub.topic")
+	if callback == "" || topic == "" || (mode != websub.SubscribeMode && mode != websub.UnsubscribeMode) {
+		http.Error(w, "invalid subscription request", http.StatusBadRequest)
+		return
+	}
+
+	leaseSeconds, _ := strconv.Atoi(r.PostForm.Get("hub.lease_seconds"))
+
+	// A hub only acts on requests the callback confirms
+	verified, err := verify(callback, mode, topic, leaseSeconds)
+	if err != nil || !verified {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := callback + " " + topic
+	if mode == websub.UnsubscribeMode {
+		delete(h.subscriptions, key)
+	} else {
+		h.subscriptions[key] = &Subscription{
+			Callback:     callback,
+			Topic:        topic,
+			Secret:       r.PostForm.Get("hub.secret"),
+			LeaseSeconds: leaseSeconds,
+			Verified:     true,
+			VerifiedAt:   time.Now(),
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Sends the challenge of a verification to the callback, which has to echo it
+func verify(callback string, mode string, topic string, leaseSeconds int) (bool, error) {
+	challenge := strconv.FormatInt(rand.Int63(), 36)
+
+	query := url.Values{}
+	query.Set("hub.mode", mode)
+	query.Set("hub.topic", topic)
+	query.Set("hub.challenge", challenge)
+	if mode == websub.SubscribeMode {
+		query.Set("hub.lease_seconds", strconv.Itoa(leaseSeconds))
+	}
+
+	callbackURL, err := url.Parse(callback)
+	if err != nil {
+		return false, err
+	}
+	callbackURL.RawQuery = query.Encode()
+
+	response, err := http.Get(callbackURL.String())
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return false, err
+	}
+	return response.StatusCode/100 == 2 && string(body) == challenge, nil
+}
+
+// Publish pushes feed, signed with their secret, to every verified subscriber of
+// topic. Returns an error if a subscriber does not answer with a 2xx.
+func (h *Hub) Publish(topic string, feed []byte) error {
+	return h.publish(topic, feed, websub.Sign)
+}
+
+// PublishForged pushes feed to the subscribers of topic with a signature made
+// from the wrong secret, as a forged notification would be
+func (h *Hub) PublishForged(topic string, feed []byte) error {
+	return h.publish(topic, feed, func(secret string, body []byte) string {
+		return websub.Sign(secret+"forged", body)
+	})
+}
+
+func (h *Hub) publish(topic string, feed []byte, sign func(secret string, body []byte) string) error {
+	h.mu.Lock()
+	subscriptions := []Subscription{}
+	for _, subscription := range h.subscriptions {
+		if subscription.Topic == topic && subscription.Verified {
+			subscriptions = append(subscriptions, *subscription)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, subscription := range subscriptions {
+		request, err := http.NewRequest(http.MethodPost, subscription.Callback, bytes.NewReader(feed))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Content-Type", "application/atom+xml")
+		request.Header.Set(websub.SignatureHeader, sign(subscription.Secret, feed))
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return err
+		}
+		response.Body.Close()
+
+		if response.StatusCode/100 != 2 {
+			return fmt.Errorf("subscriber %s answered with status %d", subscription.Callback, response.StatusCode)
+		}
+	}
+	return nil
+}
+
+// Entry is one video of a feed
+type Entry struct {
+	VideoID      string
+	ChannelID    string
+	ChannelTitle string
+	Title        string
+	PublishedAt  time.Time
+}
+
+// Feed builds the Atom feed Youtube pushes for entries
+func Feed(entries ...Entry) []byte {
+	type author struct {
+		Name string `xml:"name"`
+	}
+	type entry struct {
+		ID        string `xml:"id"`
+		VideoID   string `xml:"yt:videoId"`
+		ChannelID string `xml:"yt:channelId"`
+		Title     string `xml:"title"`
+		Author    author `xml:"author"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	}
+	type feed struct {
+		XMLName xml.Name `xml:"feed"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		YT      string   `xml:"xmlns:yt,attr"`
+		Title   string   `xml:"title"`
+		Entries []entry  `xml:"entry"`
+	}
+
+	document := feed{
+		XMLNS: "http://www.w3.org/2005/Atom",
+		YT:    "http://www.youtube.com/xml/schemas/2015",
+		Title: "YouTube video feed",
+	}
+	for _, e := range entries {
+		document.Entries = append(document.Entries, entry{
+			ID:        "yt:video:" + e.VideoID,
+			VideoID:   e.VideoID,
+			ChannelID: e.ChannelID,
+			Title:     e.Title,
+			Author:    author{Name: e.ChannelTitle},
+			Published: e.PublishedAt.Format(time.RFC3339),
+			Updated:   time.Now().UTC().Format(time.RFC3339),
+		})
+	}
+
+	body, err := xml.Marshal(document)
+	if err != nil {
+		panic(err)
+	}
+	return append([]byte(xml.Header), body...)
+}
@@ -0,0 +1,203 @@
+/*
+Package websub talks to a WebSub (PubSubHubbub) hub on behalf of the service.
+
+Youtube publishes a topic per channel and pushes an Atom feed to the callback of
+every subscriber each time the channel uploads or updates a video. A subscription
+is requested from the hub, which confirms it by calling the callback with a
+challenge, and only lasts for its lease, it has to be renewed before then.
+
+Every notification is signed with the secret given on subscribe, the callback
+checks the signature before trusting the feed.
+*/
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+)
+
+// Modes of a subscription request, and of the verification the hub sends back
+const (
+	SubscribeMode   = "subscribe"
+	UnsubscribeMode = "unsubscribe"
+	DeniedMode      = "denied"
+)
+
+// SignatureHeader carries the HMAC of a notification body, e.g. sha1=<hex>
+const SignatureHeader = "X-Hub-Signature"
+
+// TopicURL is the topic Youtube publishes the uploads of a channel to
+func TopicURL(channelID string) string {
+	return "https://www.youtube.com/xml/feeds/videos.xml?channel_id=" + url.QueryEscape(channelID)
+}
+
+// ChannelIDFromTopic returns the channel of a topic built by TopicURL, empty for any other topic
+func ChannelIDFromTopic(topic string) string {
+	topicURL, err := url.Parse(topic)
+	if err != nil || topicURL.Host != "www.youtube.com" || topicURL.Path != "/xml/feeds/videos.xml" {
+		return ""
+	}
+	return topicURL.Query().Get("channel_id")
+}
+
+// HubError is returned when the hub refuses a subscription request
+type HubError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HubError) Error() string {
+	return fmt.Sprintf("hub refused the request with status %d: %s", e.StatusCode, e.Body)
+}
+
+// HubClient sends subscription requests to a hub
+type HubClient struct {
+	hubURL     string
+	httpClient *http.Client
+}
+
+func NewHubClient(hubURL string) *HubClient {
+	return &HubClient{
+		hubURL:     hubURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Subscribe asks the hub to push the topic to callback for lease. The hub accepts
+// the request and confirms the subscription later by calling the callback.
+func (c *HubClient) Subscribe(ctx context.Context, callback string, topic string, secret string, lease time.Duration) error {
+	form := url.Values{}
+	form.Set("hub.mode", SubscribeMode)
+	form.Set("hub.callback", callback)
+	form.Set("hub.topic", topic)
+	form.Set("hub.lease_seconds", strconv.Itoa(int(lease.Seconds())))
+	form.Set("hub.secret", secret)
+
+	return c.send(ctx, form)
+}
+
+// Unsubscribe asks the hub to stop pushing the topic to callback
+func (c *HubClient) Unsubscribe(ctx context.Context, callback string, topic string) error {
+	form := url.Values{}
+	form.Set("hub.mode", UnsubscribeMode)
+	form.Set("hub.callback", callback)
+	form.Set("hub.topic", topic)
+
+	return c.send(ctx, form)
+}
+
+func (c *HubClient) send(ctx context.Context, form url.Values) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.hubURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return &HubError{StatusCode: response.StatusCode, Body: string(body)}
+	}
+	return nil
+}
+
+// Sign returns the signature header of body, as a hub sends it
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(body)
+	return "sha1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the signature header of a notification against its body.
+// The hub picks the hash, sha1 and sha256 are accepted.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	method, digest, found := strings.Cut(signature, "=")
+	if !found {
+		return false
+	}
+
+	var newHash func() hash.Hash
+	switch method {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(expected, mac.Sum(nil))
+}
+
+// The Atom feed of a notification, with the Youtube extensions
+type atomFeed struct {
+	Entries []atomEntry `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type atomEntry struct {
+	VideoID   string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	ChannelID string `xml:"http://www.youtube.com/xml/schemas/2015 channelId"`
+	Title     string `xml:"http://www.w3.org/2005/Atom title"`
+	Author    struct {
+		Name string `xml:"http://www.w3.org/2005/Atom name"`
+	} `xml:"http://www.w3.org/2005/Atom author"`
+	Published string `xml:"http://www.w3.org/2005/Atom published"`
+}
+
+// ParseFeed reads the videos of a notification. Deleted videos come as
+// tombstones instead of entries and are left out.
+func ParseFeed(body io.Reader) ([]*storage.VideoMetadata, error) {
+	var feed atomFeed
+	err := xml.NewDecoder(body).Decode(&feed)
+	if err != nil {
+		return nil, err
+	}
+
+	videoMetadataList := []*storage.VideoMetadata{}
+	for _, entry := range feed.Entries {
+		if entry.VideoID == "" {
+			continue
+		}
+
+		publishedAt, err := time.Parse(time.RFC3339, entry.Published)
+		if err != nil {
+			return nil, err
+		}
+
+		videoMetadataList = append(videoMetadataList, &storage.VideoMetadata{
+			VideoID:      entry.VideoID,
+			Title:        entry.Title,
+			PublishedAt:  publishedAt.UTC(),
+			ChannelID:    entry.ChannelID,
+			ChannelTitle: entry.Author.Name,
+		})
+	}
+
+	return videoMetadataList, nil
+}
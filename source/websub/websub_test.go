@@ -0,0 +1,104 @@
+package websub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// A notification as Youtube sends it, an upload and a deleted video
+const notification = `<?xml version='1.0' encoding='UTF-8'?>
+<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns="http://www.w3.org/2005/Atom" xmlns:at="http://purl.org/atompub/tombstones/1.0">
+  <link rel="hub" href="https://pubsubhubbub.appspot.com"/>
+  <title>YouTube video feed</title>
+  <updated>2022-09-01T12:05:00.000000+00:00</updated>
+  <entry>
+    <id>yt:video:abc</id>
+    <yt:videoId>abc</yt:videoId>
+    <yt:channelId>UCchannel</yt:channelId>
+    <title>Cricket highlights</title>
+    <link rel="alternate" href="https://www.youtube.com/watch?v=abc"/>
+    <author>
+      <name>Cricket TV</name>
+      <uri>https://www.youtube.com/channel/UCchannel</uri>
+    </author>
+    <published>2022-09-01T17:30:00+05:30</published>
+    <updated>2022-09-01T12:05:00.000000+00:00</updated>
+  </entry>
+  <at:deleted-entry ref="yt:video:def" when="2022-09-01T12:04:00+00:00"/>
+</feed>`
+
+func TestParseFeed(t *testing.T) {
+	videoMetadataList, err := ParseFeed(strings.NewReader(notification))
+	require.NoError(t, err)
+	require.Len(t, videoMetadataList, 1)
+
+	require.Equal(t, "abc", videoMetadataList[0].VideoID)
+	require.Equal(t, "Cricket highlights", videoMetadataList[0].Title)
+	require.Equal(t, "UCchannel", videoMetadataList[0].ChannelID)
+	require.Equal(t, "Cricket TV", videoMetadataList[0].ChannelTitle)
+	require.Equal(t, time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC), videoMetadataList[0].PublishedAt)
+}
+
+func TestParseFeed_Invalid(t *testing.T) {
+	_, err := ParseFeed(strings.NewReader("not a feed"))
+	require.Error(t, err)
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(notification)
+
+	require.True(t, VerifySignature("secret", body, Sign("secret", body)))
+	require.False(t, VerifySignature("other", body, Sign("secret", body)))
+	require.False(t, VerifySignature("secret", []byte("tampered"), Sign("secret", body)))
+
+	// sha256 signature of "body" with the key "secret"
+	require.True(t, VerifySignature("secret", []byte("body"), "sha256=dc46983557fea127b43af721467eb9b3fde2338fe3e14f51952aa8478c13d355"))
+
+	for _, signature := range []string{"", "sha1", "md5=abcd", "sha1=not-hex"} {
+		require.False(t, VerifySignature("secret", body, signature), signature)
+	}
+}
+
+func TestChannelIDFromTopic(t *testing.T) {
+	require.Equal(t, "UCchannel", ChannelIDFromTopic(TopicURL("UCchannel")))
+	require.Equal(t, "", ChannelIDFromTopic("https://example.com/feed?channel_id=UCchannel"))
+}
+
+func TestSubscribe(t *testing.T) {
+	var form map[string][]string
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	client := NewHubClient(hub.URL)
+	err := client.Subscribe(context.Background(), "https://service/websub", TopicURL("UCchannel"), "secret", 24*time.Hour)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"subscribe"}, form["hub.mode"])
+	require.Equal(t, []string{"https://service/websub"}, form["hub.callback"])
+	require.Equal(t, []string{TopicURL("UCchannel")}, form["hub.topic"])
+	require.Equal(t, []string{"86400"}, form["hub.lease_seconds"])
+	require.Equal(t, []string{"secret"}, form["hub.secret"])
+}
+
+func TestSubscribe_Refused(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid topic", http.StatusBadRequest)
+	}))
+	defer hub.Close()
+
+	err := NewHubClient(hub.URL).Subscribe(context.Background(), "https://service/websub", "topic", "secret", time.Hour)
+
+	var hubError *HubError
+	require.ErrorAs(t, err, &hubError)
+	require.Equal(t, http.StatusBadRequest, hubError.StatusCode)
+}
@@ -0,0 +1,234 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+)
+
+/*
+BackfillHandler collects the videos of a query that were published in a bounded
+window, [publishedAfter, publishedBefore), that is usually before the service started.
+
+How does it work?
+
+We query GET /search/list with both PublishedAfter and PublishedBefore set and
+follow the nextPageToken until there are no more pages.
+
+The search API stops handing out pages after a few hundred results. So once
+the pages of a window run out, the window is closed at the oldest video seen so
+far and searched again. This repeats until a search of the window returns no
+videos older than the ones we already have.
+
+The backfill has its own checkpoint, its ID is built from the query and the
+window. The live worker checkpoint is never touched. The checkpoint fields are
+used as -
+1. CurrentPublishedTime - the publishedBefore of the window being searched
+2. PreviousPublishedTime - the oldest video seen in the window being searched
+3. NextPageToken - the page of the window to fetch next
+4. Completed - set once the whole window has been collected
+
+API keys come from the same pool as the live worker. Videos are tagged with the
+backfill tag, in the same way the live worker tags them with its query tag.
+*/
+type BackfillHandler struct {
+	youtubeClient
+
+	query string
+	tag   string
+
+	publishedAfter  time.Time
+	publishedBefore time.Time
+
+	windowBefore  time.Time
+	oldestSeen    time.Time
+	nextPageToken string
+	completed     bool
+
+	sleepTime time.Duration
+	backoff   backoff
+
+	videoMetadataHandler storage.VideoMetadataInterface
+	checkpointHandler    storage.CheckpointInterface
+	channelHandler       storage.ChannelInterface
+	suggestionHandler    storage.SuggestionInterface
+
+	*lifecycle
+}
+
+func NewBackfillHandler(ctx context.Context, query string, tag string, publishedAfter, publishedBefore time.Time, apiKeyPool *youtube_handler.APIKeyPool, maxBackoff time.Duration) (*BackfillHandler, error) {
+	if !publishedAfter.Before(publishedBefore) {
+		return nil, fmt.Errorf("backfill publishedAfter %s is not before publishedBefore %s", publishedAfter, publishedBefore)
+	}
+
+	backfillHandler := &BackfillHandler{
+		youtubeClient:        newYoutubeClient(apiKeyPool),
+		query:                query,
+		tag:                  tag,
+		publishedAfter:       publishedAfter.UTC(),
+		publishedBefore:      publishedBefore.UTC(),
+		windowBefore:         publishedBefore.UTC(),
+		sleepTime:            2 * time.Second,
+		backoff:              newBackoff(maxBackoff),
+		videoMetadataHandler: storage.NewVideoMetadataHandler(),
+		checkpointHandler:    storage.NewCheckpointHandler(),
+		channelHandler:       storage.NewChannelHandler(),
+		suggestionHandler:    storage.NewSuggestionHandler(),
+		lifecycle:            newLifecycle(),
+	}
+
+	err := backfillHandler.RestoreCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return backfillHandler, nil
+}
+
+// ID of the backfill checkpoint, unique for a query and window
+func (h *BackfillHandler) CheckpointID() string {
+	return fmt.Sprintf("backfill:%s:%s:%s", h.query, h.publishedAfter.Format(time.RFC3339), h.publishedBefore.Format(time.RFC3339))
+}
+
+// Runs the backfill until the whole window is collected or it is stopped, calls made are cancelled with ctx
+func (h *BackfillHandler) Start(ctx context.Context) {
+	defer h.finish()
+
+	logger := common.GetLogger().WithField("BackfillID", h.CheckpointID())
+
+	for !h.completed {
+		h.sleepTime = 2 * time.Second
+
+		err := h.syncAPIKey()
+		if err == nil {
+			err = h.Execute(ctx)
+		}
+
+		if ctx.Err() != nil {
+			logger.Info("Backfill aborted")
+			return
+		}
+
+		var keysExhausted *youtube_handler.KeysExhaustedError
+		switch classifyError(err) {
+		case errorClassNone:
+			h.backoff.Reset()
+
+			err = h.SaveCheckpoint(ctx)
+			if err != nil {
+				logger.WithError(err).Error("Failed to save backfill checkpoint")
+			}
+		case errorClassQuota:
+			if errors.As(err, &keysExhausted) {
+				logger.WithField("Until", keysExhausted.Until).Warn("Every API Key is out of quota, waiting for the reset")
+				h.sleepTime = time.Until(keysExhausted.Until)
+			} else {
+				logger.Info("API Key Quota Exceeded")
+				h.parkAPIKey()
+			}
+		case errorClassRetryable:
+			h.sleepTime = h.backoff.Next()
+			logger.WithError(err).WithField("RetryIn", h.sleepTime).Warn("Error in backfill, retrying")
+		default:
+			logger.WithError(err).Error("Fatal error in backfill, exiting backfill")
+			return
+		}
+
+		if !h.completed && !h.sleep(ctx, h.sleepTime) {
+			logger.Info("Backfill stopped")
+			return
+		}
+	}
+
+	logger.Info("Backfill completed")
+}
+
+// Restores the progress of the backfill from its checkpoint, if there is one
+func (h *BackfillHandler) RestoreCheckpoint(ctx context.Context) error {
+	logger := common.GetLogger().WithField("BackfillID", h.CheckpointID())
+
+	checkpoint, err := h.checkpointHandler.ReadCheckpoint(ctx, h.CheckpointID())
+	if err != nil {
+		return err
+	}
+
+	if checkpoint == nil {
+		logger.Info("No backfill checkpoint found, starting fresh")
+		return nil
+	}
+
+	h.windowBefore = checkpoint.CurrentPublishedTime
+	h.oldestSeen = checkpoint.PreviousPublishedTime
+	h.nextPageToken = checkpoint.NextPageToken
+	h.completed = checkpoint.Completed
+
+	logger.WithField("Before", h.windowBefore).
+		WithField("NextPageToken", h.nextPageToken).
+		WithField("Completed", h.completed).
+		Info("Resuming backfill from checkpoint")
+	return nil
+}
+
+// Saves the progress of the backfill
+func (h *BackfillHandler) SaveCheckpoint(ctx context.Context) error {
+	return h.checkpointHandler.SaveCheckpoint(ctx, &storage.Checkpoint{
+		QueryID:               h.CheckpointID(),
+		CurrentPublishedTime:  h.windowBefore,
+		PreviousPublishedTime: h.oldestSeen,
+		NextPageToken:         h.nextPageToken,
+		Completed:             h.completed,
+		UpdatedAt:             time.Now().UTC(),
+	})
+}
+
+// Executes - Fetches the next page of the window and publishes it to DB
+func (h *BackfillHandler) Execute(ctx context.Context) error {
+	logger := common.GetLogger().WithField("BackfillID", h.CheckpointID())
+
+	logger.WithField("Before", h.windowBefore).WithField("NextPageToken", h.nextPageToken).Info("Fetching Youtube Data for backfill")
+	results, err := h.youtubeHandler.DoSearchListBetween(ctx, h.query, []string{"snippet"}, "video", "date",
+		h.publishedAfter.Format(time.RFC3339), h.windowBefore.Format(time.RFC3339), h.nextPageToken, 50)
+	h.spend(youtube_handler.SearchListCost)
+	if err != nil {
+		return err
+	}
+
+	// The window only moves once the results are stored, so a failed execution is retried from the same place
+	err = h.storeSearchResults(ctx, h.videoMetadataHandler, h.channelHandler, h.suggestionHandler, results.Items, h.tag)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results.Items {
+		publishedAt, err := time.Parse(time.RFC3339, result.Snippet.PublishedAt)
+		if err != nil {
+			return err
+		}
+
+		if h.oldestSeen.IsZero() || publishedAt.Before(h.oldestSeen) {
+			h.oldestSeen = publishedAt
+		}
+	}
+
+	// 1. More pages in the window, fetch the next one
+	// 2. Pages ran out with videos older than the window, close the window at the oldest video and search again
+	// 3. Nothing older left, the backfill is done
+	if results.NextPageToken != "" {
+		h.nextPageToken = results.NextPageToken
+		return nil
+	}
+
+	h.nextPageToken = ""
+	if !h.oldestSeen.IsZero() && h.oldestSeen.Before(h.windowBefore) {
+		h.windowBefore = h.oldestSeen
+		return nil
+	}
+
+	h.completed = true
+	return nil
+}
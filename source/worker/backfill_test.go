@@ -0,0 +1,157 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/mock_youtube"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/api/youtube/v3"
+)
+
+type BackfillHandlerSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	mockVideoMetadataStore *mock_storage.MockVideoMetadataInterface
+	mockCheckpointStore    *mock_storage.MockCheckpointInterface
+	mockYoutubeHandler     *mock_youtube.MockYoutubeInterface
+
+	publishedAfter  time.Time
+	publishedBefore time.Time
+
+	backfillHandler *BackfillHandler
+}
+
+func TestBackfillHandlerSuite(t *testing.T) {
+	suite.Run(t, new(BackfillHandlerSuite))
+}
+
+func (s *BackfillHandlerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.mockVideoMetadataStore = mock_storage.NewMockVideoMetadataInterface(s.ctrl)
+	s.mockCheckpointStore = mock_storage.NewMockCheckpointInterface(s.ctrl)
+	s.mockYoutubeHandler = mock_youtube.NewMockYoutubeInterface(s.ctrl)
+
+	s.publishedAfter = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.publishedBefore = time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	s.backfillHandler = &BackfillHandler{
+		youtubeClient: youtubeClient{
+			youtubeHandler: s.mockYoutubeHandler,
+			apiKeyPool:     youtube_handler.NewAPIKeyPool([]string{"abcd"}, common.DefaultYoutubeDailyQuota),
+			apiKey:         "abcd",
+		},
+
+		query:           "query",
+		tag:             "backfill",
+		publishedAfter:  s.publishedAfter,
+		publishedBefore: s.publishedBefore,
+		windowBefore:    s.publishedBefore,
+
+		videoMetadataHandler: s.mockVideoMetadataStore,
+		checkpointHandler:    s.mockCheckpointStore,
+		suggestionHandler:    storage.NewMemorySuggestionImplWithStore(storage.NewMemoryStore()),
+	}
+}
+
+func (s *BackfillHandlerSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func searchResult(videoID string, publishedAt time.Time) *youtube.SearchResult {
+	return &youtube.SearchResult{
+		Id: &youtube.ResourceId{
+			VideoId: videoID,
+		},
+		Snippet: &youtube.SearchResultSnippet{
+			Title:       "test_title",
+			PublishedAt: publishedAt.Format(time.RFC3339),
+		},
+	}
+}
+
+func (s *BackfillHandlerSuite) expectSearch(before time.Time, pageToken string, response *youtube.SearchListResponse) {
+	s.mockYoutubeHandler.EXPECT().DoSearchListBetween(gomock.Any(), "query", []string{"snippet"}, "video", "date",
+		s.publishedAfter.Format(time.RFC3339), before.Format(time.RFC3339), pageToken, 50).Return(response, nil)
+}
+
+func (s *BackfillHandlerSuite) TestExecute_PagesThenNarrowsWindowThenCompletes() {
+	newest := s.publishedBefore.Add(-time.Hour)
+	older := s.publishedBefore.Add(-2 * time.Hour)
+	oldest := s.publishedBefore.Add(-3 * time.Hour)
+
+	s.mockVideoMetadataStore.EXPECT().BulkUpsertMetadata(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), gomock.Any(), enrichmentParts).Return(&youtube.VideoListResponse{}, nil).AnyTimes()
+
+	// 1. First page of the window
+	s.expectSearch(s.publishedBefore, "", &youtube.SearchListResponse{
+		Items:         []*youtube.SearchResult{searchResult("1", newest)},
+		NextPageToken: "ABCD",
+	})
+	s.NoError(s.backfillHandler.Execute(context.Background()))
+	s.Equal("ABCD", s.backfillHandler.nextPageToken)
+	s.False(s.backfillHandler.completed)
+
+	// 2. Last page of the window, the window is closed at the oldest video
+	s.expectSearch(s.publishedBefore, "ABCD", &youtube.SearchListResponse{
+		Items: []*youtube.SearchResult{searchResult("2", older)},
+	})
+	s.NoError(s.backfillHandler.Execute(context.Background()))
+	s.Equal("", s.backfillHandler.nextPageToken)
+	s.Equal(older, s.backfillHandler.windowBefore)
+	s.False(s.backfillHandler.completed)
+
+	// 3. The narrowed window still has an older video
+	s.expectSearch(older, "", &youtube.SearchListResponse{
+		Items: []*youtube.SearchResult{searchResult("3", oldest)},
+	})
+	s.NoError(s.backfillHandler.Execute(context.Background()))
+	s.Equal(oldest, s.backfillHandler.windowBefore)
+	s.False(s.backfillHandler.completed)
+
+	// 4. Nothing older is left
+	s.expectSearch(oldest, "", &youtube.SearchListResponse{})
+	s.NoError(s.backfillHandler.Execute(context.Background()))
+	s.True(s.backfillHandler.completed)
+}
+
+func (s *BackfillHandlerSuite) TestRestoreCheckpoint() {
+	checkpoint := &storage.Checkpoint{
+		QueryID:               s.backfillHandler.CheckpointID(),
+		CurrentPublishedTime:  s.publishedBefore.Add(-time.Hour),
+		PreviousPublishedTime: s.publishedBefore.Add(-time.Hour),
+		NextPageToken:         "ABCD",
+	}
+
+	s.mockCheckpointStore.EXPECT().ReadCheckpoint(gomock.Any(), "backfill:query:2022-01-01T00:00:00Z:2022-02-01T00:00:00Z").Return(checkpoint, nil)
+
+	s.NoError(s.backfillHandler.RestoreCheckpoint(context.Background()))
+
+	s.Equal(checkpoint.CurrentPublishedTime, s.backfillHandler.windowBefore)
+	s.Equal(checkpoint.PreviousPublishedTime, s.backfillHandler.oldestSeen)
+	s.Equal("ABCD", s.backfillHandler.nextPageToken)
+	s.False(s.backfillHandler.completed)
+}
+
+func (s *BackfillHandlerSuite) TestSaveCheckpoint_DoesNotTouchLiveCheckpoint() {
+	s.backfillHandler.completed = true
+
+	s.mockCheckpointStore.EXPECT().SaveCheckpoint(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, checkpoint *storage.Checkpoint) error {
+		s.Equal("backfill:query:2022-01-01T00:00:00Z:2022-02-01T00:00:00Z", checkpoint.QueryID)
+		s.True(checkpoint.Completed)
+		return nil
+	})
+
+	s.NoError(s.backfillHandler.SaveCheckpoint(context.Background()))
+}
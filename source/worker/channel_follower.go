@@ -0,0 +1,336 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Parts of playlistItems.list read for an upload
+var uploadParts = []string{"snippet", "contentDetails"}
+
+// Most pages of uploads read in one poll of a channel, bounds the catch up after a long downtime
+const maxUploadPages = 5
+
+/*
+ChannelFollower collects every video the followed channels upload, whether or not
+it matches a query.
+
+How does it work?
+
+Every channel has an uploads playlist holding its videos most recent first. Its
+id is looked up once with channels.list, then every poll interval we read the
+playlist with playlistItems.list from the most recent upload until we reach one
+we already have. A call costs 1 unit of quota, against 100 for search.list.
+
+The uploads are stored the same way as search results - enriched, deduplicated
+on video_id and tagged with the follower tag - so a video found by both a query
+and a followed channel carries both tags.
+
+Every channel has its own checkpoint, its CurrentPublishedTime is the publish
+time of the most recent upload stored. Without a checkpoint a channel starts
+from now, like the live worker.
+*/
+type ChannelFollower struct {
+	youtubeClient
+
+	tag string
+
+	pollInterval time.Duration
+	sleepTime    time.Duration
+	backoff      backoff
+
+	channels []*followedChannel
+
+	videoMetadataHandler storage.VideoMetadataInterface
+	checkpointHandler    storage.CheckpointInterface
+	channelHandler       storage.ChannelInterface
+	suggestionHandler    storage.SuggestionInterface
+
+	*lifecycle
+}
+
+type followedChannel struct {
+	channelID         string
+	uploadsPlaylistID string
+
+	// Publish time of the most recent upload stored
+	lastPublishedTime time.Time
+}
+
+func NewChannelFollower(ctx context.Context, channelIDs []string, tag string, pollInterval time.Duration, apiKeyPool *youtube_handler.APIKeyPool, maxBackoff time.Duration) (*ChannelFollower, error) {
+	channelFollower := &ChannelFollower{
+		youtubeClient:        newYoutubeClient(apiKeyPool),
+		tag:                  tag,
+		pollInterval:         pollInterval,
+		sleepTime:            pollInterval,
+		backoff:              newBackoff(maxBackoff),
+		videoMetadataHandler: storage.NewVideoMetadataHandler(),
+		checkpointHandler:    storage.NewCheckpointHandler(),
+		channelHandler:       storage.NewChannelHandler(),
+		suggestionHandler:    storage.NewSuggestionHandler(),
+		lifecycle:            newLifecycle(),
+	}
+
+	now := time.Now().UTC()
+	for _, channelID := range channelIDs {
+		channelFollower.channels = append(channelFollower.channels, &followedChannel{
+			channelID:         channelID,
+			lastPublishedTime: now,
+		})
+	}
+
+	err := channelFollower.RestoreCheckpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return channelFollower, nil
+}
+
+// ID of the checkpoint of a followed channel
+func channelCheckpointID(channelID string) string {
+	return "channel:" + channelID
+}
+
+// Polls the uploads of the channels until it is stopped, calls made are cancelled with ctx
+func (f *ChannelFollower) Start(ctx context.Context) {
+	defer f.finish()
+
+	logger := common.GetLogger().WithField("Tag", f.tag)
+
+	for {
+		f.sleepTime = f.pollInterval
+
+		err := f.syncAPIKey()
+		if err == nil {
+			err = f.Execute(ctx)
+		}
+
+		if ctx.Err() != nil {
+			logger.Info("Channel follower aborted")
+			return
+		}
+
+		var keysExhausted *youtube_handler.KeysExhaustedError
+		switch classifyError(err) {
+		case errorClassNone:
+			f.backoff.Reset()
+		case errorClassQuota:
+			if errors.As(err, &keysExhausted) {
+				logger.WithField("Until", keysExhausted.Until).Warn("Every API Key is out of quota, waiting for the reset")
+				f.sleepTime = time.Until(keysExhausted.Until)
+			} else {
+				logger.Info("API Key Quota Exceeded")
+				f.parkAPIKey()
+				f.sleepTime = 2 * time.Second
+			}
+		case errorClassRetryable:
+			f.sleepTime = f.backoff.Next()
+			logger.WithError(err).WithField("RetryIn", f.sleepTime).Warn("Error in channel follower, retrying")
+		default:
+			logger.WithError(err).Error("Fatal error in channel follower, exiting channel follower")
+			return
+		}
+
+		if !f.sleep(ctx, f.sleepTime) {
+			logger.Info("Channel follower stopped")
+			return
+		}
+	}
+}
+
+// Restores the position of every channel from its checkpoint, if there is one
+func (f *ChannelFollower) RestoreCheckpoints(ctx context.Context) error {
+	logger := common.GetLogger()
+
+	for _, channel := range f.channels {
+		checkpoint, err := f.checkpointHandler.ReadCheckpoint(ctx, channelCheckpointID(channel.channelID))
+		if err != nil {
+			return err
+		}
+
+		if checkpoint == nil {
+			logger.WithField("ChannelID", channel.channelID).Info("No channel checkpoint found, starting from now")
+			continue
+		}
+
+		channel.lastPublishedTime = checkpoint.CurrentPublishedTime
+		logger.WithField("ChannelID", channel.channelID).WithField("From", channel.lastPublishedTime).Info("Resuming channel from checkpoint")
+	}
+
+	return nil
+}
+
+// Executes - Stores the new uploads of every followed channel
+func (f *ChannelFollower) Execute(ctx context.Context) error {
+	err := f.resolveUploadsPlaylists(ctx)
+	if err != nil {
+		return err
+	}
+
+	// A channel is saved as soon as it is polled, so a failure only repeats the channels after it
+	for _, channel := range f.channels {
+		err = f.pollChannel(ctx, channel)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Looks up the uploads playlist of the channels that do not have one yet,
+// MaxChannelsListIDs channels per call. Unknown channels are no longer followed.
+func (f *ChannelFollower) resolveUploadsPlaylists(ctx context.Context) error {
+	logger := common.GetLogger()
+
+	ids := []string{}
+	for _, channel := range f.channels {
+		if channel.uploadsPlaylistID == "" {
+			ids = append(ids, channel.channelID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	uploadsPlaylistIDs := map[string]string{}
+	for start := 0; start < len(ids); start += youtube_handler.MaxChannelsListIDs {
+		end := start + youtube_handler.MaxChannelsListIDs
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		response, err := f.youtubeHandler.DoChannelsList(ctx, ids[start:end], []string{"contentDetails"})
+		f.spend(youtube_handler.ChannelsListCost)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range response.Items {
+			if item.ContentDetails != nil && item.ContentDetails.RelatedPlaylists != nil {
+				uploadsPlaylistIDs[item.Id] = item.ContentDetails.RelatedPlaylists.Uploads
+			}
+		}
+	}
+
+	followed := []*followedChannel{}
+	for _, channel := range f.channels {
+		if channel.uploadsPlaylistID == "" {
+			channel.uploadsPlaylistID = uploadsPlaylistIDs[channel.channelID]
+		}
+
+		if channel.uploadsPlaylistID == "" {
+			logger.WithField("ChannelID", channel.channelID).Warn("Channel has no uploads playlist, no longer following it")
+			continue
+		}
+		followed = append(followed, channel)
+	}
+	f.channels = followed
+
+	return nil
+}
+
+// Reads the uploads of a channel published after its position, stores them and saves the position
+func (f *ChannelFollower) pollChannel(ctx context.Context, channel *followedChannel) error {
+	logger := common.GetLogger().WithField("ChannelID", channel.channelID)
+
+	newest := channel.lastPublishedTime
+	videoMetadataList := []*storage.VideoMetadata{}
+
+	pageToken := ""
+	for page := 0; page < maxUploadPages; page++ {
+		response, err := f.youtubeHandler.DoPlaylistItemsList(ctx, channel.uploadsPlaylistID, uploadParts, pageToken, 50)
+		f.spend(youtube_handler.PlaylistItemsListCost)
+		if err != nil {
+			return err
+		}
+
+		reachedPosition := false
+		for _, item := range response.Items {
+			videoMetadata, err := newVideoMetadataFromPlaylistItem(item)
+			if err != nil {
+				return err
+			}
+
+			// Private and deleted videos stay in the playlist without a publish time
+			if videoMetadata == nil {
+				continue
+			}
+
+			if !videoMetadata.PublishedAt.After(channel.lastPublishedTime) {
+				reachedPosition = true
+				continue
+			}
+
+			videoMetadataList = append(videoMetadataList, videoMetadata)
+			if videoMetadata.PublishedAt.After(newest) {
+				newest = videoMetadata.PublishedAt
+			}
+		}
+
+		if reachedPosition || response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	if len(videoMetadataList) == 0 {
+		return nil
+	}
+
+	logger.WithField("UploadCount", len(videoMetadataList)).Info("Storing channel uploads")
+
+	// The position only moves once the uploads are stored, so a failed poll is retried from the same place
+	err := f.storeVideoMetadata(ctx, f.videoMetadataHandler, f.channelHandler, f.suggestionHandler, videoMetadataList, f.tag)
+	if err != nil {
+		return err
+	}
+
+	channel.lastPublishedTime = newest
+
+	return f.checkpointHandler.SaveCheckpoint(ctx, &storage.Checkpoint{
+		QueryID:              channelCheckpointID(channel.channelID),
+		CurrentPublishedTime: channel.lastPublishedTime,
+		UpdatedAt:            time.Now().UTC(),
+	})
+}
+
+// Takes an upload from playlistItems.list and populates in our structure format,
+// returns nil for an upload that is not public
+func newVideoMetadataFromPlaylistItem(item *youtube.PlaylistItem) (*storage.VideoMetadata, error) {
+	if item.Snippet == nil || item.ContentDetails == nil || item.ContentDetails.VideoPublishedAt == "" {
+		return nil, nil
+	}
+
+	publishedAtTime, err := time.Parse(time.RFC3339, item.ContentDetails.VideoPublishedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	videoData := &storage.VideoMetadata{
+		VideoID:      item.ContentDetails.VideoId,
+		Title:        item.Snippet.Title,
+		Description:  item.Snippet.Description,
+		PublishedAt:  publishedAtTime,
+		ChannelID:    item.Snippet.VideoOwnerChannelId,
+		ChannelTitle: item.Snippet.VideoOwnerChannelTitle,
+	}
+
+	// The owner of an uploads playlist is the channel that uploaded the video
+	if videoData.ChannelID == "" {
+		videoData.ChannelID = item.Snippet.ChannelId
+		videoData.ChannelTitle = item.Snippet.ChannelTitle
+	}
+
+	applyThumbnails(videoData, item.Snippet.Thumbnails)
+
+	return videoData, nil
+}
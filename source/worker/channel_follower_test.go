@@ -0,0 +1,190 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/fakeyoutube"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Runs the channel follower with the real YoutubeHandler against the fake API and the memory store
+type ChannelFollowerSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	ctx  context.Context
+	now  time.Time
+	fake *fakeyoutube.Server
+
+	videoMetadataStore *storage.MemoryVideoMetadataImpl
+	checkpointStore    *storage.MemoryCheckpointImpl
+	channelFollower    *ChannelFollower
+}
+
+func TestChannelFollowerSuite(t *testing.T) {
+	suite.Run(t, new(ChannelFollowerSuite))
+}
+
+func (s *ChannelFollowerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.ctx = context.Background()
+	s.now = time.Now().UTC().Truncate(time.Second)
+	s.fake = fakeyoutube.NewServer()
+	s.fake.AddChannels(fakeyoutube.Channel{ID: "UCcricket", Title: "Cricket TV"})
+
+	store := storage.NewMemoryStore()
+	s.videoMetadataStore = storage.NewMemoryVideoMetadataImplWithStore(store)
+	s.checkpointStore = storage.NewMemoryCheckpointImplWithStore(store)
+
+	s.channelFollower = &ChannelFollower{
+		youtubeClient: youtubeClient{
+			apiKeyPool:     youtube_handler.NewAPIKeyPool([]string{"abcd"}, common.DefaultYoutubeDailyQuota),
+			apiKey:         "abcd",
+			youtubeHandler: youtube_handler.NewYoutubeHandlerWithEndpoint("abcd", s.fake.Endpoint()),
+		},
+
+		tag:          "channels",
+		pollInterval: time.Minute,
+		channels: []*followedChannel{
+			{channelID: "UCcricket", lastPublishedTime: s.now},
+		},
+
+		videoMetadataHandler: s.videoMetadataStore,
+		checkpointHandler:    s.checkpointStore,
+		channelHandler:       storage.NewMemoryChannelImplWithStore(store),
+		suggestionHandler:    storage.NewMemorySuggestionImplWithStore(store),
+	}
+}
+
+func (s *ChannelFollowerSuite) TearDownTest() {
+	s.fake.Close()
+}
+
+// Uploads count videos to the channel after the position of the follower, the newest last
+func (s *ChannelFollowerSuite) upload(from int, count int) {
+	for i := from; i < from+count; i++ {
+		s.fake.AddVideos(fakeyoutube.Video{
+			ID:           fmt.Sprintf("video%d", i),
+			Title:        fmt.Sprintf("Match day %d", i),
+			ChannelID:    "UCcricket",
+			ChannelTitle: "Cricket TV",
+			PublishedAt:  s.now.Add(time.Duration(i) * time.Second),
+		})
+	}
+}
+
+func (s *ChannelFollowerSuite) storedIDs() []string {
+	videos, err := s.videoMetadataStore.FetchPagedMetadata(s.ctx, s.now.Add(time.Hour), 0, 1000)
+	s.NoError(err)
+
+	ids := []string{}
+	for _, video := range videos {
+		ids = append(ids, video.VideoID)
+	}
+	return ids
+}
+
+func (s *ChannelFollowerSuite) TestExecute() {
+	// Published before the follower started, not collected
+	s.fake.AddVideos(fakeyoutube.Video{ID: "old", Title: "Old match", ChannelID: "UCcricket", PublishedAt: s.now.Add(-time.Hour)})
+	s.upload(1, 3)
+
+	s.NoError(s.channelFollower.Execute(s.ctx))
+	s.Equal([]string{"video3", "video2", "video1"}, s.storedIDs())
+	s.Equal("UUcricket", s.channelFollower.channels[0].uploadsPlaylistID)
+	s.Equal(s.now.Add(3*time.Second), s.channelFollower.channels[0].lastPublishedTime)
+
+	video, err := s.videoMetadataStore.FindOneMetadataWithVideoID(s.ctx, "video1")
+	s.NoError(err)
+	s.Equal([]string{"channels"}, video.QueryTags)
+	s.Equal("UCcricket", video.ChannelID)
+	s.Equal("Cricket TV", video.ChannelTitle)
+	s.False(video.EnrichedAt.IsZero())
+
+	checkpoint, err := s.checkpointStore.ReadCheckpoint(s.ctx, channelCheckpointID("UCcricket"))
+	s.NoError(err)
+	s.Equal(s.now.Add(3*time.Second), checkpoint.CurrentPublishedTime)
+
+	// The uploads playlist is only looked up once, and the next poll only stores the new upload
+	s.upload(4, 1)
+	requestCount := len(s.fake.Requests())
+
+	s.NoError(s.channelFollower.Execute(s.ctx))
+	s.Equal([]string{"video4", "video3", "video2", "video1"}, s.storedIDs())
+
+	requests := s.fake.Requests()[requestCount:]
+	s.Equal("/youtube/v3/playlistItems", requests[0].Path)
+	s.Equal("UUcricket", requests[0].Query.Get("playlistId"))
+}
+
+func (s *ChannelFollowerSuite) TestExecute_Pages() {
+	s.upload(1, 60)
+
+	s.NoError(s.channelFollower.Execute(s.ctx))
+	s.Len(s.storedIDs(), 60)
+	s.Equal(s.now.Add(60*time.Second), s.channelFollower.channels[0].lastPublishedTime)
+}
+
+func (s *ChannelFollowerSuite) TestExecute_DeduplicatesSearchResults() {
+	s.upload(1, 1)
+	s.NoError(s.videoMetadataStore.BulkInsertMetadata(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "video1", Title: "Match day 1", ChannelID: "UCcricket", PublishedAt: s.now.Add(time.Second), QueryTags: []string{"sports"}},
+	}))
+
+	s.NoError(s.channelFollower.Execute(s.ctx))
+	s.Equal([]string{"video1"}, s.storedIDs())
+
+	video, err := s.videoMetadataStore.FindOneMetadataWithVideoID(s.ctx, "video1")
+	s.NoError(err)
+	s.Equal([]string{"sports", "channels"}, video.QueryTags)
+}
+
+func (s *ChannelFollowerSuite) TestExecute_UnknownChannel() {
+	s.channelFollower.channels = append(s.channelFollower.channels, &followedChannel{channelID: "UCunknown", lastPublishedTime: s.now})
+	s.upload(1, 1)
+
+	s.NoError(s.channelFollower.Execute(s.ctx))
+	s.Len(s.channelFollower.channels, 1)
+	s.Equal([]string{"video1"}, s.storedIDs())
+}
+
+func (s *ChannelFollowerSuite) TestExecute_Failure() {
+	s.upload(1, 2)
+	s.fake.FailNext(fakeyoutube.BackendError())
+
+	err := s.channelFollower.Execute(s.ctx)
+	s.Equal(errorClassRetryable, classifyError(err))
+	s.Empty(s.storedIDs())
+	s.Equal(s.now, s.channelFollower.channels[0].lastPublishedTime)
+
+	s.NoError(s.channelFollower.Execute(s.ctx))
+	s.Len(s.storedIDs(), 2)
+}
+
+func (s *ChannelFollowerSuite) TestRestoreCheckpoints() {
+	s.NoError(s.checkpointStore.SaveCheckpoint(s.ctx, &storage.Checkpoint{
+		QueryID:              channelCheckpointID("UCcricket"),
+		CurrentPublishedTime: s.now.Add(-time.Hour),
+	}))
+
+	s.NoError(s.channelFollower.RestoreCheckpoints(s.ctx))
+	s.Equal(s.now.Add(-time.Hour), s.channelFollower.channels[0].lastPublishedTime)
+}
+
+func (s *ChannelFollowerSuite) TestNewVideoMetadataFromPlaylistItem_Private() {
+	videoMetadata, err := newVideoMetadataFromPlaylistItem(&youtube.PlaylistItem{
+		Snippet:        &youtube.PlaylistItemSnippet{Title: "Private video"},
+		ContentDetails: &youtube.PlaylistItemContentDetails{VideoId: "private"},
+	})
+	s.NoError(err)
+	s.Nil(videoMetadata)
+}
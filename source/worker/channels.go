@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Parts of channels.list read for a channel
+var channelParts = []string{"snippet", "statistics"}
+
+// How long the details of a stored channel are used before channels.list is called again
+const channelRefreshAge = 24 * time.Hour
+
+// Stores the channels of videoMetadataList with the details of channels.list,
+// MaxChannelsListIDs channels per call. Channels stored within channelRefreshAge
+// are skipped, so a channel is fetched at most once a day however often it uploads.
+func (c *youtubeClient) syncChannels(ctx context.Context, channelHandler storage.ChannelInterface, videoMetadataList []*storage.VideoMetadata) error {
+	now := time.Now().UTC()
+
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, videoMetadata := range videoMetadataList {
+		if videoMetadata.ChannelID == "" || seen[videoMetadata.ChannelID] {
+			continue
+		}
+		seen[videoMetadata.ChannelID] = true
+
+		channel, err := channelHandler.ReadChannel(ctx, videoMetadata.ChannelID)
+		if err != nil {
+			return err
+		}
+
+		if channel == nil || now.Sub(channel.UpdatedAt) >= channelRefreshAge {
+			ids = append(ids, videoMetadata.ChannelID)
+		}
+	}
+
+	for start := 0; start < len(ids); start += youtube_handler.MaxChannelsListIDs {
+		end := start + youtube_handler.MaxChannelsListIDs
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		response, err := c.youtubeHandler.DoChannelsList(ctx, ids[start:end], channelParts)
+		c.spend(youtube_handler.ChannelsListCost)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range response.Items {
+			err = channelHandler.UpsertChannel(ctx, newChannel(item, now))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(ids) > 0 {
+		common.GetLogger().WithField("ChannelCount", len(ids)).Info("Synced channels")
+	}
+
+	return nil
+}
+
+// Takes a channel from channels.list and populates in our structure format
+func newChannel(item *youtube.Channel, updatedAt time.Time) *storage.Channel {
+	channel := &storage.Channel{
+		ChannelID: item.Id,
+		UpdatedAt: updatedAt,
+	}
+
+	if item.Snippet != nil {
+		channel.Title = item.Snippet.Title
+		channel.Description = item.Snippet.Description
+		channel.Country = item.Snippet.Country
+
+		if item.Snippet.Thumbnails != nil {
+			if item.Snippet.Thumbnails.Default != nil {
+				channel.DefaultThumbnailURL = item.Snippet.Thumbnails.Default.Url
+			}
+			if item.Snippet.Thumbnails.Medium != nil {
+				channel.MediumThumbnailURL = item.Snippet.Thumbnails.Medium.Url
+			}
+			if item.Snippet.Thumbnails.High != nil {
+				channel.HighThumbnailURL = item.Snippet.Thumbnails.High.Url
+			}
+		}
+	}
+
+	// Hidden subscriber counts are reported as zero
+	if item.Statistics != nil {
+		channel.SubscriberCount = int64(item.Statistics.SubscriberCount)
+	}
+
+	return channel
+}
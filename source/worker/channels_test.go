@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/mock_youtube"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/api/youtube/v3"
+)
+
+type ChannelsSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	mockChannelStore   *mock_storage.MockChannelInterface
+	mockYoutubeHandler *mock_youtube.MockYoutubeInterface
+	client             *youtubeClient
+}
+
+func TestChannelsSuite(t *testing.T) {
+	suite.Run(t, new(ChannelsSuite))
+}
+
+func (s *ChannelsSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.mockChannelStore = mock_storage.NewMockChannelInterface(s.ctrl)
+	s.mockYoutubeHandler = mock_youtube.NewMockYoutubeInterface(s.ctrl)
+	s.client = &youtubeClient{
+		youtubeHandler: s.mockYoutubeHandler,
+		apiKeyPool:     youtube_handler.NewAPIKeyPool([]string{"abcd"}, common.DefaultYoutubeDailyQuota),
+		apiKey:         "abcd",
+	}
+}
+
+func (s *ChannelsSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *ChannelsSuite) TestSyncChannels() {
+	videoMetadataList := []*storage.VideoMetadata{
+		{VideoID: "1", ChannelID: "new"},
+		{VideoID: "2", ChannelID: "stale"},
+		{VideoID: "3", ChannelID: "fresh"},
+		{VideoID: "4", ChannelID: "new"},
+		{VideoID: "5"},
+	}
+
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "new").Return(nil, nil)
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "stale").Return(&storage.Channel{ChannelID: "stale", UpdatedAt: time.Now().Add(-2 * channelRefreshAge)}, nil)
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "fresh").Return(&storage.Channel{ChannelID: "fresh", UpdatedAt: time.Now()}, nil)
+
+	s.mockYoutubeHandler.EXPECT().DoChannelsList(gomock.Any(), []string{"new", "stale"}, channelParts).Return(&youtube.ChannelListResponse{
+		Items: []*youtube.Channel{
+			{
+				Id: "new",
+				Snippet: &youtube.ChannelSnippet{
+					Title:   "New",
+					Country: "IN",
+					Thumbnails: &youtube.ThumbnailDetails{
+						Default: &youtube.Thumbnail{Url: "default"},
+						High:    &youtube.Thumbnail{Url: "high"},
+					},
+				},
+				Statistics: &youtube.ChannelStatistics{SubscriberCount: 1000},
+			},
+			{Id: "stale", Snippet: &youtube.ChannelSnippet{Title: "Stale"}},
+		},
+	}, nil)
+
+	upserted := map[string]*storage.Channel{}
+	s.mockChannelStore.EXPECT().UpsertChannel(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, channel *storage.Channel) error {
+			upserted[channel.ChannelID] = channel
+			return nil
+		}).Times(2)
+
+	s.NoError(s.client.syncChannels(context.Background(), s.mockChannelStore, videoMetadataList))
+
+	s.Equal("New", upserted["new"].Title)
+	s.Equal("IN", upserted["new"].Country)
+	s.Equal(int64(1000), upserted["new"].SubscriberCount)
+	s.Equal("default", upserted["new"].DefaultThumbnailURL)
+	s.Equal("high", upserted["new"].HighThumbnailURL)
+	s.False(upserted["new"].UpdatedAt.IsZero())
+	s.Equal("Stale", upserted["stale"].Title)
+
+	s.Equal(int64(youtube_handler.ChannelsListCost), s.client.apiKeyPool.Snapshot().Keys[0].UnitsUsed)
+}
+
+func (s *ChannelsSuite) TestSyncChannels_AllFresh() {
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "fresh").Return(&storage.Channel{ChannelID: "fresh", UpdatedAt: time.Now()}, nil)
+
+	s.NoError(s.client.syncChannels(context.Background(), s.mockChannelStore, []*storage.VideoMetadata{{VideoID: "1", ChannelID: "fresh"}}))
+}
+
+func (s *ChannelsSuite) TestSyncChannels_Error() {
+	s.mockChannelStore.EXPECT().ReadChannel(gomock.Any(), "new").Return(nil, nil)
+	s.mockYoutubeHandler.EXPECT().DoChannelsList(gomock.Any(), []string{"new"}, channelParts).Return(nil, errors.New("error"))
+
+	s.Error(s.client.syncChannels(context.Background(), s.mockChannelStore, []*storage.VideoMetadata{{VideoID: "1", ChannelID: "new"}}))
+}
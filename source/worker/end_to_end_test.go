@@ -0,0 +1,234 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/fakeyoutube"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// Runs the worker with the real YoutubeHandler against the fake API and the memory store
+type EndToEndSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	ctx  context.Context
+	now  time.Time
+	fake *fakeyoutube.Server
+
+	videoMetadataStore *storage.MemoryVideoMetadataImpl
+	channelStore       *storage.MemoryChannelImpl
+	suggestionStore    *storage.MemorySuggestionImpl
+	workerHandler      *WorkerHandler
+}
+
+func TestEndToEndSuite(t *testing.T) {
+	suite.Run(t, new(EndToEndSuite))
+}
+
+func (s *EndToEndSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.ctx = context.Background()
+	s.now = time.Now().UTC().Truncate(time.Second)
+	s.fake = fakeyoutube.NewServer()
+
+	store := storage.NewMemoryStore()
+	s.videoMetadataStore = storage.NewMemoryVideoMetadataImplWithStore(store)
+	s.channelStore = storage.NewMemoryChannelImplWithStore(store)
+	s.suggestionStore = storage.NewMemorySuggestionImplWithStore(store)
+
+	s.workerHandler = &WorkerHandler{
+		youtubeClient: youtubeClient{
+			apiKeyPool:     youtube_handler.NewAPIKeyPool([]string{"abcd", "edfg"}, common.DefaultYoutubeDailyQuota),
+			apiKey:         "abcd",
+			youtubeHandler: youtube_handler.NewYoutubeHandlerWithEndpoint("abcd", s.fake.Endpoint()),
+		},
+
+		name:         "cricket",
+		tag:          "sports",
+		pollInterval: 10 * time.Second,
+		cursor:       Cursor{PublishedTime: s.now},
+
+		videoMetadataHandler: s.videoMetadataStore,
+		checkpointHandler:    storage.NewMemoryCheckpointImplWithStore(store),
+		channelHandler:       s.channelStore,
+		suggestionHandler:    s.suggestionStore,
+	}
+	s.workerHandler.source = &searchSource{client: &s.workerHandler.youtubeClient, query: "cricket"}
+}
+
+func (s *EndToEndSuite) TearDownTest() {
+	s.fake.Close()
+}
+
+// Publishes count videos after the position of the worker, the newest last
+func (s *EndToEndSuite) publish(count int) {
+	for i := 1; i <= count; i++ {
+		s.fake.AddVideos(fakeyoutube.Video{
+			ID:          fmt.Sprintf("video%d", i),
+			Title:       fmt.Sprintf("Cricket highlights %d", i),
+			PublishedAt: s.now.Add(time.Duration(i) * time.Second),
+			ViewCount:   uint64(i * 100),
+			Duration:    time.Duration(i) * time.Minute,
+		})
+	}
+}
+
+func (s *EndToEndSuite) storedCount() int {
+	videos, err := s.videoMetadataStore.FetchPagedMetadata(s.ctx, s.now.Add(time.Hour), 0, 1000)
+	s.NoError(err)
+	return len(videos)
+}
+
+func (s *EndToEndSuite) TestExecute_Pages() {
+	s.publish(60)
+
+	s.NoError(s.workerHandler.Execute(s.ctx))
+	s.Equal(50, s.storedCount())
+	s.NotEmpty(s.workerHandler.cursor.PageToken)
+	s.Equal(s.now.Add(60*time.Second), s.workerHandler.cursor.PublishedTime)
+	s.Equal(s.now, s.workerHandler.cursor.PreviousPublishedTime)
+
+	// The next page is requested from the previous position
+	s.NoError(s.workerHandler.Execute(s.ctx))
+	s.Equal(60, s.storedCount())
+	s.Empty(s.workerHandler.cursor.PageToken)
+
+	// Every page of search results is enriched with one videos.list call
+	requests := s.fake.Requests()
+	s.Len(requests, 4)
+	s.Equal("/youtube/v3/videos", requests[1].Path)
+	s.Equal(s.now.Format(time.RFC3339), requests[2].Query.Get("publishedAfter"))
+	s.NotEmpty(requests[2].Query.Get("pageToken"))
+
+	// Nothing new, the latest video is found again and the store is unchanged
+	s.NoError(s.workerHandler.Execute(s.ctx))
+	s.Equal(60, s.storedCount())
+
+	video, err := s.videoMetadataStore.FindOneMetadataWithVideoID(s.ctx, "video60")
+	s.NoError(err)
+	s.Equal([]string{"sports"}, video.QueryTags)
+	s.Equal(int64(6000), video.ViewCount)
+	s.Equal(time.Hour, video.Duration)
+	s.False(video.EnrichedAt.IsZero())
+
+	// The titles of every stored video are suggested, the most recent first
+	suggestions, err := s.suggestionStore.SuggestTitles(s.ctx, "criket highlights 6", 10)
+	s.NoError(err)
+	s.Len(suggestions, 2)
+	s.Equal("Cricket highlights 60", suggestions[0].Title)
+	s.Equal("Cricket highlights 6", suggestions[1].Title)
+}
+
+func (s *EndToEndSuite) TestExecute_Channels() {
+	s.fake.AddChannels(fakeyoutube.Channel{ID: "channel", Title: "Cricket TV", Country: "IN", SubscriberCount: 5000})
+	for i := 1; i <= 2; i++ {
+		s.fake.AddVideos(fakeyoutube.Video{
+			ID:           fmt.Sprintf("video%d", i),
+			Title:        fmt.Sprintf("Cricket highlights %d", i),
+			ChannelID:    "channel",
+			ChannelTitle: "Cricket TV",
+			PublishedAt:  s.now.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	s.NoError(s.workerHandler.Execute(s.ctx))
+
+	channel, err := s.channelStore.ReadChannel(s.ctx, "channel")
+	s.NoError(err)
+	s.NotNil(channel)
+	s.Equal("Cricket TV", channel.Title)
+	s.Equal("IN", channel.Country)
+	s.Equal(int64(5000), channel.SubscriberCount)
+
+	video, err := s.videoMetadataStore.FindOneMetadataWithVideoID(s.ctx, "video1")
+	s.NoError(err)
+	s.Equal("channel", video.ChannelID)
+	s.Equal("Cricket TV", video.ChannelTitle)
+
+	// One channels.list call for both videos
+	requests := s.fake.Requests()
+	s.Len(requests, 3)
+	s.Equal("/youtube/v3/channels", requests[2].Path)
+
+	// The stored channel is fresh, it is not fetched again
+	s.fake.AddVideos(fakeyoutube.Video{ID: "video3", Title: "Cricket highlights 3", ChannelID: "channel", PublishedAt: s.now.Add(3 * time.Second)})
+	s.NoError(s.workerHandler.Execute(s.ctx))
+	s.Len(s.fake.Requests(), 5)
+}
+
+func (s *EndToEndSuite) TestExecute_Failures() {
+	s.publish(3)
+
+	s.fake.FailNext(fakeyoutube.QuotaExceeded(), fakeyoutube.BackendError())
+
+	err := s.workerHandler.Execute(s.ctx)
+	s.Equal(errorClassQuota, classifyError(err))
+
+	err = s.workerHandler.Execute(s.ctx)
+	s.Equal(errorClassRetryable, classifyError(err))
+
+	// Nothing was stored and the position did not move, so the retry collects everything
+	s.Equal(0, s.storedCount())
+	s.Equal(s.now, s.workerHandler.cursor.PublishedTime)
+
+	s.NoError(s.workerHandler.Execute(s.ctx))
+	s.Equal(3, s.storedCount())
+}
+
+func (s *EndToEndSuite) TestExecute_MixedSources() {
+	s.publish(2)
+
+	directory := s.T().TempDir()
+	writeDropFile(s.T(), directory, "catalog.json", fmt.Sprintf(`[
+		{"video_id": "video2", "title": "Cricket highlights 2", "published_at": %q},
+		{"video_id": "catalog1", "title": "Stadium tour", "description": "Behind the scenes", "published_at": %q}
+	]`, s.now.Add(2*time.Second).Format(time.RFC3339), s.now.Add(-time.Hour).Format(time.RFC3339)))
+
+	catalogWorker := &WorkerHandler{
+		youtubeClient:        s.workerHandler.youtubeClient,
+		name:                 "catalog",
+		tag:                  "catalog",
+		pollInterval:         time.Minute,
+		source:               &fileSource{directory: directory},
+		videoMetadataHandler: s.videoMetadataStore,
+		checkpointHandler:    s.workerHandler.checkpointHandler,
+		channelHandler:       s.channelStore,
+		suggestionHandler:    s.suggestionStore,
+	}
+
+	s.NoError(s.workerHandler.Execute(s.ctx))
+	s.NoError(catalogWorker.Execute(s.ctx))
+	s.Equal(3, s.storedCount())
+	s.Equal("catalog.json", catalogWorker.cursor.PageToken)
+	s.Equal(time.Minute, catalogWorker.sleepTime)
+
+	// A video found by both sources is stored once with both tags
+	video, err := s.videoMetadataStore.FindOneMetadataWithVideoID(s.ctx, "video2")
+	s.NoError(err)
+	s.Equal([]string{"sports", "catalog"}, video.QueryTags)
+
+	video, err = s.videoMetadataStore.FindOneMetadataWithVideoID(s.ctx, "catalog1")
+	s.NoError(err)
+	s.Equal([]string{"catalog"}, video.QueryTags)
+	s.Equal("Behind the scenes", video.Description)
+
+	suggestions, err := s.suggestionStore.SuggestTitles(s.ctx, "stadium t", 10)
+	s.NoError(err)
+	s.Len(suggestions, 1)
+	s.Equal("catalog1", suggestions[0].VideoID)
+
+	// The cursor of every source is saved in the checkpoint of its query
+	s.NoError(catalogWorker.SaveCheckpoint(s.ctx))
+	checkpoint, err := s.workerHandler.checkpointHandler.ReadCheckpoint(s.ctx, "catalog")
+	s.NoError(err)
+	s.Equal("catalog.json", checkpoint.NextPageToken)
+}
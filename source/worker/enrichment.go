@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Parts of videos.list read by the enrichment stage, the cost of a call does not depend on them
+var enrichmentParts = []string{"snippet", "contentDetails", "statistics"}
+
+// Fills in the details of videos.list - statistics, duration, tags, category and
+// language - on videoMetadataList, MaxVideosListIDs videos per call. Videos that
+// videos.list leaves out, e.g. deleted since the search, are stored without them.
+func (c *youtubeClient) enrich(ctx context.Context, videoMetadataList []*storage.VideoMetadata) error {
+	for start := 0; start < len(videoMetadataList); start += youtube_handler.MaxVideosListIDs {
+		end := start + youtube_handler.MaxVideosListIDs
+		if end > len(videoMetadataList) {
+			end = len(videoMetadataList)
+		}
+
+		batch := map[string]*storage.VideoMetadata{}
+		ids := []string{}
+		for _, videoMetadata := range videoMetadataList[start:end] {
+			batch[videoMetadata.VideoID] = videoMetadata
+			ids = append(ids, videoMetadata.VideoID)
+		}
+
+		response, err := c.youtubeHandler.DoVideosList(ctx, ids, enrichmentParts)
+		c.spend(youtube_handler.VideosListCost)
+		if err != nil {
+			return err
+		}
+
+		enrichedAt := time.Now().UTC()
+		for _, video := range response.Items {
+			videoMetadata, ok := batch[video.Id]
+			if !ok {
+				continue
+			}
+			applyVideoDetails(videoMetadata, video, enrichedAt)
+		}
+	}
+
+	return nil
+}
+
+func applyVideoDetails(videoMetadata *storage.VideoMetadata, video *youtube.Video, enrichedAt time.Time) {
+	if video.Snippet != nil {
+		if video.Snippet.ChannelId != "" {
+			videoMetadata.ChannelID = video.Snippet.ChannelId
+		}
+		if video.Snippet.ChannelTitle != "" {
+			videoMetadata.ChannelTitle = video.Snippet.ChannelTitle
+		}
+		// Push notifications only carry the title, the rest of the snippet comes from here
+		if videoMetadata.Description == "" {
+			videoMetadata.Description = video.Snippet.Description
+		}
+		if videoMetadata.DefaultThumbnailURL == "" {
+			applyThumbnails(videoMetadata, video.Snippet.Thumbnails)
+		}
+		videoMetadata.Tags = video.Snippet.Tags
+		videoMetadata.CategoryID = video.Snippet.CategoryId
+		videoMetadata.DefaultLanguage = video.Snippet.DefaultLanguage
+	}
+
+	if video.Statistics != nil {
+		videoMetadata.ViewCount = int64(video.Statistics.ViewCount)
+		videoMetadata.LikeCount = int64(video.Statistics.LikeCount)
+		videoMetadata.CommentCount = int64(video.Statistics.CommentCount)
+	}
+
+	// A duration the API formats unexpectedly is not worth failing the whole page over
+	if video.ContentDetails != nil && video.ContentDetails.Duration != "" {
+		duration, err := youtube_handler.ParseISODuration(video.ContentDetails.Duration)
+		if err != nil {
+			common.GetLogger().WithError(err).WithField("VideoID", video.Id).Warn("Failed to parse video duration")
+		}
+		videoMetadata.Duration = duration
+	}
+
+	videoMetadata.EnrichedAt = enrichedAt
+}
@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/mock_youtube"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/api/youtube/v3"
+)
+
+type EnrichmentSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	mockYoutubeHandler *mock_youtube.MockYoutubeInterface
+	client             *youtubeClient
+}
+
+func TestEnrichmentSuite(t *testing.T) {
+	suite.Run(t, new(EnrichmentSuite))
+}
+
+func (s *EnrichmentSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.mockYoutubeHandler = mock_youtube.NewMockYoutubeInterface(s.ctrl)
+	s.client = &youtubeClient{
+		youtubeHandler: s.mockYoutubeHandler,
+		apiKeyPool:     youtube_handler.NewAPIKeyPool([]string{"abcd"}, common.DefaultYoutubeDailyQuota),
+		apiKey:         "abcd",
+	}
+}
+
+func (s *EnrichmentSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *EnrichmentSuite) TestEnrich() {
+	videoMetadataList := []*storage.VideoMetadata{
+		{VideoID: "video", ChannelID: "search_channel"},
+		{VideoID: "deleted"},
+	}
+
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), []string{"video", "deleted"}, enrichmentParts).Return(&youtube.VideoListResponse{
+		Items: []*youtube.Video{{
+			Id: "video",
+			Snippet: &youtube.VideoSnippet{
+				ChannelId:       "channel",
+				Tags:            []string{"cricket"},
+				CategoryId:      "17",
+				DefaultLanguage: "en",
+			},
+			ContentDetails: &youtube.VideoContentDetails{Duration: "PT4M13S"},
+			Statistics:     &youtube.VideoStatistics{ViewCount: 1000, LikeCount: 100, CommentCount: 10},
+		}},
+	}, nil)
+
+	s.NoError(s.client.enrich(context.Background(), videoMetadataList))
+
+	enriched := videoMetadataList[0]
+	s.Equal("channel", enriched.ChannelID)
+	s.Equal([]string{"cricket"}, enriched.Tags)
+	s.Equal("17", enriched.CategoryID)
+	s.Equal("en", enriched.DefaultLanguage)
+	s.Equal(4*time.Minute+13*time.Second, enriched.Duration)
+	s.Equal(int64(1000), enriched.ViewCount)
+	s.Equal(int64(100), enriched.LikeCount)
+	s.Equal(int64(10), enriched.CommentCount)
+	s.False(enriched.EnrichedAt.IsZero())
+
+	// Left out by videos.list
+	s.True(videoMetadataList[1].EnrichedAt.IsZero())
+}
+
+func (s *EnrichmentSuite) TestEnrich_Batches() {
+	videoMetadataList := []*storage.VideoMetadata{}
+	for i := 0; i < 120; i++ {
+		videoMetadataList = append(videoMetadataList, &storage.VideoMetadata{VideoID: fmt.Sprintf("video%d", i)})
+	}
+
+	batchSizes := []int{}
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), gomock.Any(), enrichmentParts).
+		DoAndReturn(func(ctx context.Context, ids []string, parts []string) (*youtube.VideoListResponse, error) {
+			batchSizes = append(batchSizes, len(ids))
+			return &youtube.VideoListResponse{}, nil
+		}).Times(3)
+
+	s.NoError(s.client.enrich(context.Background(), videoMetadataList))
+	s.Equal([]int{50, 50, 20}, batchSizes)
+
+	// Every call is counted against the key
+	s.Equal(int64(3*youtube_handler.VideosListCost), s.client.apiKeyPool.Snapshot().Keys[0].UnitsUsed)
+}
+
+func (s *EnrichmentSuite) TestEnrich_Nothing() {
+	s.NoError(s.client.enrich(context.Background(), nil))
+}
+
+func (s *EnrichmentSuite) TestEnrich_Error() {
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), []string{"video"}, enrichmentParts).Return(nil, errors.New("error"))
+
+	s.Error(s.client.enrich(context.Background(), []*storage.VideoMetadata{{VideoID: "video"}}))
+}
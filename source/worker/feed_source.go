@@ -0,0 +1,250 @@
+package worker
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+)
+
+// Largest feed read, the feed of a Youtube channel is around 50KB
+const maxFeedSize = 10 << 20
+
+/*
+feedSource polls an RSS 2.0 or Atom feed, e.g. the feed Youtube serves for every
+channel at https://www.youtube.com/feeds/videos.xml?channel_id=<id>.
+
+A feed holds its most recent items, every poll we keep the ones published after
+the PublishedTime of the cursor and move it to the most recent one. The video id
+of an item is its yt:videoId, the Youtube extension, and its id or guid for any
+other feed.
+*/
+type feedSource struct {
+	url        string
+	httpClient *http.Client
+}
+
+func newFeedSource(url string) *feedSource {
+	return &feedSource{
+		url:        url,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// feedError is returned when the server of a feed answers with an error
+type feedError struct {
+	url        string
+	statusCode int
+}
+
+func (e *feedError) Error() string {
+	return fmt.Sprintf("feed %s answered with status %d", e.url, e.statusCode)
+}
+
+func (s *feedSource) Fetch(ctx context.Context, cursor Cursor) (*Batch, error) {
+	logger := common.GetLogger().WithField("Feed", s.url)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return nil, &feedError{url: s.url, statusCode: response.StatusCode}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxFeedSize))
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := parseFeed(body)
+	if err != nil {
+		return nil, &invalidSourceDataError{location: s.url, err: err}
+	}
+
+	next := cursor
+	videoMetadataList := []*storage.VideoMetadata{}
+	for _, videoMetadata := range items {
+		if !videoMetadata.PublishedAt.After(cursor.PublishedTime) {
+			continue
+		}
+
+		videoMetadataList = append(videoMetadataList, videoMetadata)
+		if next.PublishedTime.Before(videoMetadata.PublishedAt) {
+			next.PublishedTime = videoMetadata.PublishedAt
+		}
+	}
+
+	logger.WithField("ItemCount", len(items)).WithField("NewCount", len(videoMetadataList)).Info("Fetched feed")
+
+	return &Batch{
+		Videos: videoMetadataList,
+		Cursor: next,
+	}, nil
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	Link        string `xml:"link"`
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type atomDocument struct {
+	Title   string      `xml:"http://www.w3.org/2005/Atom title"`
+	Entries []atomEntry `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"http://www.w3.org/2005/Atom id"`
+	VideoID   string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	ChannelID string `xml:"http://www.youtube.com/xml/schemas/2015 channelId"`
+	Title     string `xml:"http://www.w3.org/2005/Atom title"`
+	Summary   string `xml:"http://www.w3.org/2005/Atom summary"`
+	Author    struct {
+		Name string `xml:"http://www.w3.org/2005/Atom name"`
+	} `xml:"http://www.w3.org/2005/Atom author"`
+	Published string `xml:"http://www.w3.org/2005/Atom published"`
+	Media     struct {
+		Description string `xml:"http://search.yahoo.com/mrss/ description"`
+		Thumbnail   struct {
+			URL string `xml:"url,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// RSS dates are RFC 822 dates, with a 4 digit year in practice
+var rssDateLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339}
+
+// Reads the items of an RSS 2.0 or Atom feed as videos
+func parseFeed(body []byte) ([]*storage.VideoMetadata, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	err := xml.Unmarshal(body, &root)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root.XMLName.Local {
+	case "rss":
+		return parseRSS(body)
+	case "feed":
+		return parseAtom(body)
+	default:
+		return nil, fmt.Errorf("unknown feed format %s", root.XMLName.Local)
+	}
+}
+
+func parseRSS(body []byte) ([]*storage.VideoMetadata, error) {
+	var document rssDocument
+	err := xml.Unmarshal(body, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	videoMetadataList := []*storage.VideoMetadata{}
+	for _, item := range document.Channel.Items {
+		videoID := item.GUID
+		if videoID == "" {
+			videoID = item.Link
+		}
+		if videoID == "" {
+			continue
+		}
+
+		publishedAt, err := parseRSSDate(item.PubDate)
+		if err != nil {
+			return nil, err
+		}
+
+		videoMetadataList = append(videoMetadataList, &storage.VideoMetadata{
+			VideoID:      videoID,
+			Title:        item.Title,
+			Description:  item.Description,
+			PublishedAt:  publishedAt,
+			ChannelTitle: document.Channel.Title,
+		})
+	}
+
+	return videoMetadataList, nil
+}
+
+func parseRSSDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	var err error
+	for _, layout := range rssDateLayouts {
+		var publishedAt time.Time
+		publishedAt, err = time.Parse(layout, value)
+		if err == nil {
+			return publishedAt.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func parseAtom(body []byte) ([]*storage.VideoMetadata, error) {
+	var document atomDocument
+	err := xml.Unmarshal(body, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	videoMetadataList := []*storage.VideoMetadata{}
+	for _, entry := range document.Entries {
+		videoID := entry.VideoID
+		if videoID == "" {
+			videoID = entry.ID
+		}
+		if videoID == "" {
+			continue
+		}
+
+		publishedAt, err := time.Parse(time.RFC3339, entry.Published)
+		if err != nil {
+			return nil, err
+		}
+
+		videoMetadata := &storage.VideoMetadata{
+			VideoID:          videoID,
+			Title:            entry.Title,
+			Description:      entry.Media.Description,
+			HighThumbnailURL: entry.Media.Thumbnail.URL,
+			PublishedAt:      publishedAt.UTC(),
+			ChannelID:        entry.ChannelID,
+			ChannelTitle:     entry.Author.Name,
+		}
+		if videoMetadata.Description == "" {
+			videoMetadata.Description = entry.Summary
+		}
+		if videoMetadata.ChannelTitle == "" {
+			videoMetadata.ChannelTitle = document.Title
+		}
+
+		videoMetadataList = append(videoMetadataList, videoMetadata)
+	}
+
+	return videoMetadataList, nil
+}
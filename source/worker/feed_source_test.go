@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// The feed Youtube serves for a channel, trimmed to two videos
+const youtubeChannelFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns:media="http://search.yahoo.com/mrss/" xmlns="http://www.w3.org/2005/Atom">
+ <id>yt:channel:cricket</id>
+ <yt:channelId>UCcricket</yt:channelId>
+ <title>Cricket TV</title>
+ <entry>
+  <id>yt:video:new</id>
+  <yt:videoId>new</yt:videoId>
+  <yt:channelId>UCcricket</yt:channelId>
+  <title>Final over</title>
+  <author><name>Cricket TV</name></author>
+  <published>2022-09-01T12:00:00+00:00</published>
+  <media:group>
+   <media:title>Final over</media:title>
+   <media:thumbnail url="https://i.ytimg.com/vi/new/hqdefault.jpg" width="480" height="360"/>
+   <media:description>The last six balls of the final</media:description>
+  </media:group>
+ </entry>
+ <entry>
+  <id>yt:video:old</id>
+  <yt:videoId>old</yt:videoId>
+  <yt:channelId>UCcricket</yt:channelId>
+  <title>Toss</title>
+  <author><name>Cricket TV</name></author>
+  <published>2022-09-01T09:00:00+00:00</published>
+ </entry>
+</feed>`
+
+const rssFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+ <channel>
+  <title>Video blog</title>
+  <item>
+   <guid>https://example.com/videos/2</guid>
+   <title>Second video</title>
+   <description>A walk through the stadium</description>
+   <pubDate>Thu, 01 Sep 2022 12:00:00 +0000</pubDate>
+  </item>
+  <item>
+   <link>https://example.com/videos/1</link>
+   <title>First video</title>
+   <pubDate>Thu, 01 Sep 2022 09:00:00 GMT</pubDate>
+  </item>
+ </channel>
+</rss>`
+
+type FeedSourceSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	ctx    context.Context
+	status int
+	body   string
+	server *httptest.Server
+	source *feedSource
+}
+
+func TestFeedSourceSuite(t *testing.T) {
+	suite.Run(t, new(FeedSourceSuite))
+}
+
+func (s *FeedSourceSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.ctx = context.Background()
+	s.status = http.StatusOK
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(s.status)
+		w.Write([]byte(s.body))
+	}))
+	s.source = newFeedSource(s.server.URL)
+}
+
+func (s *FeedSourceSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *FeedSourceSuite) TestFetch_Atom() {
+	s.body = youtubeChannelFeed
+	cursor := Cursor{PublishedTime: time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC)}
+
+	batch, err := s.source.Fetch(s.ctx, cursor)
+	s.NoError(err)
+	s.False(batch.More)
+	s.Len(batch.Videos, 1)
+
+	video := batch.Videos[0]
+	s.Equal("new", video.VideoID)
+	s.Equal("Final over", video.Title)
+	s.Equal("The last six balls of the final", video.Description)
+	s.Equal("https://i.ytimg.com/vi/new/hqdefault.jpg", video.HighThumbnailURL)
+	s.Equal("UCcricket", video.ChannelID)
+	s.Equal("Cricket TV", video.ChannelTitle)
+	s.Equal(time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC), video.PublishedAt)
+	s.Equal(video.PublishedAt, batch.Cursor.PublishedTime)
+
+	// Nothing new on the next poll
+	batch, err = s.source.Fetch(s.ctx, batch.Cursor)
+	s.NoError(err)
+	s.Empty(batch.Videos)
+	s.Equal(video.PublishedAt, batch.Cursor.PublishedTime)
+}
+
+func (s *FeedSourceSuite) TestFetch_RSS() {
+	s.body = rssFeed
+
+	batch, err := s.source.Fetch(s.ctx, Cursor{})
+	s.NoError(err)
+	s.Len(batch.Videos, 2)
+
+	s.Equal("https://example.com/videos/2", batch.Videos[0].VideoID)
+	s.Equal("A walk through the stadium", batch.Videos[0].Description)
+	s.Equal("Video blog", batch.Videos[0].ChannelTitle)
+	s.Equal("https://example.com/videos/1", batch.Videos[1].VideoID)
+	s.Equal(time.Date(2022, 9, 1, 9, 0, 0, 0, time.UTC), batch.Videos[1].PublishedAt)
+	s.Equal(time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC), batch.Cursor.PublishedTime)
+}
+
+func (s *FeedSourceSuite) TestFetch_ServerError() {
+	s.status = http.StatusServiceUnavailable
+
+	_, err := s.source.Fetch(s.ctx, Cursor{})
+	s.Error(err)
+	s.Equal(errorClassRetryable, classifyError(err))
+}
+
+func (s *FeedSourceSuite) TestFetch_InvalidFeed() {
+	for _, body := range []string{"not xml", "<html></html>", `<rss><channel><item><guid>1</guid><pubDate>yesterday</pubDate></item></channel></rss>`} {
+		s.body = body
+
+		_, err := s.source.Fetch(s.ctx, Cursor{})
+		s.Error(err, body)
+		s.Equal(errorClassFatal, classifyError(err), body)
+	}
+}
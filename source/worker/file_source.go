@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+)
+
+/*
+fileSource reads the JSON files dropped in a directory, e.g. an export of an
+internal catalog. Each file is a list of videos -
+
+	[{"video_id": "abc", "title": "Cricket", "description": "...", "channel_id": "UC...",
+	  "channel_title": "...", "published_at": "2022-09-01T12:00:00Z", "thumbnail_url": "https://..."}]
+
+video_id and published_at are required. Files are read one per execution in the
+order of their names, the PageToken of the cursor is the name of the last file
+read, so files should be named to sort in the order they are dropped, e.g. with
+a timestamp prefix. A file has to be complete once it has its .json name, write it
+under another name and rename it.
+*/
+type fileSource struct {
+	directory string
+}
+
+type fileVideo struct {
+	VideoID      string    `json:"video_id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	ChannelID    string    `json:"channel_id"`
+	ChannelTitle string    `json:"channel_title"`
+	PublishedAt  time.Time `json:"published_at"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+func (s *fileSource) Fetch(ctx context.Context, cursor Cursor) (*Batch, error) {
+	logger := common.GetLogger().WithField("Directory", s.directory)
+
+	pending, err := s.pendingFiles(cursor.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		return &Batch{Cursor: cursor}, nil
+	}
+
+	name := pending[0]
+	path := filepath.Join(s.directory, name)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var videos []fileVideo
+	err = json.Unmarshal(content, &videos)
+	if err != nil {
+		return nil, &invalidSourceDataError{location: path, err: err}
+	}
+
+	next := cursor
+	next.PageToken = name
+
+	videoMetadataList := []*storage.VideoMetadata{}
+	for _, video := range videos {
+		if video.VideoID == "" || video.PublishedAt.IsZero() {
+			return nil, &invalidSourceDataError{location: path, err: errors.New("every video needs a video_id and a published_at")}
+		}
+
+		videoMetadataList = append(videoMetadataList, &storage.VideoMetadata{
+			VideoID:             video.VideoID,
+			Title:               video.Title,
+			Description:         video.Description,
+			DefaultThumbnailURL: video.ThumbnailURL,
+			PublishedAt:         video.PublishedAt.UTC(),
+			ChannelID:           video.ChannelID,
+			ChannelTitle:        video.ChannelTitle,
+		})
+
+		if next.PublishedTime.Before(video.PublishedAt) {
+			next.PublishedTime = video.PublishedAt.UTC()
+		}
+	}
+
+	logger.WithField("File", name).WithField("VideoCount", len(videoMetadataList)).Info("Read dropped file")
+
+	return &Batch{
+		Videos: videoMetadataList,
+		Cursor: next,
+		More:   len(pending) > 1,
+	}, nil
+}
+
+// Names of the .json files of the directory after the last file read, in order
+func (s *fileSource) pendingFiles(lastFile string) ([]string, error) {
+	entries, err := os.ReadDir(s.directory)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") || name <= lastFile {
+			continue
+		}
+		pending = append(pending, name)
+	}
+
+	sort.Strings(pending)
+	return pending, nil
+}
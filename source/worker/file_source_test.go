@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeDropFile(t *testing.T, directory string, name string, content string) {
+	require.NoError(t, os.WriteFile(filepath.Join(directory, name), []byte(content), 0o644))
+}
+
+func TestFileSource_Fetch(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+	source := &fileSource{directory: directory}
+
+	// Nothing dropped yet
+	batch, err := source.Fetch(ctx, Cursor{})
+	require.NoError(t, err)
+	require.Empty(t, batch.Videos)
+	require.False(t, batch.More)
+
+	writeDropFile(t, directory, "2022-09-02.json", `[{"video_id": "c", "title": "Third", "published_at": "2022-09-02T10:00:00Z"}]`)
+	writeDropFile(t, directory, "2022-09-01.json", `[
+		{"video_id": "a", "title": "First", "channel_id": "UCcatalog", "channel_title": "Catalog", "published_at": "2022-09-01T10:00:00Z", "thumbnail_url": "https://example.com/a.jpg"},
+		{"video_id": "b", "title": "Second", "published_at": "2022-09-01T11:00:00+01:00"}
+	]`)
+	writeDropFile(t, directory, "README.txt", "not a drop")
+
+	// Files are read one at a time in the order of their names
+	batch, err = source.Fetch(ctx, batch.Cursor)
+	require.NoError(t, err)
+	require.True(t, batch.More)
+	require.Equal(t, "2022-09-01.json", batch.Cursor.PageToken)
+	require.Len(t, batch.Videos, 2)
+	require.Equal(t, "a", batch.Videos[0].VideoID)
+	require.Equal(t, "Catalog", batch.Videos[0].ChannelTitle)
+	require.Equal(t, "https://example.com/a.jpg", batch.Videos[0].DefaultThumbnailURL)
+	require.Equal(t, time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC), batch.Videos[1].PublishedAt)
+
+	batch, err = source.Fetch(ctx, batch.Cursor)
+	require.NoError(t, err)
+	require.False(t, batch.More)
+	require.Equal(t, "2022-09-02.json", batch.Cursor.PageToken)
+	require.Equal(t, []string{"c"}, []string{batch.Videos[0].VideoID})
+
+	batch, err = source.Fetch(ctx, batch.Cursor)
+	require.NoError(t, err)
+	require.Empty(t, batch.Videos)
+	require.Equal(t, "2022-09-02.json", batch.Cursor.PageToken)
+}
+
+func TestFileSource_Fetch_Invalid(t *testing.T) {
+	for _, content := range []string{`not json`, `[{"title": "No id", "published_at": "2022-09-01T10:00:00Z"}]`, `[{"video_id": "a"}]`} {
+		directory := t.TempDir()
+		writeDropFile(t, directory, "drop.json", content)
+
+		_, err := (&fileSource{directory: directory}).Fetch(context.Background(), Cursor{})
+		require.Error(t, err, content)
+		require.Equal(t, errorClassFatal, classifyError(err), content)
+	}
+}
+
+func TestFileSource_Fetch_MissingDirectory(t *testing.T) {
+	_, err := (&fileSource{directory: filepath.Join(t.TempDir(), "missing")}).Fetch(context.Background(), Cursor{})
+	require.Error(t, err)
+	require.Equal(t, errorClassRetryable, classifyError(err))
+}
@@ -0,0 +1,217 @@
+package worker
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+)
+
+/*
+Leases keep several instances of the service from polling the same thing twice.
+
+Every ingestion query, and every other loop spending quota, has a lease stored in
+the database. Only the instance holding the lease runs the loop, and it renews
+the lease every third of its duration. The other instances try to take it at the
+same pace, so once the holder dies, or loses the database, another instance takes
+over within 4/3 of the duration.
+
+An instance that fails to renew a lease keeps running the loop until the lease it
+last renewed expires, then stops it, since another instance may have taken it by now.
+*/
+type leaseKeeper struct {
+	leaseHandler storage.LeaseInterface
+
+	holder   string
+	duration time.Duration
+
+	// When every lease held expires, as of its last renewal
+	expiresAt map[string]time.Time
+}
+
+func newLeaseKeeper(leaseHandler storage.LeaseInterface, holder string, duration time.Duration) *leaseKeeper {
+	return &leaseKeeper{
+		leaseHandler: leaseHandler,
+		holder:       holder,
+		duration:     duration,
+		expiresAt:    map[string]time.Time{},
+	}
+}
+
+// How often leases are renewed
+func (k *leaseKeeper) renewInterval() time.Duration {
+	return k.duration / 3
+}
+
+// Takes or renews the lease, returns whether the loop it guards may run here
+func (k *leaseKeeper) hold(ctx context.Context, name string) bool {
+	logger := common.GetLogger().WithField("Lease", name)
+
+	// The lease expires a duration after it was asked for, not after the answer
+	renewedAt := time.Now()
+
+	acquired, err := k.leaseHandler.AcquireLease(ctx, name, k.holder, k.duration)
+	if err != nil {
+		expiresAt, ok := k.expiresAt[name]
+		if ok && renewedAt.Before(expiresAt) {
+			logger.WithError(err).Warn("Failed to renew lease, keeping it until it expires")
+			return true
+		}
+
+		delete(k.expiresAt, name)
+		logger.WithError(err).Warn("Failed to take lease")
+		return false
+	}
+
+	if !acquired {
+		if _, ok := k.expiresAt[name]; ok {
+			logger.Warn("Lease taken over by another instance")
+		}
+		delete(k.expiresAt, name)
+		return false
+	}
+
+	if _, ok := k.expiresAt[name]; !ok {
+		logger.WithField("Holder", k.holder).Info("Took lease")
+	}
+	k.expiresAt[name] = renewedAt.Add(k.duration)
+	return true
+}
+
+// Gives up the lease, so another instance can take it without waiting for it to expire
+func (k *leaseKeeper) release(ctx context.Context, name string) {
+	delete(k.expiresAt, name)
+
+	err := k.leaseHandler.ReleaseLease(ctx, name, k.holder)
+	if err != nil {
+		common.GetLogger().WithError(err).WithField("Lease", name).Warn("Failed to release lease")
+	}
+}
+
+// Names of the leases held, sorted
+func (k *leaseKeeper) held() []string {
+	names := []string{}
+	for name := range k.expiresAt {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Runner is a long running background loop
+type Runner interface {
+	Start(ctx context.Context)
+	Stop()
+	Shutdown(ctx context.Context) error
+}
+
+/*
+LeasedRunner runs a background loop on the one instance holding its lease, see
+leaseKeeper.
+
+The loop is created when the lease is taken and stopped when it is lost. Loops
+resume from their checkpoints when they are created, so the instance taking over
+carries on from where the previous holder stopped. A loop that returns on its own,
+once it completed or on a fatal error, is not created again while the lease is
+held, and the lease is kept so no other instance creates it either.
+*/
+type LeasedRunner struct {
+	name      string
+	leases    *leaseKeeper
+	newRunner func(ctx context.Context) (Runner, error)
+
+	runner Runner
+	// Closed once the Start of runner returns
+	runnerDone chan struct{}
+	// Set once the loop returned on its own, while the lease is held
+	runnerFinished bool
+
+	*lifecycle
+}
+
+func NewLeasedRunner(name string, holder string, leaseDuration time.Duration, newRunner func(ctx context.Context) (Runner, error)) *LeasedRunner {
+	return &LeasedRunner{
+		name:      name,
+		leases:    newLeaseKeeper(storage.NewLeaseHandler(), holder, leaseDuration),
+		newRunner: newRunner,
+		lifecycle: newLifecycle(),
+	}
+}
+
+// Keeps the loop running while the lease is held until it is shut down, the loop is started with ctx
+func (r *LeasedRunner) Start(ctx context.Context) {
+	defer r.finish()
+
+	for {
+		r.Reconcile(ctx)
+
+		if !r.sleep(ctx, r.leases.renewInterval()) {
+			return
+		}
+	}
+}
+
+// Starts the loop if the lease is held and it is not running yet, stops it if the lease is lost
+func (r *LeasedRunner) Reconcile(ctx context.Context) {
+	logger := common.GetLogger().WithField("Lease", r.name)
+
+	if !r.leases.hold(ctx, r.name) {
+		if r.runner != nil {
+			logger.Info("Stopping loop, its lease is lost")
+			r.runner.Stop()
+			r.runner = nil
+			r.runnerFinished = false
+		}
+		return
+	}
+
+	if r.runner != nil {
+		if r.runnerFinished {
+			return
+		}
+
+		select {
+		case <-r.runnerDone:
+			logger.Info("Loop stopped on its own, keeping its lease without starting it again")
+			r.runnerFinished = true
+		default:
+		}
+		return
+	}
+
+	// A loop that fails to be created is retried on the next renewal
+	runner, err := r.newRunner(ctx)
+	if err != nil {
+		logger.WithError(err).Error("Failed to init loop")
+		return
+	}
+
+	logger.Info("Starting loop")
+	r.runner = runner
+	r.runnerDone = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		runner.Start(ctx)
+	}(r.runnerDone)
+}
+
+// Shutdown stops renewing the lease, then stops the loop and gives up the lease
+// once it stopped, waiting until ctx is done
+func (r *LeasedRunner) Shutdown(ctx context.Context) error {
+	err := r.lifecycle.Shutdown(ctx)
+	if err != nil {
+		return err
+	}
+
+	if r.runner != nil {
+		err = r.runner.Shutdown(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.leases.release(ctx, r.name)
+	return nil
+}
@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type LeaseSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	ctx        context.Context
+	leaseStore *storage.MemoryLeaseImpl
+
+	started []*fakeRunner
+	runner  *LeasedRunner
+}
+
+func TestLeaseSuite(t *testing.T) {
+	suite.Run(t, new(LeaseSuite))
+}
+
+func (s *LeaseSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.ctx = context.Background()
+	s.leaseStore = storage.NewMemoryLeaseImplWithStore(storage.NewMemoryStore())
+
+	s.started = nil
+	s.runner = &LeasedRunner{
+		name:   "channels",
+		leases: newLeaseKeeper(s.leaseStore, "instance", time.Minute),
+		newRunner: func(ctx context.Context) (Runner, error) {
+			runner := &fakeRunner{finish: make(chan struct{})}
+			s.started = append(s.started, runner)
+			return runner, nil
+		},
+		lifecycle: newLifecycle(),
+	}
+}
+
+func (s *LeaseSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *LeaseSuite) TestLeaseKeeper_KeepsLeaseUntilExpiryWhenRenewalFails() {
+	mockLeaseStore := mock_storage.NewMockLeaseInterface(s.ctrl)
+	leases := newLeaseKeeper(mockLeaseStore, "instance", 100*time.Millisecond)
+
+	mockLeaseStore.EXPECT().AcquireLease(gomock.Any(), "query:cricket", "instance", 100*time.Millisecond).Return(true, nil)
+	s.True(leases.hold(s.ctx, "query:cricket"))
+
+	mockLeaseStore.EXPECT().AcquireLease(gomock.Any(), "query:cricket", "instance", 100*time.Millisecond).Return(false, errors.New("error")).Times(2)
+	s.True(leases.hold(s.ctx, "query:cricket"))
+
+	// Another instance may have taken it by now
+	time.Sleep(150 * time.Millisecond)
+	s.False(leases.hold(s.ctx, "query:cricket"))
+	s.Empty(leases.held())
+}
+
+func (s *LeaseSuite) TestLeaseKeeper_NeverHeldIsNotKeptOnError() {
+	mockLeaseStore := mock_storage.NewMockLeaseInterface(s.ctrl)
+	leases := newLeaseKeeper(mockLeaseStore, "instance", time.Minute)
+
+	mockLeaseStore.EXPECT().AcquireLease(gomock.Any(), "query:cricket", "instance", time.Minute).Return(false, errors.New("error"))
+	s.False(leases.hold(s.ctx, "query:cricket"))
+}
+
+func (s *LeaseSuite) TestLeasedRunner_RunsWhileLeaseIsHeld() {
+	acquired, err := s.leaseStore.AcquireLease(s.ctx, "channels", "other", time.Minute)
+	s.NoError(err)
+	s.True(acquired)
+
+	s.runner.Reconcile(s.ctx)
+	s.Empty(s.started)
+
+	// The other instance went away
+	s.NoError(s.leaseStore.ReleaseLease(s.ctx, "channels", "other"))
+
+	s.runner.Reconcile(s.ctx)
+	s.runner.Reconcile(s.ctx)
+	s.Len(s.started, 1)
+	s.False(s.started[0].stopped)
+
+	// The lease is lost to another instance
+	s.NoError(s.leaseStore.ReleaseLease(s.ctx, "channels", "instance"))
+	acquired, err = s.leaseStore.AcquireLease(s.ctx, "channels", "other", time.Minute)
+	s.NoError(err)
+	s.True(acquired)
+
+	s.runner.Reconcile(s.ctx)
+	s.True(s.started[0].stopped)
+}
+
+func (s *LeaseSuite) TestLeasedRunner_FinishedLoopIsNotStartedAgain() {
+	s.runner.Reconcile(s.ctx)
+	s.Len(s.started, 1)
+
+	// The loop completes on its own
+	close(s.started[0].finish)
+	s.Eventually(func() bool {
+		s.runner.Reconcile(s.ctx)
+		return s.runner.runnerFinished
+	}, time.Second, 10*time.Millisecond)
+
+	s.runner.Reconcile(s.ctx)
+	s.runner.Reconcile(s.ctx)
+	s.Len(s.started, 1)
+
+	// The lease is kept, so no other instance starts it either
+	acquired, err := s.leaseStore.AcquireLease(s.ctx, "channels", "other", time.Minute)
+	s.NoError(err)
+	s.False(acquired)
+}
+
+func (s *LeaseSuite) TestLeasedRunner_ShutdownReleasesLease() {
+	go s.runner.Start(s.ctx)
+
+	s.Eventually(func() bool {
+		acquired, err := s.leaseStore.AcquireLease(s.ctx, "channels", "other", time.Minute)
+		return err == nil && !acquired
+	}, time.Second, 10*time.Millisecond)
+
+	s.NoError(s.runner.Shutdown(s.ctx))
+	s.Len(s.started, 1)
+	s.True(s.started[0].stopped)
+
+	acquired, err := s.leaseStore.AcquireLease(s.ctx, "channels", "other", time.Minute)
+	s.NoError(err)
+	s.True(acquired)
+}
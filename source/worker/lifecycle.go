@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// lifecycle lets a long running loop be stopped between two iterations, so an
+// iteration that already started gets to finish
+type lifecycle struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Stop asks the loop to stop after its current iteration and waits for it
+func (l *lifecycle) Stop() {
+	l.Shutdown(context.Background())
+}
+
+// Shutdown asks the loop to stop after its current iteration and waits for it
+// until ctx is done. The iteration itself is only cut short by cancelling the
+// context the loop was started with.
+func (l *lifecycle) Shutdown(ctx context.Context) error {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sleeps between two iterations, returns false if the loop should stop instead
+func (l *lifecycle) sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-l.stop:
+		return false
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// Marks the loop as stopped, deferred by the loop
+func (l *lifecycle) finish() {
+	close(l.done)
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycle_Shutdown(t *testing.T) {
+	l := newLifecycle()
+
+	go func() {
+		defer l.finish()
+		for l.sleep(context.Background(), time.Millisecond) {
+		}
+	}()
+
+	require.NoError(t, l.Shutdown(context.Background()))
+	// Stopping again is a no-op
+	require.NoError(t, l.Shutdown(context.Background()))
+}
+
+func TestLifecycle_ShutdownDeadline(t *testing.T) {
+	l := newLifecycle()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Nothing ever finishes the loop, Shutdown gives up at the deadline
+	require.ErrorIs(t, l.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestLifecycle_SleepCancelled(t *testing.T) {
+	l := newLifecycle()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.False(t, l.sleep(ctx, time.Hour))
+}
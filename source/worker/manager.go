@@ -0,0 +1,299 @@
+package worker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+)
+
+// queryRunner is a long running loop for one ingestion query, WorkerHandler in production
+type queryRunner interface {
+	Runner
+	State() WorkerState
+}
+
+type managedRunner struct {
+	queryConfig common.YoutubeQueryConfig
+	runner      queryRunner
+}
+
+/*
+Manager runs one worker per ingestion query stored in the database, whatever its source.
+
+Queries are managed at runtime through the admin API, which only writes to the
+database. Every reload interval the Manager lists the queries and reconciles the
+running workers with them -
+1. A paused or deleted query has its worker stopped
+2. An updated query has its worker stopped and started again with the new definition
+3. A new or resumed query has a worker started
+
+A worker that failed on a fatal error is left as it is, so its state stays visible
+through WorkerStates until the query is updated.
+
+When several instances share the database, every query only runs on the instance
+holding its lease, see leaseKeeper. Reconciling renews the leases of the queries
+that run here and tries to take the others, so the Manager reconciles at least
+every third of the lease duration. A query whose lease is lost has its worker
+stopped, a query paused or deleted has its lease released.
+
+Workers resume from the checkpoint of their query name, so a restarted worker
+carries on from where the previous one stopped.
+
+On shutdown the Manager stops reconciling and stops every worker at once, each
+one finishing its current execution.
+*/
+type Manager struct {
+	// mu guards the runners and the leases, reconcileMu runs one Reconcile at a time
+	mu          sync.Mutex
+	reconcileMu sync.Mutex
+	*lifecycle
+
+	reloadInterval time.Duration
+	leases         *leaseKeeper
+
+	ingestionQueryHandler storage.IngestionQueryInterface
+	newRunner             func(ctx context.Context, queryConfig common.YoutubeQueryConfig) (queryRunner, error)
+
+	runners map[string]*managedRunner
+}
+
+func NewManager(apiKeyPool *youtube_handler.APIKeyPool, maxCatchUp time.Duration, maxBackoff time.Duration, reloadInterval time.Duration, instanceID string, leaseDuration time.Duration) *Manager {
+	return &Manager{
+		reloadInterval:        reloadInterval,
+		leases:                newLeaseKeeper(storage.NewLeaseHandler(), instanceID, leaseDuration),
+		ingestionQueryHandler: storage.NewIngestionQueryHandler(),
+		newRunner: func(ctx context.Context, queryConfig common.YoutubeQueryConfig) (queryRunner, error) {
+			return NewWorkerHandler(ctx, queryConfig, apiKeyPool, maxCatchUp, maxBackoff)
+		},
+		runners:   map[string]*managedRunner{},
+		lifecycle: newLifecycle(),
+	}
+}
+
+// Stores the queries that are not in the database yet. Queries already stored are
+// left as they are, so changes made through the admin API survive a restart.
+func (m *Manager) Seed(ctx context.Context, queries []common.YoutubeQueryConfig) error {
+	logger := common.GetLogger()
+
+	for _, queryConfig := range queries {
+		now := time.Now().UTC()
+		err := m.ingestionQueryHandler.CreateIngestionQuery(ctx, &storage.IngestionQuery{
+			Name:         queryConfig.Name,
+			Source:       queryConfig.Source,
+			Query:        queryConfig.Query,
+			Location:     queryConfig.Location,
+			Tag:          queryConfig.Tag,
+			PollInterval: queryConfig.PollInterval,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+		if err == storage.ErrIngestionQueryExists {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		logger.WithField("QueryName", queryConfig.Name).Info("Seeded ingestion query")
+	}
+
+	return nil
+}
+
+// Reconciles the workers with the queries in the database every reload interval
+// until the Manager is shut down. Workers are started with ctx.
+func (m *Manager) Start(ctx context.Context) {
+	defer m.finish()
+
+	logger := common.GetLogger()
+
+	interval := m.reloadInterval
+	if m.leases.renewInterval() < interval {
+		interval = m.leases.renewInterval()
+	}
+
+	for {
+		err := m.Reconcile(ctx)
+		if err != nil {
+			logger.WithError(err).Error("Failed to reconcile workers with ingestion queries")
+		}
+
+		if !m.sleep(ctx, interval) {
+			return
+		}
+	}
+}
+
+// Shutdown stops reconciling, then stops every worker and waits for them until ctx is done.
+// The leases are released once every worker stopped.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	err := m.lifecycle.Shutdown(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	runners := []queryRunner{}
+	for _, running := range m.runners {
+		runners = append(runners, running.runner)
+	}
+	m.mu.Unlock()
+
+	errs := make(chan error, len(runners))
+	for _, runner := range runners {
+		go func(runner queryRunner) {
+			errs <- runner.Shutdown(ctx)
+		}(runner)
+	}
+
+	for range runners {
+		if shutdownErr := <-errs; shutdownErr != nil {
+			err = shutdownErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, leaseName := range m.leases.held() {
+		m.leases.release(ctx, leaseName)
+	}
+	return nil
+}
+
+// Name of the lease of an ingestion query
+func queryLeaseName(name string) string {
+	return "query:" + name
+}
+
+// Starts and stops workers so that exactly the unpaused queries in the database whose lease
+// is held here are running
+func (m *Manager) Reconcile(ctx context.Context) error {
+	logger := common.GetLogger()
+
+	m.reconcileMu.Lock()
+	defer m.reconcileMu.Unlock()
+
+	ingestionQueries, err := m.ingestionQueryHandler.ListIngestionQueries(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+
+	desired := map[string]common.YoutubeQueryConfig{}
+	leased := map[string]bool{}
+	for _, ingestionQuery := range ingestionQueries {
+		if ingestionQuery.Paused {
+			continue
+		}
+
+		leaseName := queryLeaseName(ingestionQuery.Name)
+		if !m.leases.hold(ctx, leaseName) {
+			continue
+		}
+
+		desired[ingestionQuery.Name] = queryConfigFromIngestionQuery(ingestionQuery)
+		leased[leaseName] = true
+	}
+
+	stopping := []*managedRunner{}
+	for name, running := range m.runners {
+		queryConfig, ok := desired[name]
+		if ok && queryConfig == running.queryConfig {
+			continue
+		}
+
+		stopping = append(stopping, running)
+		delete(m.runners, name)
+	}
+
+	m.mu.Unlock()
+
+	// Stopping waits for the current iteration of a worker, the states of the
+	// other workers can be read meanwhile
+	for _, running := range stopping {
+		logger.WithField("QueryName", running.queryConfig.Name).Info("Stopping worker")
+		running.runner.Stop()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The workers are stopped, the leases of paused and deleted queries can go
+	for _, leaseName := range m.leases.held() {
+		if !leased[leaseName] {
+			m.leases.release(ctx, leaseName)
+		}
+	}
+
+	for _, ingestionQuery := range ingestionQueries {
+		queryConfig, ok := desired[ingestionQuery.Name]
+		if !ok {
+			continue
+		}
+		if _, ok := m.runners[queryConfig.Name]; ok {
+			continue
+		}
+
+		// A worker that fails to start is retried on the next reload
+		runner, err := m.newRunner(ctx, queryConfig)
+		if err != nil {
+			logger.WithError(err).WithField("QueryName", queryConfig.Name).Error("Failed to init worker")
+			continue
+		}
+
+		logger.WithField("QueryName", queryConfig.Name).Info("Starting worker")
+		m.runners[queryConfig.Name] = &managedRunner{
+			queryConfig: queryConfig,
+			runner:      runner,
+		}
+		go runner.Start(ctx)
+	}
+
+	return nil
+}
+
+// WorkerStates returns the state of every running worker, ordered by query name
+func (m *Manager) WorkerStates() []WorkerState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states := []WorkerState{}
+	for _, running := range m.runners {
+		states = append(states, running.runner.State())
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
+func queryConfigFromIngestionQuery(ingestionQuery *storage.IngestionQuery) common.YoutubeQueryConfig {
+	queryConfig := common.YoutubeQueryConfig{
+		Name:         ingestionQuery.Name,
+		Source:       ingestionQuery.Source,
+		Query:        ingestionQuery.Query,
+		Location:     ingestionQuery.Location,
+		Tag:          ingestionQuery.Tag,
+		PollInterval: ingestionQuery.PollInterval,
+	}
+
+	if queryConfig.Source == "" {
+		queryConfig.Source = common.SearchSource
+	}
+
+	if queryConfig.PollInterval <= 0 {
+		queryConfig.PollInterval = common.DefaultPollInterval
+	}
+	return queryConfig
+}
@@ -0,0 +1,323 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeRunner struct {
+	queryConfig common.YoutubeQueryConfig
+	stopped     bool
+
+	// Start returns once it is closed, as a loop giving up on a fatal error does
+	finish chan struct{}
+	// Stop waits for it to be closed when set, as a worker finishing its iteration does
+	iteration chan struct{}
+}
+
+func (r *fakeRunner) Start(ctx context.Context) {
+	select {
+	case <-r.finish:
+	case <-ctx.Done():
+	}
+}
+
+func (r *fakeRunner) Stop() {
+	if r.iteration != nil {
+		<-r.iteration
+	}
+	r.stopped = true
+}
+
+func (r *fakeRunner) Shutdown(ctx context.Context) error {
+	r.stopped = true
+	return nil
+}
+
+func (r *fakeRunner) State() WorkerState {
+	return WorkerState{Name: r.queryConfig.Name, Query: r.queryConfig.Query, Status: WorkerStatusRunning}
+}
+
+type ManagerSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	mockIngestionQueryStore *mock_storage.MockIngestionQueryInterface
+	leaseStore              *storage.MemoryLeaseImpl
+
+	started []*fakeRunner
+	manager *Manager
+}
+
+func TestManagerSuite(t *testing.T) {
+	suite.Run(t, new(ManagerSuite))
+}
+
+func (s *ManagerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.mockIngestionQueryStore = mock_storage.NewMockIngestionQueryInterface(s.ctrl)
+	s.leaseStore = storage.NewMemoryLeaseImplWithStore(storage.NewMemoryStore())
+
+	s.started = nil
+	s.manager = &Manager{
+		reloadInterval:        time.Second,
+		leases:                newLeaseKeeper(s.leaseStore, "instance", time.Minute),
+		ingestionQueryHandler: s.mockIngestionQueryStore,
+		newRunner: func(ctx context.Context, queryConfig common.YoutubeQueryConfig) (queryRunner, error) {
+			runner := &fakeRunner{queryConfig: queryConfig}
+			s.started = append(s.started, runner)
+			return runner, nil
+		},
+		runners: map[string]*managedRunner{},
+	}
+}
+
+func (s *ManagerSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *ManagerSuite) expectList(ingestionQueries ...*storage.IngestionQuery) {
+	s.mockIngestionQueryStore.EXPECT().ListIngestionQueries(gomock.Any()).Return(ingestionQueries, nil)
+}
+
+func (s *ManagerSuite) TestReconcile_StartsUnpausedQueries() {
+	s.expectList(
+		&storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute},
+		&storage.IngestionQuery{Name: "tennis", Query: "tennis", Tag: "tennis", Paused: true},
+	)
+
+	s.NoError(s.manager.Reconcile(context.Background()))
+
+	s.Len(s.started, 1)
+	s.Equal(common.YoutubeQueryConfig{Name: "cricket", Source: common.SearchSource, Query: "cricket", Tag: "sports", PollInterval: time.Minute}, s.started[0].queryConfig)
+	s.Contains(s.manager.runners, "cricket")
+}
+
+func (s *ManagerSuite) TestReconcile_LeavesUnchangedQueriesRunning() {
+	cricket := &storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute}
+
+	s.expectList(cricket)
+	s.NoError(s.manager.Reconcile(context.Background()))
+
+	s.expectList(cricket)
+	s.NoError(s.manager.Reconcile(context.Background()))
+
+	s.Len(s.started, 1)
+	s.False(s.started[0].stopped)
+}
+
+func (s *ManagerSuite) TestReconcile_PauseUpdateDelete() {
+	s.expectList(
+		&storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute},
+		&storage.IngestionQuery{Name: "football", Query: "football", Tag: "sports", PollInterval: time.Minute},
+		&storage.IngestionQuery{Name: "tennis", Query: "tennis", Tag: "tennis", PollInterval: time.Minute},
+	)
+	s.NoError(s.manager.Reconcile(context.Background()))
+	s.Len(s.started, 3)
+	cricket, football, tennis := s.started[0], s.started[1], s.started[2]
+
+	// cricket is paused, football is updated and tennis is deleted
+	s.expectList(
+		&storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute, Paused: true},
+		&storage.IngestionQuery{Name: "football", Query: "football highlights", Tag: "sports", PollInterval: time.Minute},
+	)
+	s.NoError(s.manager.Reconcile(context.Background()))
+
+	s.True(cricket.stopped)
+	s.True(football.stopped)
+	s.True(tennis.stopped)
+
+	s.Len(s.started, 4)
+	s.Equal("football highlights", s.started[3].queryConfig.Query)
+	s.Len(s.manager.runners, 1)
+
+	// cricket is resumed
+	s.expectList(
+		&storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute},
+		&storage.IngestionQuery{Name: "football", Query: "football highlights", Tag: "sports", PollInterval: time.Minute},
+	)
+	s.NoError(s.manager.Reconcile(context.Background()))
+
+	s.Len(s.started, 5)
+	s.Equal("cricket", s.started[4].queryConfig.Name)
+	s.False(s.started[3].stopped)
+}
+
+func (s *ManagerSuite) TestReconcile_StatesReadableWhileStopping() {
+	cricket := &storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute}
+	tennis := &storage.IngestionQuery{Name: "tennis", Query: "tennis", Tag: "tennis", PollInterval: time.Minute}
+
+	s.expectList(cricket, tennis)
+	s.NoError(s.manager.Reconcile(context.Background()))
+	s.Len(s.started, 2)
+
+	// cricket is deleted while in the middle of an iteration
+	iteration := make(chan struct{})
+	s.started[0].iteration = iteration
+
+	s.expectList(tennis)
+	reconciled := make(chan error)
+	go func() {
+		reconciled <- s.manager.Reconcile(context.Background())
+	}()
+
+	// Polls until cricket is no longer listed, which it is not from the moment it starts stopping
+	states := make(chan []WorkerState)
+	go func() {
+		for {
+			workerStates := s.manager.WorkerStates()
+			if len(workerStates) == 1 {
+				states <- workerStates
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	select {
+	case workerStates := <-states:
+		s.Len(workerStates, 1)
+		s.Equal("tennis", workerStates[0].Name)
+	case <-time.After(time.Second):
+		s.Fail("WorkerStates blocked by a stopping worker")
+	}
+
+	close(iteration)
+	s.NoError(<-reconciled)
+	s.True(s.started[0].stopped)
+}
+
+func (s *ManagerSuite) TestReconcile_LeaseHeldByAnotherInstance() {
+	acquired, err := s.leaseStore.AcquireLease(context.Background(), "query:cricket", "other", time.Minute)
+	s.NoError(err)
+	s.True(acquired)
+
+	cricket := &storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute}
+	tennis := &storage.IngestionQuery{Name: "tennis", Query: "tennis", Tag: "tennis", PollInterval: time.Minute}
+
+	s.expectList(cricket, tennis)
+	s.NoError(s.manager.Reconcile(context.Background()))
+	s.Len(s.started, 1)
+	s.Equal("tennis", s.started[0].queryConfig.Name)
+
+	// The other instance went away, its query is taken over
+	s.NoError(s.leaseStore.ReleaseLease(context.Background(), "query:cricket", "other"))
+
+	s.expectList(cricket, tennis)
+	s.NoError(s.manager.Reconcile(context.Background()))
+	s.Len(s.started, 2)
+	s.Equal("cricket", s.started[1].queryConfig.Name)
+}
+
+func (s *ManagerSuite) TestReconcile_LostLeaseStopsWorker() {
+	cricket := &storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute}
+
+	s.expectList(cricket)
+	s.NoError(s.manager.Reconcile(context.Background()))
+	s.Len(s.started, 1)
+
+	// Another instance took the lease while this one could not renew it
+	s.NoError(s.leaseStore.ReleaseLease(context.Background(), "query:cricket", "instance"))
+	acquired, err := s.leaseStore.AcquireLease(context.Background(), "query:cricket", "other", time.Minute)
+	s.NoError(err)
+	s.True(acquired)
+
+	s.expectList(cricket)
+	s.NoError(s.manager.Reconcile(context.Background()))
+	s.True(s.started[0].stopped)
+	s.Empty(s.manager.runners)
+
+	// The lease stays with the other instance
+	acquired, err = s.leaseStore.AcquireLease(context.Background(), "query:cricket", "third", time.Minute)
+	s.NoError(err)
+	s.False(acquired)
+}
+
+func (s *ManagerSuite) TestReconcile_PausedQueryReleasesLease() {
+	s.expectList(&storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute})
+	s.NoError(s.manager.Reconcile(context.Background()))
+	s.Equal([]string{"query:cricket"}, s.manager.leases.held())
+
+	s.expectList(&storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute, Paused: true})
+	s.NoError(s.manager.Reconcile(context.Background()))
+	s.True(s.started[0].stopped)
+	s.Empty(s.manager.leases.held())
+
+	acquired, err := s.leaseStore.AcquireLease(context.Background(), "query:cricket", "other", time.Minute)
+	s.NoError(err)
+	s.True(acquired)
+}
+
+func (s *ManagerSuite) TestWorkerStates() {
+	s.Empty(s.manager.WorkerStates())
+
+	s.expectList(
+		&storage.IngestionQuery{Name: "tennis", Query: "tennis", Tag: "tennis", PollInterval: time.Minute},
+		&storage.IngestionQuery{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute},
+		&storage.IngestionQuery{Name: "football", Query: "football", Tag: "sports", Paused: true},
+	)
+	s.NoError(s.manager.Reconcile(context.Background()))
+
+	states := s.manager.WorkerStates()
+	s.Len(states, 2)
+	s.Equal("cricket", states[0].Name)
+	s.Equal("tennis", states[1].Name)
+}
+
+func (s *ManagerSuite) TestShutdown_StopsLoopAndWorkers() {
+	s.manager.lifecycle = newLifecycle()
+	s.mockIngestionQueryStore.EXPECT().ListIngestionQueries(gomock.Any()).Return([]*storage.IngestionQuery{
+		{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute},
+	}, nil).MinTimes(1)
+
+	go s.manager.Start(context.Background())
+
+	s.Eventually(func() bool {
+		return len(s.manager.WorkerStates()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	s.NoError(s.manager.Shutdown(context.Background()))
+	s.Len(s.started, 1)
+	s.True(s.started[0].stopped)
+
+	// Another instance takes over without waiting for the lease to expire
+	acquired, err := s.leaseStore.AcquireLease(context.Background(), "query:cricket", "other", time.Minute)
+	s.NoError(err)
+	s.True(acquired)
+}
+
+func (s *ManagerSuite) TestReconcile_ListError() {
+	s.mockIngestionQueryStore.EXPECT().ListIngestionQueries(gomock.Any()).Return(nil, errors.New("error"))
+
+	s.Error(s.manager.Reconcile(context.Background()))
+	s.Empty(s.started)
+}
+
+func (s *ManagerSuite) TestSeed_SkipsStoredQueries() {
+	queries := []common.YoutubeQueryConfig{
+		{Name: "cricket", Query: "cricket", Tag: "sports", PollInterval: time.Minute},
+		{Name: "tennis", Query: "tennis", Tag: "tennis", PollInterval: time.Minute},
+	}
+
+	s.mockIngestionQueryStore.EXPECT().CreateIngestionQuery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, ingestionQuery *storage.IngestionQuery) error {
+		s.Equal("cricket", ingestionQuery.Name)
+		s.Equal(time.Minute, ingestionQuery.PollInterval)
+		s.False(ingestionQuery.Paused)
+		return nil
+	})
+	s.mockIngestionQueryStore.EXPECT().CreateIngestionQuery(gomock.Any(), gomock.Any()).Return(storage.ErrIngestionQueryExists)
+
+	s.NoError(s.manager.Seed(context.Background(), queries))
+}
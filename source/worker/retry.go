@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/api/googleapi"
+)
+
+type errorClass int
+
+const (
+	// The call succeeded
+	errorClassNone errorClass = iota
+	// A blip, the call is retried with backoff
+	errorClassRetryable
+	// The API key ran out of quota, another key is used or the reset is awaited
+	errorClassQuota
+	// Retrying cannot help, the runner gives up
+	errorClassFatal
+)
+
+// Mongo error codes for a server that refuses the credentials of the service,
+// Unauthorized and AuthenticationFailed
+var fatalMongoCodes = []int{13, 18}
+
+/*
+classifyError sorts the errors of a runner iteration -
+1. Quota - the API refused the key for quota, or every key is parked
+2. Fatal - requests the API rejects as invalid (4xx other than rate limits),
+unparseable API responses, sources holding data that cannot be read and mongo
+refusing the credentials
+3. Retryable - everything else, 5xx and rate limits from the API, network errors
+and timeouts from the API or the database
+
+Unknown errors are retried, a runner that stops on an unexpected error stops
+ingestion until someone notices. A runner retrying it shows up as degraded.
+*/
+func classifyError(err error) errorClass {
+	if err == nil {
+		return errorClassNone
+	}
+
+	var keysExhausted *youtube_handler.KeysExhaustedError
+	if errors.As(err, &keysExhausted) || youtube_handler.IsQuotaExceeded(err) {
+		return errorClassQuota
+	}
+
+	var apiError *googleapi.Error
+	if errors.As(err, &apiError) {
+		if apiError.Code >= 500 || apiError.Code == http.StatusTooManyRequests {
+			return errorClassRetryable
+		}
+
+		for _, item := range apiError.Errors {
+			switch item.Reason {
+			case "rateLimitExceeded", "userRateLimitExceeded", "backendError":
+				return errorClassRetryable
+			}
+		}
+
+		if apiError.Code >= 400 {
+			return errorClassFatal
+		}
+		return errorClassRetryable
+	}
+
+	var parseError *time.ParseError
+	if errors.As(err, &parseError) {
+		return errorClassFatal
+	}
+
+	var invalidSourceData *invalidSourceDataError
+	if errors.As(err, &invalidSourceData) {
+		return errorClassFatal
+	}
+
+	var serverError mongo.ServerError
+	if errors.As(err, &serverError) {
+		for _, code := range fatalMongoCodes {
+			if serverError.HasErrorCode(code) {
+				return errorClassFatal
+			}
+		}
+	}
+
+	return errorClassRetryable
+}
+
+// Delay before the first retry, it doubles with every consecutive failure
+const baseBackoff = time.Second
+
+// backoff is a jittered exponential backoff, capped at ceiling
+type backoff struct {
+	ceiling time.Duration
+	attempt int
+
+	random func() float64
+}
+
+func newBackoff(ceiling time.Duration) backoff {
+	return backoff{
+		ceiling: ceiling,
+		random:  rand.Float64,
+	}
+}
+
+// Next returns the delay before the next retry. The delay is picked at random
+// between half and all of the exponential delay, so runners that failed together
+// don't retry together.
+func (b *backoff) Next() time.Duration {
+	delay := b.ceiling
+	if b.attempt < 32 && baseBackoff<<b.attempt < b.ceiling {
+		delay = baseBackoff << b.attempt
+	}
+	b.attempt += 1
+
+	return delay/2 + time.Duration(b.random()*float64(delay/2))
+}
+
+// Reset starts the delays over after a success
+func (b *backoff) Reset() {
+	b.attempt = 0
+}
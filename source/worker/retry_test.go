@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/api/googleapi"
+)
+
+func TestClassifyError(t *testing.T) {
+	_, parseErr := time.Parse(time.RFC3339, "yesterday")
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected errorClass
+	}{
+		{"success", nil, errorClassNone},
+		{"quota exceeded", &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}, errorClassQuota},
+		{"keys exhausted", &youtube_handler.KeysExhaustedError{Until: time.Now()}, errorClassQuota},
+		{"youtube 500", &googleapi.Error{Code: http.StatusInternalServerError}, errorClassRetryable},
+		{"youtube 503 wrapped", fmt.Errorf("search: %w", &googleapi.Error{Code: http.StatusServiceUnavailable}), errorClassRetryable},
+		{"youtube 429", &googleapi.Error{Code: http.StatusTooManyRequests}, errorClassRetryable},
+		{"youtube rate limit", &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}, errorClassRetryable},
+		{"youtube bad request", &googleapi.Error{Code: http.StatusBadRequest, Errors: []googleapi.ErrorItem{{Reason: "invalidSearchFilter"}}}, errorClassFatal},
+		{"youtube invalid key", &googleapi.Error{Code: http.StatusBadRequest, Errors: []googleapi.ErrorItem{{Reason: "keyInvalid"}}}, errorClassFatal},
+		{"unparseable response", parseErr, errorClassFatal},
+		{"mongo unauthorized", mongo.CommandError{Code: 13, Message: "not authorized"}, errorClassFatal},
+		{"mongo command error", mongo.CommandError{Code: 91, Message: "shutdown in progress"}, errorClassRetryable},
+		{"timeout", context.DeadlineExceeded, errorClassRetryable},
+		{"unknown", errors.New("connection reset by peer"), errorClassRetryable},
+	}
+
+	for _, testCase := range testCases {
+		require.Equal(t, testCase.expected, classifyError(testCase.err), testCase.name)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	backoff := newBackoff(10 * time.Second)
+
+	// Without jitter the delay is the top of its range
+	backoff.random = func() float64 { return 1 }
+	require.Equal(t, time.Second, backoff.Next())
+	require.Equal(t, 2*time.Second, backoff.Next())
+	require.Equal(t, 4*time.Second, backoff.Next())
+	require.Equal(t, 8*time.Second, backoff.Next())
+	require.Equal(t, 10*time.Second, backoff.Next())
+	require.Equal(t, 10*time.Second, backoff.Next())
+
+	// With full jitter it is half of it
+	backoff.random = func() float64 { return 0 }
+	require.Equal(t, 5*time.Second, backoff.Next())
+
+	backoff.Reset()
+	require.Equal(t, 500*time.Millisecond, backoff.Next())
+
+	// The ceiling holds no matter how many attempts failed
+	for i := 0; i < 100; i++ {
+		backoff.Next()
+	}
+	require.Equal(t, 5*time.Second, backoff.Next())
+}
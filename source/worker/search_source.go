@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+/*
+searchSource polls search.list for a query, the original source of the service.
+
+We query search.list with the following parameters set
+1. Parts = snippet, this fetches us the metadata we require
+2. Type = video, we only want data for videos
+3. OrderBy = date, we get the data ordered with most recent event at the start of the response
+4. PublishedAfter = <time.RFC3339 formated date> we will be fetching all the data that was created on and after this point of time.
+5. MaxResults = 50
+
+Assumption - search.list can return data for a video which has been updated
+
+If the cursor has a page token we fetch the next page of the previous call, from
+PreviousPublishedTime. Else it's a fresh call from PublishedTime, and the results
+are ordered by date so the most recent one becomes the PublishedTime of the next
+fresh call.
+*/
+type searchSource struct {
+	client *youtubeClient
+	query  string
+}
+
+func (s *searchSource) Fetch(ctx context.Context, cursor Cursor) (*Batch, error) {
+	logger := common.GetLogger().WithField("Query", s.query)
+
+	var err error
+	var results *youtube.SearchListResponse
+
+	if cursor.PageToken != "" {
+		logger.WithField("From", cursor.PublishedTime).WithField("NextPageToken", cursor.PageToken).Info("Fetching Youtube Data for next Page")
+		results, err = s.client.youtubeHandler.DoSearchListNextPage(ctx, s.query, []string{"snippet"}, "video", "date", cursor.PreviousPublishedTime.Format(time.RFC3339), cursor.PageToken, 50)
+	} else {
+		logger.WithField("From", cursor.PublishedTime).Info("Fetching Youtube Data for new DateTime")
+		results, err = s.client.youtubeHandler.DoSearchList(ctx, s.query, []string{"snippet"}, "video", "date", cursor.PublishedTime.Format(time.RFC3339), 50)
+	}
+	s.client.spend(youtube_handler.SearchListCost)
+
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Recieved Youtube Result")
+
+	videoMetadataList := []*storage.VideoMetadata{}
+	for _, result := range results.Items {
+		videoMetadata, err := newVideoMetadata(result)
+		if err != nil {
+			return nil, err
+		}
+		videoMetadataList = append(videoMetadataList, videoMetadata)
+	}
+
+	next := cursor
+	next.PageToken = results.NextPageToken
+
+	// The PublishedTime only moves on fresh calls, pages keep requesting from PreviousPublishedTime
+	if cursor.PageToken == "" {
+		for _, videoMetadata := range videoMetadataList {
+			if next.PublishedTime.Before(videoMetadata.PublishedAt) {
+				next.PreviousPublishedTime = next.PublishedTime
+				next.PublishedTime = videoMetadata.PublishedAt
+			}
+		}
+	}
+
+	return &Batch{
+		Videos: videoMetadataList,
+		Cursor: next,
+		More:   results.NextPageToken != "",
+	}, nil
+}
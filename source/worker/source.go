@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+)
+
+/*
+Source is a place a worker ingests videos from.
+
+Every execution the worker asks its source for the videos after its cursor. The
+source returns them normalized to VideoMetadata, along with the cursor to resume
+from, and the worker stores them - enriched, deduplicated on video_id and tagged
+with the query tag - before moving its cursor. A failed execution is retried from
+the same cursor, so a source only has to be able to fetch the same batch again.
+
+Sources -
+1. search - search.list for the query, see searchSource
+2. feed - an RSS or Atom feed, see feedSource
+3. file - JSON files dropped in a directory, see fileSource
+
+Another source, e.g. an internal catalog, implements Fetch and is added to newSource.
+*/
+type Source interface {
+	Fetch(ctx context.Context, cursor Cursor) (*Batch, error)
+}
+
+// Cursor is the position of a source, saved as the checkpoint of its query. A
+// source uses the fields it needs - search all three, a feed PublishedTime and the
+// file drop PageToken.
+type Cursor struct {
+	// Publish time of the most recent video ingested
+	PublishedTime time.Time
+	// The PublishedTime the pages of PageToken were requested from
+	PreviousPublishedTime time.Time
+	// Where the source stopped within its results
+	PageToken string
+}
+
+// Batch is the result of a fetch
+type Batch struct {
+	Videos []*storage.VideoMetadata
+	Cursor Cursor
+
+	// Set when the source has more videos ready, the worker fetches them without waiting for the poll interval
+	More bool
+}
+
+// invalidSourceDataError is returned when a source holds data that cannot be read.
+// Fetching it again would fail the same way, the worker stops on it.
+type invalidSourceDataError struct {
+	location string
+	err      error
+}
+
+func (e *invalidSourceDataError) Error() string {
+	return fmt.Sprintf("invalid data at %s: %v", e.location, e.err)
+}
+
+func (e *invalidSourceDataError) Unwrap() error {
+	return e.err
+}
+
+// Builds the source of a query. The search source calls the API with client, so
+// it follows the key of the worker.
+func newSource(queryConfig common.YoutubeQueryConfig, client *youtubeClient) (Source, error) {
+	switch queryConfig.Source {
+	case common.SearchSource, "":
+		return &searchSource{client: client, query: queryConfig.Query}, nil
+	case common.FeedSource:
+		return newFeedSource(queryConfig.Location), nil
+	case common.FileSource:
+		return &fileSource{directory: queryConfig.Location}, nil
+	default:
+		return nil, fmt.Errorf("unknown source %s", queryConfig.Source)
+	}
+}
@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+type WorkerStatus string
+
+const (
+	// The last iteration succeeded
+	WorkerStatusRunning WorkerStatus = "running"
+	// The last iterations failed, the worker is retrying with backoff
+	WorkerStatusDegraded WorkerStatus = "degraded"
+	// Every API key is out of quota, the worker waits for the reset
+	WorkerStatusWaitingForQuota WorkerStatus = "waiting_for_quota"
+	// The worker gave up on a fatal error, updating the query restarts it
+	WorkerStatusFailed WorkerStatus = "failed"
+	// The worker was stopped
+	WorkerStatusStopped WorkerStatus = "stopped"
+)
+
+// WorkerState is what operators see of a worker
+type WorkerState struct {
+	Name   string
+	Query  string
+	Status WorkerStatus
+
+	ConsecutiveFailures int
+	LastError           string
+	LastSuccessAt       time.Time
+	NextAttemptAt       time.Time
+}
+
+// workerStateTracker keeps the state of a worker, read by the admin API while the worker updates it
+type workerStateTracker struct {
+	mu    sync.Mutex
+	state WorkerState
+}
+
+func newWorkerStateTracker(name string, query string) *workerStateTracker {
+	return &workerStateTracker{
+		state: WorkerState{
+			Name:   name,
+			Query:  query,
+			Status: WorkerStatusRunning,
+		},
+	}
+}
+
+func (t *workerStateTracker) get() WorkerState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.state
+}
+
+func (t *workerStateTracker) succeeded(nextAttemptAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.state.Status = WorkerStatusRunning
+	t.state.ConsecutiveFailures = 0
+	t.state.LastError = ""
+	t.state.LastSuccessAt = time.Now().UTC()
+	t.state.NextAttemptAt = nextAttemptAt
+}
+
+func (t *workerStateTracker) failed(status WorkerStatus, err error, nextAttemptAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.state.Status = status
+	t.state.ConsecutiveFailures += 1
+	t.state.LastError = err.Error()
+	t.state.NextAttemptAt = nextAttemptAt
+}
+
+func (t *workerStateTracker) stopped() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.state.Status = WorkerStatusStopped
+	t.state.NextAttemptAt = time.Time{}
+}
@@ -0,0 +1,168 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+)
+
+/*
+StatisticsRefresher keeps the statistics of recent videos up to date and records
+how they grow.
+
+Every refresh interval it re-fetches the statistics of the videos published
+within maxAge through videos.list, up to MaxVideosListIDs videos per call.
+Each observation is stored as a snapshot and the counts of the video itself are
+updated, so the feed shows the latest ones. Videos older than maxAge are left
+alone, their counts barely move after the first days.
+
+API keys come from the same pool as the workers, errors are classified and
+retried the same way.
+*/
+type StatisticsRefresher struct {
+	youtubeClient
+
+	maxAge   time.Duration
+	interval time.Duration
+
+	sleepTime time.Duration
+	backoff   backoff
+
+	videoMetadataHandler storage.VideoMetadataInterface
+	snapshotHandler      storage.VideoStatisticsSnapshotInterface
+
+	*lifecycle
+}
+
+func NewStatisticsRefresher(apiKeyPool *youtube_handler.APIKeyPool, maxAge time.Duration, interval time.Duration, maxBackoff time.Duration) *StatisticsRefresher {
+	return &StatisticsRefresher{
+		youtubeClient:        newYoutubeClient(apiKeyPool),
+		maxAge:               maxAge,
+		interval:             interval,
+		backoff:              newBackoff(maxBackoff),
+		videoMetadataHandler: storage.NewVideoMetadataHandler(),
+		snapshotHandler:      storage.NewVideoStatisticsSnapshotHandler(),
+		lifecycle:            newLifecycle(),
+	}
+}
+
+// Refreshes the statistics every interval until it is stopped, calls made are cancelled with ctx
+func (r *StatisticsRefresher) Start(ctx context.Context) {
+	defer r.finish()
+
+	logger := common.GetLogger().WithField("MaxAge", r.maxAge)
+
+	for {
+		r.sleepTime = r.interval
+
+		err := r.syncAPIKey()
+		if err == nil {
+			err = r.Execute(ctx)
+		}
+
+		if ctx.Err() != nil {
+			logger.Info("Statistics refresher aborted")
+			return
+		}
+
+		var keysExhausted *youtube_handler.KeysExhaustedError
+		switch classifyError(err) {
+		case errorClassNone:
+			r.backoff.Reset()
+		case errorClassQuota:
+			if errors.As(err, &keysExhausted) {
+				logger.WithField("Until", keysExhausted.Until).Warn("Every API Key is out of quota, waiting for the reset")
+				r.sleepTime = time.Until(keysExhausted.Until)
+			} else {
+				logger.Info("API Key Quota Exceeded")
+				r.parkAPIKey()
+				r.sleepTime = 2 * time.Second
+			}
+		case errorClassRetryable:
+			r.sleepTime = r.backoff.Next()
+			logger.WithError(err).WithField("RetryIn", r.sleepTime).Warn("Error in statistics refresher, retrying")
+		default:
+			logger.WithError(err).Error("Fatal error in statistics refresher, exiting statistics refresher")
+			return
+		}
+
+		if !r.sleep(ctx, r.sleepTime) {
+			logger.Info("Statistics refresher stopped")
+			return
+		}
+	}
+}
+
+// Executes - Fetches the statistics of the videos younger than maxAge, stores a
+// snapshot of each and updates the counts of the videos
+func (r *StatisticsRefresher) Execute(ctx context.Context) error {
+	logger := common.GetLogger()
+
+	now := time.Now().UTC()
+	videoMetadataList, err := r.videoMetadataHandler.FindMetadataPublishedAfter(ctx, now.Add(-r.maxAge))
+	if err != nil {
+		return err
+	}
+
+	logger.WithField("VideoCount", len(videoMetadataList)).Info("Refreshing video statistics")
+
+	for start := 0; start < len(videoMetadataList); start += youtube_handler.MaxVideosListIDs {
+		end := start + youtube_handler.MaxVideosListIDs
+		if end > len(videoMetadataList) {
+			end = len(videoMetadataList)
+		}
+
+		err = r.refreshBatch(ctx, videoMetadataList[start:end])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Refreshes at most MaxVideosListIDs videos with a single videos.list call
+func (r *StatisticsRefresher) refreshBatch(ctx context.Context, batch []*storage.VideoMetadata) error {
+	requested := map[string]bool{}
+	ids := []string{}
+	for _, videoMetadata := range batch {
+		requested[videoMetadata.VideoID] = true
+		ids = append(ids, videoMetadata.VideoID)
+	}
+
+	response, err := r.youtubeHandler.DoVideosList(ctx, ids, []string{"statistics"})
+	r.spend(youtube_handler.VideosListCost)
+	if err != nil {
+		return err
+	}
+
+	observedAt := time.Now().UTC()
+
+	snapshots := []*storage.VideoStatisticsSnapshot{}
+	for _, video := range response.Items {
+		if !requested[video.Id] || video.Statistics == nil {
+			continue
+		}
+
+		snapshot := &storage.VideoStatisticsSnapshot{
+			VideoID:      video.Id,
+			Timestamp:    observedAt,
+			ViewCount:    int64(video.Statistics.ViewCount),
+			LikeCount:    int64(video.Statistics.LikeCount),
+			CommentCount: int64(video.Statistics.CommentCount),
+		}
+		snapshots = append(snapshots, snapshot)
+
+		// Only the statistics are written, ingestion may have updated the rest of the video since it was read
+		err = r.videoMetadataHandler.UpdateMetadataStatistics(ctx, snapshot)
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.snapshotHandler.BulkInsertSnapshots(ctx, snapshots)
+}
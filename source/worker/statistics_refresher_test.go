@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/mock_youtube"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/api/youtube/v3"
+)
+
+type StatisticsRefresherSuite struct {
+	suite.Suite
+	*require.Assertions
+	ctrl *gomock.Controller
+
+	mockVideoMetadataStore *mock_storage.MockVideoMetadataInterface
+	mockSnapshotStore      *mock_storage.MockVideoStatisticsSnapshotInterface
+	mockYoutubeHandler     *mock_youtube.MockYoutubeInterface
+
+	statisticsRefresher *StatisticsRefresher
+}
+
+func TestStatisticsRefresherSuite(t *testing.T) {
+	suite.Run(t, new(StatisticsRefresherSuite))
+}
+
+func (s *StatisticsRefresherSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.ctrl = gomock.NewController(s.T())
+
+	s.mockVideoMetadataStore = mock_storage.NewMockVideoMetadataInterface(s.ctrl)
+	s.mockSnapshotStore = mock_storage.NewMockVideoStatisticsSnapshotInterface(s.ctrl)
+	s.mockYoutubeHandler = mock_youtube.NewMockYoutubeInterface(s.ctrl)
+
+	s.statisticsRefresher = &StatisticsRefresher{
+		youtubeClient: youtubeClient{
+			youtubeHandler: s.mockYoutubeHandler,
+			apiKeyPool:     youtube_handler.NewAPIKeyPool([]string{"abcd"}, common.DefaultYoutubeDailyQuota),
+			apiKey:         "abcd",
+		},
+
+		maxAge:   72 * time.Hour,
+		interval: time.Hour,
+
+		videoMetadataHandler: s.mockVideoMetadataStore,
+		snapshotHandler:      s.mockSnapshotStore,
+	}
+}
+
+func (s *StatisticsRefresherSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *StatisticsRefresherSuite) TestExecute() {
+	start := time.Now().UTC()
+
+	s.mockVideoMetadataStore.EXPECT().FindMetadataPublishedAfter(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, timestamp time.Time) ([]*storage.VideoMetadata, error) {
+			// Only the videos younger than maxAge are refreshed
+			s.WithinDuration(start.Add(-72*time.Hour), timestamp, time.Minute)
+			return []*storage.VideoMetadata{
+				{VideoID: "video", Title: "title", ViewCount: 10},
+				{VideoID: "deleted"},
+			}, nil
+		})
+
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), []string{"video", "deleted"}, []string{"statistics"}).Return(&youtube.VideoListResponse{
+		Items: []*youtube.Video{{
+			Id:         "video",
+			Statistics: &youtube.VideoStatistics{ViewCount: 1000, LikeCount: 100, CommentCount: 10},
+		}},
+	}, nil)
+
+	s.mockVideoMetadataStore.EXPECT().UpdateMetadataStatistics(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, snapshot *storage.VideoStatisticsSnapshot) error {
+			s.Equal("video", snapshot.VideoID)
+			s.Equal(int64(1000), snapshot.ViewCount)
+			s.Equal(int64(100), snapshot.LikeCount)
+			s.Equal(int64(10), snapshot.CommentCount)
+			return nil
+		})
+
+	s.mockSnapshotStore.EXPECT().BulkInsertSnapshots(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, snapshots []*storage.VideoStatisticsSnapshot) error {
+			s.Len(snapshots, 1)
+			s.Equal("video", snapshots[0].VideoID)
+			s.Equal(int64(1000), snapshots[0].ViewCount)
+			s.Equal(int64(100), snapshots[0].LikeCount)
+			s.Equal(int64(10), snapshots[0].CommentCount)
+			s.False(snapshots[0].Timestamp.Before(start))
+			return nil
+		})
+
+	s.NoError(s.statisticsRefresher.Execute(context.Background()))
+	s.Equal(int64(youtube_handler.VideosListCost), s.statisticsRefresher.apiKeyPool.Snapshot().Keys[0].UnitsUsed)
+}
+
+func (s *StatisticsRefresherSuite) TestExecute_Batches() {
+	videoMetadataList := []*storage.VideoMetadata{}
+	for i := 0; i < 70; i++ {
+		videoMetadataList = append(videoMetadataList, &storage.VideoMetadata{VideoID: fmt.Sprintf("video%d", i)})
+	}
+	s.mockVideoMetadataStore.EXPECT().FindMetadataPublishedAfter(gomock.Any(), gomock.Any()).Return(videoMetadataList, nil)
+
+	batchSizes := []int{}
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), gomock.Any(), []string{"statistics"}).
+		DoAndReturn(func(ctx context.Context, ids []string, parts []string) (*youtube.VideoListResponse, error) {
+			batchSizes = append(batchSizes, len(ids))
+			return &youtube.VideoListResponse{}, nil
+		}).Times(2)
+	s.mockSnapshotStore.EXPECT().BulkInsertSnapshots(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	s.NoError(s.statisticsRefresher.Execute(context.Background()))
+	s.Equal([]int{50, 20}, batchSizes)
+}
+
+func (s *StatisticsRefresherSuite) TestExecute_Nothing() {
+	s.mockVideoMetadataStore.EXPECT().FindMetadataPublishedAfter(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	s.NoError(s.statisticsRefresher.Execute(context.Background()))
+}
+
+func (s *StatisticsRefresherSuite) TestExecute_Error() {
+	s.mockVideoMetadataStore.EXPECT().FindMetadataPublishedAfter(gomock.Any(), gomock.Any()).Return([]*storage.VideoMetadata{{VideoID: "video"}}, nil)
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), []string{"video"}, []string{"statistics"}).Return(nil, errors.New("error"))
+
+	s.Error(s.statisticsRefresher.Execute(context.Background()))
+}
@@ -0,0 +1,246 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/websub"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+)
+
+// How often the subscriptions are checked for one to request or renew
+const subscriptionCheckInterval = time.Minute
+
+// How long the hub has to verify a subscription request before it is sent again
+const verificationTimeout = 5 * time.Minute
+
+/*
+WebSubSubscriber keeps the followed channels subscribed on a WebSub hub and
+stores the uploads the hub pushes for them.
+
+How does it work?
+
+Every followed channel is subscribed to with its topic, the hub confirms the
+subscription by calling the callback of the service, which hands it to Verify.
+The hub grants a lease, the subscription is renewed once 90% of it has passed.
+Requests the hub does not verify in time are sent again.
+
+The callback checks the signature of every notification and passes the videos of
+its feed to Ingest. They are enriched, deduplicated on video_id and tagged like
+the uploads of the ChannelFollower, a push costs 1 unit of quota for videos.list
+instead of waiting for the next poll.
+
+Leases are only kept in memory, on startup every channel is subscribed to again.
+*/
+type WebSubSubscriber struct {
+	youtubeClient
+
+	hubClient   *websub.HubClient
+	callbackURL string
+	secret      string
+	lease       time.Duration
+	tag         string
+
+	sleepTime time.Duration
+	backoff   backoff
+
+	// Guards subscriptions, shared with the callback
+	mu            sync.Mutex
+	subscriptions map[string]*subscription
+
+	// Notifications are stored one at a time, the youtube client is not safe for concurrent use
+	ingestMu sync.Mutex
+
+	videoMetadataHandler storage.VideoMetadataInterface
+	channelHandler       storage.ChannelInterface
+	suggestionHandler    storage.SuggestionInterface
+
+	*lifecycle
+}
+
+// subscription is the state of the subscription to the topic of a channel
+type subscription struct {
+	channelID string
+
+	// Time of the last subscription request
+	requestedAt time.Time
+	// Zero until the hub verifies the subscription
+	renewAt   time.Time
+	expiresAt time.Time
+}
+
+func NewWebSubSubscriber(channelIDs []string, tag string, hubURL string, callbackURL string, secret string, lease time.Duration, apiKeyPool *youtube_handler.APIKeyPool, maxBackoff time.Duration) *WebSubSubscriber {
+	webSubSubscriber := &WebSubSubscriber{
+		youtubeClient:        newYoutubeClient(apiKeyPool),
+		hubClient:            websub.NewHubClient(hubURL),
+		callbackURL:          callbackURL,
+		secret:               secret,
+		lease:                lease,
+		tag:                  tag,
+		backoff:              newBackoff(maxBackoff),
+		subscriptions:        map[string]*subscription{},
+		videoMetadataHandler: storage.NewVideoMetadataHandler(),
+		channelHandler:       storage.NewChannelHandler(),
+		suggestionHandler:    storage.NewSuggestionHandler(),
+		lifecycle:            newLifecycle(),
+	}
+
+	for _, channelID := range channelIDs {
+		webSubSubscriber.subscriptions[websub.TopicURL(channelID)] = &subscription{channelID: channelID}
+	}
+
+	return webSubSubscriber
+}
+
+// Requests and renews the subscriptions until it is stopped, calls made are cancelled with ctx
+func (s *WebSubSubscriber) Start(ctx context.Context) {
+	defer s.finish()
+
+	logger := common.GetLogger().WithField("Callback", s.callbackURL)
+
+	for {
+		s.sleepTime = subscriptionCheckInterval
+
+		err := s.Execute(ctx)
+
+		if ctx.Err() != nil {
+			logger.Info("WebSub subscriber aborted")
+			return
+		}
+
+		if err == nil {
+			s.backoff.Reset()
+		} else {
+			s.sleepTime = s.backoff.Next()
+			logger.WithError(err).WithField("RetryIn", s.sleepTime).Warn("Error in WebSub subscriber, retrying")
+		}
+
+		if !s.sleep(ctx, s.sleepTime) {
+			logger.Info("WebSub subscriber stopped")
+			return
+		}
+	}
+}
+
+// Executes - Sends a subscription request for every channel that is not
+// subscribed yet or due for renewal
+func (s *WebSubSubscriber) Execute(ctx context.Context) error {
+	logger := common.GetLogger()
+
+	// The hub verifies a request through the callback before answering it, the
+	// lock cannot be held during the request
+	for _, topic := range s.dueTopics(time.Now()) {
+		logger.WithField("Topic", topic).Info("Requesting WebSub subscription")
+
+		err := s.hubClient.Subscribe(ctx, s.callbackURL, topic, s.secret, s.lease)
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.subscriptions[topic].requestedAt = time.Now()
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Topics to subscribe to at now, not verified or past their renewal, and not
+// waiting on the verification of a request
+func (s *WebSubSubscriber) dueTopics(now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	topics := []string{}
+	for topic, subscription := range s.subscriptions {
+		if now.Before(subscription.renewAt) || now.Sub(subscription.requestedAt) < verificationTimeout {
+			continue
+		}
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// Verify answers a verification of the hub, returns whether the service wants the
+// subscription. Subscriptions to followed channels are confirmed and their lease
+// recorded, unsubscriptions only for channels that are no longer followed.
+func (s *WebSubSubscriber) Verify(mode string, topic string, leaseSeconds int) bool {
+	logger := common.GetLogger().WithField("Topic", topic).WithField("Mode", mode)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription, followed := s.subscriptions[topic]
+
+	switch mode {
+	case websub.SubscribeMode:
+		if !followed {
+			logger.Warn("Refusing WebSub subscription to a channel that is not followed")
+			return false
+		}
+
+		lease := time.Duration(leaseSeconds) * time.Second
+		if lease <= 0 {
+			lease = s.lease
+		}
+
+		now := time.Now()
+		subscription.renewAt = now.Add(lease * 9 / 10)
+		subscription.expiresAt = now.Add(lease)
+
+		logger.WithField("ExpiresAt", subscription.expiresAt).Info("WebSub subscription verified")
+		return true
+	case websub.UnsubscribeMode:
+		return !followed
+	case websub.DeniedMode:
+		// The hub refused the subscription, it is requested again after the verification timeout
+		if followed {
+			logger.Warn("WebSub subscription denied by the hub")
+			subscription.renewAt = time.Time{}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// Ingest stores the videos of a verified notification. Videos of channels that
+// are not followed, pushed by a subscription left over from an earlier
+// configuration, are dropped.
+func (s *WebSubSubscriber) Ingest(ctx context.Context, videoMetadataList []*storage.VideoMetadata) error {
+	logger := common.GetLogger()
+
+	followedVideos := []*storage.VideoMetadata{}
+	s.mu.Lock()
+	for _, videoMetadata := range videoMetadataList {
+		if _, followed := s.subscriptions[websub.TopicURL(videoMetadata.ChannelID)]; !followed {
+			logger.WithField("VideoID", videoMetadata.VideoID).WithField("ChannelID", videoMetadata.ChannelID).Warn("Dropping notification of a channel that is not followed")
+			continue
+		}
+		followedVideos = append(followedVideos, videoMetadata)
+	}
+	s.mu.Unlock()
+
+	if len(followedVideos) == 0 {
+		return nil
+	}
+
+	s.ingestMu.Lock()
+	defer s.ingestMu.Unlock()
+
+	err := s.syncAPIKey()
+	if err != nil {
+		return err
+	}
+
+	logger.WithField("VideoCount", len(followedVideos)).Info("Storing videos pushed by the WebSub hub")
+
+	err = s.storeVideoMetadata(ctx, s.videoMetadataHandler, s.channelHandler, s.suggestionHandler, followedVideos, s.tag)
+	if youtube_handler.IsQuotaExceeded(err) {
+		s.parkAPIKey()
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"github.com/ashmeet13/YoutubeDataService/source/storage"
+	"github.com/ashmeet13/YoutubeDataService/source/websub"
+	"github.com/ashmeet13/YoutubeDataService/source/websub/fakehub"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/fakeyoutube"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// Runs the subscriber against the fake hub, the fake API and the memory store
+type WebSubSubscriberSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	ctx      context.Context
+	hub      *fakehub.Hub
+	fake     *fakeyoutube.Server
+	callback *httptest.Server
+
+	// The callback cannot be reached by the hub
+	unreachable bool
+
+	videoMetadataStore *storage.MemoryVideoMetadataImpl
+	subscriber         *WebSubSubscriber
+}
+
+func TestWebSubSubscriberSuite(t *testing.T) {
+	suite.Run(t, new(WebSubSubscriberSuite))
+}
+
+func (s *WebSubSubscriberSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.ctx = context.Background()
+	s.hub = fakehub.NewHub()
+	s.fake = fakeyoutube.NewServer()
+
+	// Stands in for the callback of the server, which answers the verifications
+	s.callback = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.unreachable {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		query := r.URL.Query()
+		leaseSeconds, _ := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if !s.subscriber.Verify(query.Get("hub.mode"), query.Get("hub.topic"), leaseSeconds) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(query.Get("hub.challenge")))
+	}))
+
+	store := storage.NewMemoryStore()
+	s.videoMetadataStore = storage.NewMemoryVideoMetadataImplWithStore(store)
+
+	s.subscriber = &WebSubSubscriber{
+		youtubeClient: youtubeClient{
+			apiKeyPool:     youtube_handler.NewAPIKeyPool([]string{"abcd"}, common.DefaultYoutubeDailyQuota),
+			apiKey:         "abcd",
+			youtubeHandler: youtube_handler.NewYoutubeHandlerWithEndpoint("abcd", s.fake.Endpoint()),
+		},
+
+		hubClient:   websub.NewHubClient(s.hub.URL()),
+		callbackURL: s.callback.URL,
+		secret:      "secret",
+		lease:       24 * time.Hour,
+		tag:         "channels",
+		subscriptions: map[string]*subscription{
+			websub.TopicURL("UCcricket"): {channelID: "UCcricket"},
+		},
+
+		videoMetadataHandler: s.videoMetadataStore,
+		channelHandler:       storage.NewMemoryChannelImplWithStore(store),
+		suggestionHandler:    storage.NewMemorySuggestionImplWithStore(store),
+	}
+}
+
+func (s *WebSubSubscriberSuite) TearDownTest() {
+	s.callback.Close()
+	s.hub.Close()
+	s.fake.Close()
+}
+
+func (s *WebSubSubscriberSuite) TestExecute_Subscribes() {
+	s.NoError(s.subscriber.Execute(s.ctx))
+
+	subscriptions := s.hub.Subscriptions()
+	s.Len(subscriptions, 1)
+	s.True(subscriptions[0].Verified)
+	s.Equal(websub.TopicURL("UCcricket"), subscriptions[0].Topic)
+	s.Equal("secret", subscriptions[0].Secret)
+	s.Equal(86400, subscriptions[0].LeaseSeconds)
+
+	subscription := s.subscriber.subscriptions[websub.TopicURL("UCcricket")]
+	s.WithinDuration(time.Now().Add(24*time.Hour), subscription.expiresAt, time.Minute)
+
+	// Verified subscriptions are left alone until their renewal
+	s.NoError(s.subscriber.Execute(s.ctx))
+	s.Equal(1, s.hub.RequestCount())
+
+	s.Empty(s.subscriber.dueTopics(subscription.renewAt.Add(-time.Second)))
+	s.Equal([]string{websub.TopicURL("UCcricket")}, s.subscriber.dueTopics(subscription.renewAt))
+}
+
+func (s *WebSubSubscriberSuite) TestExecute_Unverified() {
+	s.unreachable = true
+
+	s.NoError(s.subscriber.Execute(s.ctx))
+	s.Empty(s.hub.Subscriptions())
+
+	// The request is sent again once the hub had time to verify it
+	requestedAt := s.subscriber.subscriptions[websub.TopicURL("UCcricket")].requestedAt
+	s.Empty(s.subscriber.dueTopics(requestedAt.Add(time.Minute)))
+	s.Len(s.subscriber.dueTopics(requestedAt.Add(verificationTimeout)), 1)
+}
+
+func (s *WebSubSubscriberSuite) TestExecute_HubFailure() {
+	s.hub.FailNext(http.StatusServiceUnavailable)
+
+	err := s.subscriber.Execute(s.ctx)
+	s.Error(err)
+	s.Equal(errorClassRetryable, classifyError(err))
+
+	s.NoError(s.subscriber.Execute(s.ctx))
+	s.Len(s.hub.Subscriptions(), 1)
+}
+
+func (s *WebSubSubscriberSuite) TestVerify() {
+	topic := websub.TopicURL("UCcricket")
+
+	s.False(s.subscriber.Verify(websub.SubscribeMode, websub.TopicURL("UCother"), 3600))
+	s.False(s.subscriber.Verify(websub.UnsubscribeMode, topic, 0))
+	s.True(s.subscriber.Verify(websub.UnsubscribeMode, websub.TopicURL("UCother"), 0))
+
+	// The hub may grant a shorter lease than requested
+	s.True(s.subscriber.Verify(websub.SubscribeMode, topic, 3600))
+	s.WithinDuration(time.Now().Add(54*time.Minute), s.subscriber.subscriptions[topic].renewAt, time.Minute)
+
+	s.True(s.subscriber.Verify(websub.DeniedMode, topic, 0))
+	s.True(s.subscriber.subscriptions[topic].renewAt.IsZero())
+}
+
+func (s *WebSubSubscriberSuite) TestIngest() {
+	publishedAt := time.Now().UTC().Truncate(time.Second)
+	s.fake.AddVideos(fakeyoutube.Video{ID: "abc", Title: "Highlights", Description: "The final over", ChannelID: "UCcricket", PublishedAt: publishedAt, ViewCount: 10})
+
+	err := s.subscriber.Ingest(s.ctx, []*storage.VideoMetadata{
+		{VideoID: "abc", Title: "Highlights", ChannelID: "UCcricket", ChannelTitle: "Cricket TV", PublishedAt: publishedAt},
+		{VideoID: "def", Title: "Not followed", ChannelID: "UCother", PublishedAt: publishedAt},
+	})
+	s.NoError(err)
+
+	video, err := s.videoMetadataStore.FindOneMetadataWithVideoID(s.ctx, "abc")
+	s.NoError(err)
+	s.Equal([]string{"channels"}, video.QueryTags)
+	s.Equal("The final over", video.Description)
+	s.NotEmpty(video.DefaultThumbnailURL)
+	s.Equal(int64(10), video.ViewCount)
+
+	video, err = s.videoMetadataStore.FindOneMetadataWithVideoID(s.ctx, "def")
+	s.NoError(err)
+	s.Nil(video)
+}
+
+func (s *WebSubSubscriberSuite) TestIngest_Failure() {
+	s.fake.FailNext(fakeyoutube.BackendError())
+
+	err := s.subscriber.Ingest(s.ctx, []*storage.VideoMetadata{{VideoID: "abc", ChannelID: "UCcricket", PublishedAt: time.Now()}})
+	s.Error(err)
+
+	video, err := s.videoMetadataStore.FindOneMetadataWithVideoID(s.ctx, "abc")
+	s.NoError(err)
+	s.Nil(video)
+}
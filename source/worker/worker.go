@@ -1,7 +1,9 @@
 package worker
 
 import (
-	"strings"
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ashmeet13/YoutubeDataService/source/common"
@@ -11,183 +13,305 @@ import (
 )
 
 /*
-Worker is an async background worker that polls the Youtube API for Video events.
+Worker is an async background worker that polls the Source of an ingestion query for Video events.
 
 How does it work?
 
-We query the GET /search/list endpoint to fetch data with the following parameters set
-1. Parts = snippet, this fetches us the metadata we require
-2. Type = video, we only want data for videos
-3. OrderBy = date, we get the data ordered with most recent event at the start of the response
-4. PublishedAfter = <time.RFC3339 formated date> we will be fetching all the data that was created on and after this point of time.
-5. MaxResults = 50
+Every execution the worker fetches the videos after its cursor from the source of
+the query, see Source. They are stored in our DB, tagged with the query tag, by
+storeVideoMetadata. Only once they are stored the cursor of the worker moves to the
+one returned with them, so a failed execution is retried from the same position.
 
-Assumption - /search/list can return data for a video which has been updated
+While the source has more videos ready the worker fetches them after 5 seconds,
+otherwise it waits for the poll interval of the query.
 
-We iterate over the result and create structs to insert into our DB.
-While doing so we make a DB read call to check if the video already exists.
+After every successful execution the cursor of the worker is saved as a checkpoint
+for the query. On startup the worker resumes from the checkpoint, so videos
+published while the service was down are still collected. If a maximum catch up
+window is configured, a checkpoint older than the window is moved forward to now - window.
 
-If it does and the published at of the new result is after the database doc, we update the doc for the video id.
-Else, we add the docs in a list to bulk insert in the end.
-
-While iterating we also check if the results were for a call the nextPage of previous call or is it a fresh call
-If it's a fresh call then we have recieved data ordered by date and we would use this data to set the next timestamp
-of our call
-
-Finally we check
+A worker runs until Stop is called, the Manager stops it when its query is paused,
+updated or deleted, or the service shuts down. Stopping lets the current execution
+finish, cancelling the context passed to Start cuts it short. Failed iterations are classified by classifyError, retryable
+ones are retried with a jittered exponential backoff up to maxBackoff and only
+fatal ones stop the worker. The worker reports how it is doing through State.
 */
 
 type WorkerHandler struct {
-	query string
+	youtubeClient
+
+	name string
+	tag  string
+
+	source Source
+	cursor Cursor
 
-	apiKeys       []string
-	nextPageToken string
+	pollInterval time.Duration
+	sleepTime    time.Duration
 
-	sleepTime   int
-	apiKeyIndex int
+	maxCatchUp time.Duration
 
-	currentPublishedTime  time.Time
-	previousPublishedTime time.Time
+	backoff backoff
+	state   *workerStateTracker
 
-	youtubeHandler       youtube_handler.YoutubeInterface
 	videoMetadataHandler storage.VideoMetadataInterface
+	checkpointHandler    storage.CheckpointInterface
+	channelHandler       storage.ChannelInterface
+	suggestionHandler    storage.SuggestionInterface
+
+	*lifecycle
 }
 
-func NewWorkerHandler(query string, apiKeys []string) (*WorkerHandler, error) {
-	return &WorkerHandler{
-		query:                query,
-		currentPublishedTime: time.Now().UTC(),
-		apiKeys:              apiKeys,
-		apiKeyIndex:          0,
-		sleepTime:            10,
-		youtubeHandler:       youtube_handler.NewYoutubeHandler(apiKeys[0]),
-		videoMetadataHandler: storage.NewVideoMetadataImpl(),
-		nextPageToken:        "",
-	}, nil
+func NewWorkerHandler(ctx context.Context, queryConfig common.YoutubeQueryConfig, apiKeyPool *youtube_handler.APIKeyPool, maxCatchUp time.Duration, maxBackoff time.Duration) (*WorkerHandler, error) {
+	workerHandler := &WorkerHandler{
+		youtubeClient:        newYoutubeClient(apiKeyPool),
+		name:                 queryConfig.Name,
+		tag:                  queryConfig.Tag,
+		cursor:               Cursor{PublishedTime: time.Now().UTC()},
+		pollInterval:         queryConfig.PollInterval,
+		sleepTime:            queryConfig.PollInterval,
+		maxCatchUp:           maxCatchUp,
+		backoff:              newBackoff(maxBackoff),
+		state:                newWorkerStateTracker(queryConfig.Name, queryConfig.Query),
+		videoMetadataHandler: storage.NewVideoMetadataHandler(),
+		checkpointHandler:    storage.NewCheckpointHandler(),
+		channelHandler:       storage.NewChannelHandler(),
+		suggestionHandler:    storage.NewSuggestionHandler(),
+		lifecycle:            newLifecycle(),
+	}
+
+	source, err := newSource(queryConfig, &workerHandler.youtubeClient)
+	if err != nil {
+		return nil, err
+	}
+	workerHandler.source = source
+
+	err = workerHandler.RestoreCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return workerHandler, nil
 }
 
-// Starts the worker and executes it at an interval of 10 seconds or 5 seconds
-func (h *WorkerHandler) Start() {
-	logger := common.GetLogger()
+// Starts the worker and executes it at the poll interval of the query, or every 5 seconds while there are more pages.
+// Calls made by an execution are cancelled with ctx.
+func (h *WorkerHandler) Start(ctx context.Context) {
+	defer h.finish()
+
+	logger := common.GetLogger().WithField("QueryName", h.name)
 
 	for {
-		err := h.Execute()
-		if err != nil {
-			if strings.Contains(err.Error(), "quotaExceeded") {
-				logger.Info("API Key Quota Exceeded")
-				h.youtubeHandler.UpdateAPIKey(h.FetchNextAPIKey())
-				h.sleepTime = 2
+		err := h.syncAPIKey()
+		if err == nil {
+			err = h.Execute(ctx)
+		}
+
+		if ctx.Err() != nil {
+			logger.Info("Worker aborted")
+			h.state.stopped()
+			return
+		}
+
+		var keysExhausted *youtube_handler.KeysExhaustedError
+		switch classifyError(err) {
+		case errorClassNone:
+			h.backoff.Reset()
+			h.state.succeeded(time.Now().UTC().Add(h.sleepTime))
+
+			err = h.SaveCheckpoint(ctx)
+			if err != nil {
+				logger.WithError(err).Error("Failed to save worker checkpoint")
+			}
+		case errorClassQuota:
+			if errors.As(err, &keysExhausted) {
+				logger.WithField("Until", keysExhausted.Until).Warn("Every API Key is out of quota, waiting for the reset")
+				h.sleepTime = time.Until(keysExhausted.Until)
+				h.state.failed(WorkerStatusWaitingForQuota, err, keysExhausted.Until)
 			} else {
-				logger.WithError(err).Error("Error in worker, exiting worker")
-				break
+				logger.Info("API Key Quota Exceeded")
+				h.parkAPIKey()
+				h.sleepTime = 2 * time.Second
 			}
+		case errorClassRetryable:
+			h.sleepTime = h.backoff.Next()
+			logger.WithError(err).WithField("RetryIn", h.sleepTime).Warn("Error in worker, retrying")
+			h.state.failed(WorkerStatusDegraded, err, time.Now().UTC().Add(h.sleepTime))
+		default:
+			logger.WithError(err).Error("Fatal error in worker, exiting worker")
+			h.state.failed(WorkerStatusFailed, err, time.Time{})
+			return
 		}
+
 		logger.WithField("SleepDuration", h.sleepTime).Info("Worker Execution Completed")
-		time.Sleep(time.Duration(h.sleepTime) * time.Second)
+
+		if !h.sleep(ctx, h.sleepTime) {
+			logger.Info("Worker stopped")
+			h.state.stopped()
+			return
+		}
 	}
+}
 
+// State returns how the worker is doing, safe to call while the worker runs
+func (h *WorkerHandler) State() WorkerState {
+	return h.state.get()
 }
 
-// Updates the API key in case quota was exceeded in the current key
-func (h *WorkerHandler) FetchNextAPIKey() string {
-	logger := common.GetLogger()
-	h.apiKeyIndex += 1
-	if h.apiKeyIndex == len(h.apiKeys) {
-		h.apiKeyIndex = 0
+// Restores the cursor of the worker from the checkpoint of the query, if there is one
+func (h *WorkerHandler) RestoreCheckpoint(ctx context.Context) error {
+	logger := common.GetLogger().WithField("QueryName", h.name)
+
+	checkpoint, err := h.checkpointHandler.ReadCheckpoint(ctx, h.name)
+	if err != nil {
+		return err
+	}
+
+	if checkpoint == nil {
+		logger.WithField("From", h.cursor.PublishedTime).Info("No checkpoint found, starting fresh")
+		return nil
+	}
+
+	h.cursor = Cursor{
+		PublishedTime:         checkpoint.CurrentPublishedTime,
+		PreviousPublishedTime: checkpoint.PreviousPublishedTime,
+		PageToken:             checkpoint.NextPageToken,
 	}
 
-	logger.WithField("NewAPIKeyIndex", h.apiKeyIndex).WithField("TotalKeys", len(h.apiKeys)).Info("API Key Updated")
-	return h.apiKeys[h.apiKeyIndex]
+	// Don't catch up further back than the window. The page token belongs to the
+	// old window so it is dropped along with it.
+	if h.maxCatchUp > 0 {
+		catchUpFrom := time.Now().UTC().Add(-h.maxCatchUp)
+		if h.cursor.PublishedTime.Before(catchUpFrom) {
+			logger.WithField("Checkpoint", h.cursor.PublishedTime).
+				WithField("MaxCatchUp", h.maxCatchUp).
+				Info("Checkpoint is older than the catch up window")
+
+			h.cursor = Cursor{PublishedTime: catchUpFrom}
+		}
+	}
+
+	logger.WithField("From", h.cursor.PublishedTime).
+		WithField("NextPageToken", h.cursor.PageToken).
+		Info("Resuming from checkpoint")
+	return nil
+}
+
+// Saves the cursor of the worker as the checkpoint of the query
+func (h *WorkerHandler) SaveCheckpoint(ctx context.Context) error {
+	return h.checkpointHandler.SaveCheckpoint(ctx, &storage.Checkpoint{
+		QueryID:               h.name,
+		CurrentPublishedTime:  h.cursor.PublishedTime,
+		PreviousPublishedTime: h.cursor.PreviousPublishedTime,
+		NextPageToken:         h.cursor.PageToken,
+		UpdatedAt:             time.Now().UTC(),
+	})
 }
 
 // Executes - To Fetch Data and Publish to DB
-func (h *WorkerHandler) Execute() error {
+func (h *WorkerHandler) Execute(ctx context.Context) error {
 	// Reset sleep time for next call
-	h.sleepTime = 10
+	h.sleepTime = h.pollInterval
 
-	logger := common.GetLogger()
-
-	var err error
-	var results *youtube.SearchListResponse
-
-	// 1. If NextPageToken present
-	//			Request Paginated Response from PreviousPublishedAt, i.e. previous call next page
-	//    Else,
-	// 			Request Response from CurrentPublishedAt, i.e. fresh call
-	if h.nextPageToken != "" {
-		logger.WithField("From", h.currentPublishedTime).WithField("NextPageToken", h.nextPageToken).Info("Fetching Youtube Data for next Page")
-		results, err = h.youtubeHandler.DoSearchListNextPage(h.query, []string{"snippet"}, "video", "date", h.previousPublishedTime.Format(time.RFC3339), h.nextPageToken, 50)
-	} else {
-		logger.WithField("From", h.currentPublishedTime).Info("Fetching Youtube Data for new DateTime")
-		results, err = h.youtubeHandler.DoSearchList(h.query, []string{"snippet"}, "video", "date", h.currentPublishedTime.Format(time.RFC3339), 50)
+	batch, err := h.source.Fetch(ctx, h.cursor)
+	if err != nil {
+		return err
 	}
 
+	err = h.storeVideoMetadata(ctx, h.videoMetadataHandler, h.channelHandler, h.suggestionHandler, batch.Videos, h.tag)
 	if err != nil {
 		return err
 	}
 
-	logger.Info("Recieved Youtube Result")
+	h.cursor = batch.Cursor
+	if batch.More {
+		h.sleepTime = 5 * time.Second
+	}
 
-	metadataToInsert := []*storage.VideoMetadata{}
-	// 2. Iterate over the results.Items to fetch required Data
-	for _, result := range results.Items {
+	return nil
+}
 
-		// 3. Format it into required Struct
+// Stores the search results in the DB, tagged with the query tag
+func (c *youtubeClient) storeSearchResults(ctx context.Context, videoMetadataHandler storage.VideoMetadataInterface, channelHandler storage.ChannelInterface, suggestionHandler storage.SuggestionInterface, results []*youtube.SearchResult, tag string) error {
+	videoMetadataList := []*storage.VideoMetadata{}
+	for _, result := range results {
 		videoMetadata, err := newVideoMetadata(result)
 		if err != nil {
 			return err
 		}
+		videoMetadataList = append(videoMetadataList, videoMetadata)
+	}
 
-		// 4. Check if video already present
-		storageMetadata, err := h.videoMetadataHandler.FindOneMetadataWithVideoID(videoMetadata.VideoID)
-		if err != nil {
-			return err
-		}
+	return c.storeVideoMetadata(ctx, videoMetadataHandler, channelHandler, suggestionHandler, videoMetadataList, tag)
+}
 
-		if storageMetadata != nil {
-			// 5. If present and has been updated, update value in DB
-			if storageMetadata.PublishedAt.Before(videoMetadata.PublishedAt) {
-				logger.WithField("VideoID", videoMetadata.VideoID).Info("Updating Document")
-				h.videoMetadataHandler.UpdateOneMetadata(videoMetadata.VideoID, videoMetadata)
-			}
-		} else {
-			// 6. If not present, add in list to bulk insert later
-			metadataToInsert = append(metadataToInsert, videoMetadata)
-		}
+// Stores videos found by any source in the DB, tagged with the source tag
+//  1. Enrich them with the details of videos.list
+//  2. Store their channels with the details of channels.list
+//  3. Upsert them in one round trip, a stored video is replaced if it has been updated
+//     and tagged if it was not yet tagged by this source
+//  4. Index the titles of the stored videos for suggestions
+//
+// A video that fails to be stored fails the whole call, so the caller retries the batch
+func (c *youtubeClient) storeVideoMetadata(ctx context.Context, videoMetadataHandler storage.VideoMetadataInterface, channelHandler storage.ChannelInterface, suggestionHandler storage.SuggestionInterface, videoMetadataList []*storage.VideoMetadata, tag string) error {
+	logger := common.GetLogger()
 
-		// 7. If no nextPageToken was used, update the currentPublishedTime to use for next call fresh call
-		if h.nextPageToken == "" {
-			publishedAt, err := time.Parse(time.RFC3339, result.Snippet.PublishedAt)
-			if err != nil {
-				return err
-			}
+	err := c.enrich(ctx, videoMetadataList)
+	if err != nil {
+		return err
+	}
 
-			// Save the publishedAt time for the next call. This will be only used
-			// if there is no next page token. If there is a next page token, previousPublishedAt
-			// along with the token will be used.
-			if h.currentPublishedTime.Before(publishedAt) {
-				h.previousPublishedTime = h.currentPublishedTime
-				h.currentPublishedTime = publishedAt
-			}
-		}
+	err = c.syncChannels(ctx, channelHandler, videoMetadataList)
+	if err != nil {
+		return err
 	}
 
-	// 8. Reset token for next call, if a page token is available set it and reduce sleep time
-	h.nextPageToken = ""
-	if results.NextPageToken != "" {
-		h.nextPageToken = results.NextPageToken
-		h.sleepTime = 5
+	if len(videoMetadataList) == 0 {
+		return nil
 	}
 
-	// 9. Bulk Insert into DB
-	if len(metadataToInsert) > 0 {
-		logger.WithField("InsertDocumentCount", len(metadataToInsert)).Info("Publishing documents to database")
+	for _, videoMetadata := range videoMetadataList {
+		videoMetadata.QueryTags = []string{tag}
+	}
 
-		err := h.videoMetadataHandler.BulkInsertMetadata(metadataToInsert)
-		if err != nil {
-			return err
+	results, err := videoMetadataHandler.BulkUpsertMetadata(ctx, videoMetadataList)
+	if err != nil {
+		return err
+	}
+
+	var insertedCount, failedCount int
+	var firstErr error
+	storedVideos := []*storage.VideoMetadata{}
+	for i, result := range results {
+		if result.Outcome != storage.UpsertFailed {
+			storedVideos = append(storedVideos, videoMetadataList[i])
+		}
+
+		switch result.Outcome {
+		case storage.UpsertInserted:
+			insertedCount++
+		case storage.UpsertFailed:
+			failedCount++
+			if firstErr == nil {
+				firstErr = result.Err
+			}
+			logger.WithError(result.Err).WithField("VideoID", result.VideoID).Warn("Failed to store video")
 		}
 	}
+
+	logger.WithField("InsertedCount", insertedCount).
+		WithField("MatchedCount", len(results)-insertedCount-failedCount).
+		WithField("FailedCount", failedCount).
+		Info("Published documents to database")
+
+	err = suggestionHandler.IndexTitles(ctx, storedVideos)
+	if err != nil {
+		return err
+	}
+
+	if failedCount > 0 {
+		return fmt.Errorf("failed to store %d of %d videos: %w", failedCount, len(results), firstErr)
+	}
 	return nil
 }
 
@@ -199,29 +323,37 @@ func newVideoMetadata(result *youtube.SearchResult) (*storage.VideoMetadata, err
 	}
 
 	videoData := &storage.VideoMetadata{
-		VideoID:     result.Id.VideoId,
-		Title:       result.Snippet.Title,
-		Description: result.Snippet.Description,
-		PublishedAt: publishedAtTime,
+		VideoID:      result.Id.VideoId,
+		Title:        result.Snippet.Title,
+		Description:  result.Snippet.Description,
+		PublishedAt:  publishedAtTime,
+		ChannelID:    result.Snippet.ChannelId,
+		ChannelTitle: result.Snippet.ChannelTitle,
 	}
 
-	if result.Snippet.Thumbnails != nil {
-		if result.Snippet.Thumbnails.Default != nil {
-			videoData.DefaultThumbnailURL = result.Snippet.Thumbnails.Default.Url
-		}
-		if result.Snippet.Thumbnails.Maxres != nil {
-			videoData.MaxresThumbnailURL = result.Snippet.Thumbnails.Maxres.Url
-		}
-		if result.Snippet.Thumbnails.High != nil {
-			videoData.HighThumbnailURL = result.Snippet.Thumbnails.High.Url
-		}
-		if result.Snippet.Thumbnails.Medium != nil {
-			videoData.MediumThumbnailURL = result.Snippet.Thumbnails.Medium.Url
-		}
-		if result.Snippet.Thumbnails.Standard != nil {
-			videoData.StandardThumbnailURL = result.Snippet.Thumbnails.Standard.Url
-		}
-	}
+	applyThumbnails(videoData, result.Snippet.Thumbnails)
 
 	return videoData, nil
 }
+
+func applyThumbnails(videoData *storage.VideoMetadata, thumbnails *youtube.ThumbnailDetails) {
+	if thumbnails == nil {
+		return
+	}
+
+	if thumbnails.Default != nil {
+		videoData.DefaultThumbnailURL = thumbnails.Default.Url
+	}
+	if thumbnails.Maxres != nil {
+		videoData.MaxresThumbnailURL = thumbnails.Maxres.Url
+	}
+	if thumbnails.High != nil {
+		videoData.HighThumbnailURL = thumbnails.High.Url
+	}
+	if thumbnails.Medium != nil {
+		videoData.MediumThumbnailURL = thumbnails.Medium.Url
+	}
+	if thumbnails.Standard != nil {
+		videoData.StandardThumbnailURL = thumbnails.Standard.Url
+	}
+}
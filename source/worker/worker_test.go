@@ -1,11 +1,15 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/ashmeet13/YoutubeDataService/source/common"
 	"github.com/ashmeet13/YoutubeDataService/source/storage"
 	"github.com/ashmeet13/YoutubeDataService/source/storage/mock_storage"
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
 	"github.com/ashmeet13/YoutubeDataService/source/youtube/mock_youtube"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -20,6 +24,7 @@ type WorkerHandlerSuite struct {
 
 	mockVideoMetadataStore *mock_storage.MockVideoMetadataInterface
 	mockYoutubeHandler     *mock_youtube.MockYoutubeInterface
+	mockCheckpointStore    *mock_storage.MockCheckpointInterface
 
 	workerHandler *WorkerHandler
 }
@@ -34,43 +39,117 @@ func (s *WorkerHandlerSuite) SetupSuite() {
 
 	s.mockVideoMetadataStore = mock_storage.NewMockVideoMetadataInterface(s.ctrl)
 	s.mockYoutubeHandler = mock_youtube.NewMockYoutubeInterface(s.ctrl)
+	s.mockCheckpointStore = mock_storage.NewMockCheckpointInterface(s.ctrl)
 
 	s.workerHandler = &WorkerHandler{
 		videoMetadataHandler: s.mockVideoMetadataStore,
-		youtubeHandler:       s.mockYoutubeHandler,
+		checkpointHandler:    s.mockCheckpointStore,
+		suggestionHandler:    storage.NewMemorySuggestionImplWithStore(storage.NewMemoryStore()),
 
-		apiKeys:     []string{"abcd", "edfg"},
-		apiKeyIndex: 0,
+		youtubeClient: youtubeClient{
+			youtubeHandler: s.mockYoutubeHandler,
+			apiKeyPool:     youtube_handler.NewAPIKeyPool([]string{"abcd", "edfg"}, common.DefaultYoutubeDailyQuota),
+			apiKey:         "abcd",
+		},
 
-		query: "query",
+		name:         "name",
+		tag:          "tag",
+		pollInterval: 10 * time.Second,
 	}
+	s.workerHandler.source = &searchSource{client: &s.workerHandler.youtubeClient, query: "query"}
 }
 
 func (s *WorkerHandlerSuite) TearDownSuite() {
 	s.ctrl.Finish()
 }
 
-func (s *WorkerHandlerSuite) TestFetchNextAPIKey() {
-	apiKey := s.workerHandler.FetchNextAPIKey()
+func (s *WorkerHandlerSuite) TestParkAPIKey() {
+	pool := youtube_handler.NewAPIKeyPool([]string{"abcd", "edfg"}, common.DefaultYoutubeDailyQuota)
+	client := &youtubeClient{
+		youtubeHandler: s.mockYoutubeHandler,
+		apiKeyPool:     pool,
+		apiKey:         "abcd",
+	}
+
+	client.parkAPIKey()
+
+	s.mockYoutubeHandler.EXPECT().UpdateAPIKey("edfg").Return(nil)
+	s.NoError(client.syncAPIKey())
+	s.Equal("edfg", client.apiKey)
+
+	// Once every key is parked the client waits instead of cycling back
+	client.parkAPIKey()
+
+	var keysExhausted *youtube_handler.KeysExhaustedError
+	s.ErrorAs(client.syncAPIKey(), &keysExhausted)
+	s.Equal("edfg", client.apiKey)
+}
+
+func (s *WorkerHandlerSuite) TestParkAPIKey_ParkedByAnotherWorker() {
+	pool := youtube_handler.NewAPIKeyPool([]string{"abcd", "edfg"}, common.DefaultYoutubeDailyQuota)
+	client := &youtubeClient{
+		youtubeHandler: s.mockYoutubeHandler,
+		apiKeyPool:     pool,
+		apiKey:         "abcd",
+	}
+	other := &youtubeClient{
+		youtubeHandler: s.mockYoutubeHandler,
+		apiKeyPool:     pool,
+		apiKey:         "abcd",
+	}
+
+	// Both workers find the same key out of quota, only that key is parked
+	other.parkAPIKey()
+	client.parkAPIKey()
+
+	s.mockYoutubeHandler.EXPECT().UpdateAPIKey("edfg").Return(nil).Times(2)
+	s.NoError(other.syncAPIKey())
+	s.NoError(client.syncAPIKey())
+	s.Equal("edfg", other.apiKey)
+	s.Equal("edfg", client.apiKey)
+}
+
+func (s *WorkerHandlerSuite) TestExecute_SpendsQuota() {
+	unitsUsed := s.workerHandler.apiKeyPool.Snapshot().Keys[0].UnitsUsed
 
-	s.Equal(apiKey, "edfg")
-	s.Equal(s.workerHandler.apiKeyIndex, 1)
+	s.workerHandler.cursor.PageToken = ""
+	s.mockYoutubeHandler.EXPECT().DoSearchList(gomock.Any(), "query", []string{"snippet"}, "video", "date", gomock.Any(), 50).
+		Return(&youtube.SearchListResponse{}, nil)
 
-	apiKey = s.workerHandler.FetchNextAPIKey()
+	s.NoError(s.workerHandler.Execute(context.Background()))
+	s.Equal(unitsUsed+youtube_handler.SearchListCost, s.workerHandler.apiKeyPool.Snapshot().Keys[0].UnitsUsed)
+}
 
-	s.Equal(apiKey, "abcd")
-	s.Equal(s.workerHandler.apiKeyIndex, 0)
+func (s *WorkerHandlerSuite) TestExecute_StoreFailureKeepsPosition() {
+	currentPublishedTime := time.Now().UTC().Truncate(time.Second)
+	s.workerHandler.cursor.PublishedTime = currentPublishedTime
+	s.workerHandler.cursor.PageToken = ""
+
+	s.mockYoutubeHandler.EXPECT().DoSearchList(gomock.Any(), "query", []string{"snippet"}, "video", "date", gomock.Any(), 50).
+		Return(&youtube.SearchListResponse{
+			Items:         []*youtube.SearchResult{{Id: &youtube.ResourceId{VideoId: "new_video_id"}, Snippet: &youtube.SearchResultSnippet{PublishedAt: currentPublishedTime.Add(time.Minute).Format(time.RFC3339)}}},
+			NextPageToken: "ABCD",
+		}, nil)
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), []string{"new_video_id"}, enrichmentParts).Return(&youtube.VideoListResponse{}, nil)
+	s.mockVideoMetadataStore.EXPECT().BulkUpsertMetadata(gomock.Any(), gomock.Any()).
+		Return([]*storage.UpsertResult{{VideoID: "new_video_id", Outcome: storage.UpsertFailed, Err: errors.New("dummy test error")}}, nil)
+
+	s.Error(s.workerHandler.Execute(context.Background()))
+
+	// The next execution retries the same call
+	s.Equal(currentPublishedTime, s.workerHandler.cursor.PublishedTime)
+	s.Equal("", s.workerHandler.cursor.PageToken)
 }
 
 func (s *WorkerHandlerSuite) TestExecute_FreshCall() {
-	s.workerHandler.nextPageToken = ""
+	s.workerHandler.cursor.PageToken = ""
 
 	currentPublishedTime := time.Now().UTC()
-	s.workerHandler.currentPublishedTime = currentPublishedTime
+	s.workerHandler.cursor.PublishedTime = currentPublishedTime
 
-	expectedDate := s.workerHandler.currentPublishedTime.Format(time.RFC3339)
+	expectedDate := s.workerHandler.cursor.PublishedTime.Format(time.RFC3339)
 
-	testPublishedAtTime := s.workerHandler.currentPublishedTime.Add(5 * time.Second)
+	testPublishedAtTime := s.workerHandler.cursor.PublishedTime.Add(5 * time.Second)
 
 	results := &youtube.SearchListResponse{
 		Items: []*youtube.SearchResult{
@@ -100,32 +179,34 @@ func (s *WorkerHandlerSuite) TestExecute_FreshCall() {
 		Description:      "test_description",
 		PublishedAt:      expectedNewDate,
 		HighThumbnailURL: "test_high_url",
+		QueryTags:        []string{"tag"},
 	}
 
-	s.mockYoutubeHandler.EXPECT().DoSearchList("query", []string{"snippet"}, "video", "date", expectedDate, 50).Return(results, nil)
-	s.mockVideoMetadataStore.EXPECT().FindOneMetadataWithVideoID("test_video_id").Return(nil, nil)
-	s.mockVideoMetadataStore.EXPECT().BulkInsertMetadata([]*storage.VideoMetadata{metadataVideo}).Return(nil)
+	s.mockYoutubeHandler.EXPECT().DoSearchList(gomock.Any(), "query", []string{"snippet"}, "video", "date", expectedDate, 50).Return(results, nil)
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), []string{"test_video_id"}, enrichmentParts).Return(&youtube.VideoListResponse{}, nil)
+	s.mockVideoMetadataStore.EXPECT().BulkUpsertMetadata(gomock.Any(), []*storage.VideoMetadata{metadataVideo}).
+		Return([]*storage.UpsertResult{{VideoID: "test_video_id", Outcome: storage.UpsertInserted}}, nil)
 
-	s.workerHandler.Execute()
+	s.workerHandler.Execute(context.Background())
 
-	s.Equal("", s.workerHandler.nextPageToken)
-	s.Equal(10, s.workerHandler.sleepTime)
-	s.Equal(expectedNewDate, s.workerHandler.currentPublishedTime)
-	s.Equal(currentPublishedTime, s.workerHandler.previousPublishedTime)
+	s.Equal("", s.workerHandler.cursor.PageToken)
+	s.Equal(10*time.Second, s.workerHandler.sleepTime)
+	s.Equal(expectedNewDate, s.workerHandler.cursor.PublishedTime)
+	s.Equal(currentPublishedTime, s.workerHandler.cursor.PreviousPublishedTime)
 }
 
 func (s *WorkerHandlerSuite) TestExecute_PageCall() {
-	s.workerHandler.nextPageToken = "ABCD"
+	s.workerHandler.cursor.PageToken = "ABCD"
 
 	currentPublishedTime := time.Now().UTC()
-	s.workerHandler.currentPublishedTime = currentPublishedTime
+	s.workerHandler.cursor.PublishedTime = currentPublishedTime
 
 	previousPublishedTime := currentPublishedTime.Add(-5 * time.Second)
-	s.workerHandler.previousPublishedTime = previousPublishedTime
+	s.workerHandler.cursor.PreviousPublishedTime = previousPublishedTime
 
-	expectedDate := s.workerHandler.previousPublishedTime.Format(time.RFC3339)
+	expectedDate := s.workerHandler.cursor.PreviousPublishedTime.Format(time.RFC3339)
 
-	testPublishedAtTime := s.workerHandler.currentPublishedTime.Add(5 * time.Second)
+	testPublishedAtTime := s.workerHandler.cursor.PublishedTime.Add(5 * time.Second)
 
 	results := &youtube.SearchListResponse{
 		Items: []*youtube.SearchResult{
@@ -155,16 +236,128 @@ func (s *WorkerHandlerSuite) TestExecute_PageCall() {
 		Description:      "test_description",
 		PublishedAt:      expectedNewDate,
 		HighThumbnailURL: "test_high_url",
+		QueryTags:        []string{"tag"},
+	}
+
+	s.mockYoutubeHandler.EXPECT().DoSearchListNextPage(gomock.Any(), "query", []string{"snippet"}, "video", "date", expectedDate, "ABCD", 50).Return(results, nil)
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), []string{"test_video_id"}, enrichmentParts).Return(&youtube.VideoListResponse{}, nil)
+	s.mockVideoMetadataStore.EXPECT().BulkUpsertMetadata(gomock.Any(), []*storage.VideoMetadata{metadataVideo}).
+		Return([]*storage.UpsertResult{{VideoID: "test_video_id", Outcome: storage.UpsertInserted}}, nil)
+
+	s.workerHandler.Execute(context.Background())
+
+	s.Equal("", s.workerHandler.cursor.PageToken)
+	s.Equal(10*time.Second, s.workerHandler.sleepTime)
+	s.Equal(currentPublishedTime, s.workerHandler.cursor.PublishedTime)
+	s.Equal(previousPublishedTime, s.workerHandler.cursor.PreviousPublishedTime)
+}
+
+func (s *WorkerHandlerSuite) TestRestoreCheckpoint_NoCheckpoint() {
+	currentPublishedTime := time.Now().UTC()
+	s.workerHandler.cursor.PublishedTime = currentPublishedTime
+	s.workerHandler.cursor.PreviousPublishedTime = time.Time{}
+	s.workerHandler.cursor.PageToken = ""
+
+	s.mockCheckpointStore.EXPECT().ReadCheckpoint(gomock.Any(), "name").Return(nil, nil)
+
+	s.NoError(s.workerHandler.RestoreCheckpoint(context.Background()))
+
+	s.Equal(currentPublishedTime, s.workerHandler.cursor.PublishedTime)
+	s.Equal("", s.workerHandler.cursor.PageToken)
+}
+
+func (s *WorkerHandlerSuite) TestRestoreCheckpoint_Resume() {
+	s.workerHandler.maxCatchUp = 0
+
+	checkpoint := &storage.Checkpoint{
+		QueryID:               "name",
+		CurrentPublishedTime:  time.Now().UTC().Add(-48 * time.Hour),
+		PreviousPublishedTime: time.Now().UTC().Add(-49 * time.Hour),
+		NextPageToken:         "ABCD",
+	}
+
+	s.mockCheckpointStore.EXPECT().ReadCheckpoint(gomock.Any(), "name").Return(checkpoint, nil)
+
+	s.NoError(s.workerHandler.RestoreCheckpoint(context.Background()))
+
+	s.Equal(checkpoint.CurrentPublishedTime, s.workerHandler.cursor.PublishedTime)
+	s.Equal(checkpoint.PreviousPublishedTime, s.workerHandler.cursor.PreviousPublishedTime)
+	s.Equal("ABCD", s.workerHandler.cursor.PageToken)
+}
+
+func (s *WorkerHandlerSuite) TestRestoreCheckpoint_CappedByMaxCatchUp() {
+	s.workerHandler.maxCatchUp = time.Hour
+	defer func() { s.workerHandler.maxCatchUp = 0 }()
+
+	checkpoint := &storage.Checkpoint{
+		QueryID:               "name",
+		CurrentPublishedTime:  time.Now().UTC().Add(-48 * time.Hour),
+		PreviousPublishedTime: time.Now().UTC().Add(-49 * time.Hour),
+		NextPageToken:         "ABCD",
+	}
+
+	s.mockCheckpointStore.EXPECT().ReadCheckpoint(gomock.Any(), "name").Return(checkpoint, nil)
+
+	s.NoError(s.workerHandler.RestoreCheckpoint(context.Background()))
+
+	s.WithinDuration(time.Now().UTC().Add(-time.Hour), s.workerHandler.cursor.PublishedTime, time.Minute)
+	s.True(s.workerHandler.cursor.PreviousPublishedTime.IsZero())
+	s.Equal("", s.workerHandler.cursor.PageToken)
+}
+
+func (s *WorkerHandlerSuite) TestSaveCheckpoint() {
+	currentPublishedTime := time.Now().UTC()
+	previousPublishedTime := currentPublishedTime.Add(-5 * time.Second)
+
+	s.workerHandler.cursor.PublishedTime = currentPublishedTime
+	s.workerHandler.cursor.PreviousPublishedTime = previousPublishedTime
+	s.workerHandler.cursor.PageToken = "ABCD"
+
+	s.mockCheckpointStore.EXPECT().SaveCheckpoint(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, checkpoint *storage.Checkpoint) error {
+		s.Equal("name", checkpoint.QueryID)
+		s.Equal(currentPublishedTime, checkpoint.CurrentPublishedTime)
+		s.Equal(previousPublishedTime, checkpoint.PreviousPublishedTime)
+		s.Equal("ABCD", checkpoint.NextPageToken)
+		return nil
+	})
+
+	s.NoError(s.workerHandler.SaveCheckpoint(context.Background()))
+}
+
+// Merging the tags of a stored video is left to the store
+func (s *WorkerHandlerSuite) TestExecute_ExistingVideoIsUpsertedWithQueryTag() {
+	s.workerHandler.cursor.PageToken = ""
+
+	currentPublishedTime := time.Now().UTC()
+	s.workerHandler.cursor.PublishedTime = currentPublishedTime
+
+	publishedAt, _ := time.Parse(time.RFC3339, currentPublishedTime.Add(-5*time.Second).Format(time.RFC3339))
+
+	results := &youtube.SearchListResponse{
+		Items: []*youtube.SearchResult{
+			{
+				Id: &youtube.ResourceId{
+					VideoId: "test_video_id",
+				},
+				Snippet: &youtube.SearchResultSnippet{
+					Title:       "test_title",
+					PublishedAt: publishedAt.Format(time.RFC3339),
+				},
+			},
+		},
 	}
 
-	s.mockYoutubeHandler.EXPECT().DoSearchListNextPage("query", []string{"snippet"}, "video", "date", expectedDate, "ABCD", 50).Return(results, nil)
-	s.mockVideoMetadataStore.EXPECT().FindOneMetadataWithVideoID("test_video_id").Return(nil, nil)
-	s.mockVideoMetadataStore.EXPECT().BulkInsertMetadata([]*storage.VideoMetadata{metadataVideo}).Return(nil)
+	taggedMetadata := &storage.VideoMetadata{
+		VideoID:     "test_video_id",
+		Title:       "test_title",
+		PublishedAt: publishedAt,
+		QueryTags:   []string{"tag"},
+	}
 
-	s.workerHandler.Execute()
+	s.mockYoutubeHandler.EXPECT().DoSearchList(gomock.Any(), "query", []string{"snippet"}, "video", "date", currentPublishedTime.Format(time.RFC3339), 50).Return(results, nil)
+	s.mockYoutubeHandler.EXPECT().DoVideosList(gomock.Any(), []string{"test_video_id"}, enrichmentParts).Return(&youtube.VideoListResponse{}, nil)
+	s.mockVideoMetadataStore.EXPECT().BulkUpsertMetadata(gomock.Any(), []*storage.VideoMetadata{taggedMetadata}).
+		Return([]*storage.UpsertResult{{VideoID: "test_video_id", Outcome: storage.UpsertMatched}}, nil)
 
-	s.Equal("", s.workerHandler.nextPageToken)
-	s.Equal(10, s.workerHandler.sleepTime)
-	s.Equal(currentPublishedTime, s.workerHandler.currentPublishedTime)
-	s.Equal(previousPublishedTime, s.workerHandler.previousPublishedTime)
+	s.NoError(s.workerHandler.Execute(context.Background()))
 }
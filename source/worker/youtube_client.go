@@ -0,0 +1,51 @@
+package worker
+
+import (
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+)
+
+// youtubeClient is a youtube handler that follows the key of a shared API key pool.
+// It is embedded by every runner that calls the Youtube API, so quota handling
+// works the same way for all of them.
+type youtubeClient struct {
+	apiKeyPool *youtube_handler.APIKeyPool
+	apiKey     string
+
+	youtubeHandler youtube_handler.YoutubeInterface
+}
+
+// The handler starts without a key when every key is parked, syncAPIKey sets it once one is available
+func newYoutubeClient(apiKeyPool *youtube_handler.APIKeyPool) youtubeClient {
+	apiKey, _ := apiKeyPool.Current()
+
+	return youtubeClient{
+		apiKeyPool:     apiKeyPool,
+		apiKey:         apiKey,
+		youtubeHandler: youtube_handler.NewYoutubeHandler(apiKey),
+	}
+}
+
+// Parks the current key after the API refused it for quota, the next syncAPIKey moves past it
+func (c *youtubeClient) parkAPIKey() {
+	c.apiKeyPool.Exhaust(c.apiKey)
+}
+
+// Picks up the key of the pool, in case it moved on since the last call.
+// Returns a KeysExhaustedError if every key is parked.
+func (c *youtubeClient) syncAPIKey() error {
+	apiKey, err := c.apiKeyPool.Current()
+	if err != nil {
+		return err
+	}
+
+	if apiKey != c.apiKey {
+		c.apiKey = apiKey
+		c.youtubeHandler.UpdateAPIKey(apiKey)
+	}
+	return nil
+}
+
+// Adds the cost of a call made with the current key to the estimate of the pool
+func (c *youtubeClient) spend(units int64) {
+	c.apiKeyPool.Record(c.apiKey, units)
+}
@@ -0,0 +1,253 @@
+package youtube_handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	// The quota reset is on Pacific time, embed the zone in case the host has no tzdata
+	_ "time/tzdata"
+
+	"github.com/ashmeet13/YoutubeDataService/source/common"
+	"google.golang.org/api/googleapi"
+)
+
+// Estimated quota cost of each API call, in units
+const (
+	SearchListCost        = 100
+	VideosListCost        = 1
+	ChannelsListCost      = 1
+	PlaylistItemsListCost = 1
+)
+
+// Most ids a single videos.list or channels.list call accepts
+const (
+	MaxVideosListIDs   = 50
+	MaxChannelsListIDs = 50
+)
+
+// Quotas of the Youtube API reset at midnight Pacific time
+var quotaResetLocation = mustLoadLocation("America/Los_Angeles")
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
+
+// nextQuotaReset returns the first Pacific midnight after now
+func nextQuotaReset(now time.Time) time.Time {
+	pacificNow := now.In(quotaResetLocation)
+	return time.Date(pacificNow.Year(), pacificNow.Month(), pacificNow.Day()+1, 0, 0, 0, 0, quotaResetLocation).UTC()
+}
+
+// KeysExhaustedError is returned by the pool when every key is out of quota
+type KeysExhaustedError struct {
+	Until time.Time
+}
+
+func (e *KeysExhaustedError) Error() string {
+	return fmt.Sprintf("every API key is out of quota until %s", e.Until.Format(time.RFC3339))
+}
+
+type apiKeyState struct {
+	apiKey    string
+	unitsUsed int64
+	requests  int64
+	exhausted bool
+}
+
+/*
+APIKeyPool is the set of API keys shared by every worker.
+
+All workers use the current key. The pool keeps an estimate of the quota units
+spent on every key since the last reset. A key is parked as soon as its estimate
+reaches the daily quota, or a worker reports the API refused it for quota. The
+pool then moves to the next key that is not parked.
+
+Parked keys and the estimates are reset at midnight Pacific time, when the API
+resets the quotas. If every key is parked, Current returns a KeysExhaustedError
+with the time of the reset, so the workers wait instead of spinning through the keys.
+*/
+type APIKeyPool struct {
+	mu sync.Mutex
+
+	apiKeys     []*apiKeyState
+	apiKeyIndex int
+
+	dailyQuota int64
+	resetAt    time.Time
+
+	now func() time.Time
+}
+
+func NewAPIKeyPool(apiKeys []string, dailyQuota int64) *APIKeyPool {
+	pool := &APIKeyPool{
+		apiKeyIndex: 0,
+		dailyQuota:  dailyQuota,
+		now:         time.Now,
+	}
+
+	for _, apiKey := range apiKeys {
+		pool.apiKeys = append(pool.apiKeys, &apiKeyState{apiKey: apiKey})
+	}
+
+	pool.resetAt = nextQuotaReset(pool.now())
+	return pool
+}
+
+// Current returns the key every worker should be using
+func (p *APIKeyPool) Current() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.resetIfDue()
+
+	for i := 0; i < len(p.apiKeys); i++ {
+		index := (p.apiKeyIndex + i) % len(p.apiKeys)
+		if !p.apiKeys[index].exhausted {
+			if index != p.apiKeyIndex {
+				common.GetLogger().WithField("NewAPIKeyIndex", index).WithField("TotalKeys", len(p.apiKeys)).Info("API Key Updated")
+			}
+			p.apiKeyIndex = index
+			return p.apiKeys[index].apiKey, nil
+		}
+	}
+
+	return "", &KeysExhaustedError{Until: p.resetAt}
+}
+
+// Record adds the units of one API call made with apiKey to its estimate. The key
+// is parked once the estimate reaches the daily quota.
+func (p *APIKeyPool) Record(apiKey string, units int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.resetIfDue()
+
+	state := p.find(apiKey)
+	if state == nil {
+		return
+	}
+
+	state.unitsUsed += units
+	state.requests += 1
+
+	if p.dailyQuota > 0 && state.unitsUsed >= p.dailyQuota && !state.exhausted {
+		common.GetLogger().WithField("UnitsUsed", state.unitsUsed).WithField("ResetAt", p.resetAt).Info("API Key reached its estimated daily quota")
+		state.exhausted = true
+	}
+}
+
+// Exhaust parks apiKey until the next quota reset, after the API refused it for quota
+func (p *APIKeyPool) Exhaust(apiKey string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.resetIfDue()
+
+	state := p.find(apiKey)
+	if state == nil || state.exhausted {
+		return
+	}
+
+	common.GetLogger().WithField("UnitsUsed", state.unitsUsed).WithField("ResetAt", p.resetAt).Info("API Key parked until quota reset")
+	state.exhausted = true
+}
+
+// APIKeyStatus is the state of one key, the key itself is masked
+type APIKeyStatus struct {
+	Key       string
+	Current   bool
+	UnitsUsed int64
+	Requests  int64
+	Exhausted bool
+}
+
+// APIKeyPoolSnapshot is the state of the pool at one point in time
+type APIKeyPoolSnapshot struct {
+	DailyQuota int64
+	ResetAt    time.Time
+	Keys       []APIKeyStatus
+}
+
+// Snapshot returns the state of every key, for inspection
+func (p *APIKeyPool) Snapshot() APIKeyPoolSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.resetIfDue()
+
+	snapshot := APIKeyPoolSnapshot{
+		DailyQuota: p.dailyQuota,
+		ResetAt:    p.resetAt,
+		Keys:       []APIKeyStatus{},
+	}
+
+	for index, state := range p.apiKeys {
+		snapshot.Keys = append(snapshot.Keys, APIKeyStatus{
+			Key:       maskAPIKey(state.apiKey),
+			Current:   index == p.apiKeyIndex,
+			UnitsUsed: state.unitsUsed,
+			Requests:  state.requests,
+			Exhausted: state.exhausted,
+		})
+	}
+
+	return snapshot
+}
+
+// Clears the estimates and unparks every key once the quotas have been reset.
+// Callers hold the lock.
+func (p *APIKeyPool) resetIfDue() {
+	now := p.now()
+	if now.Before(p.resetAt) {
+		return
+	}
+
+	for _, state := range p.apiKeys {
+		state.unitsUsed = 0
+		state.requests = 0
+		state.exhausted = false
+	}
+
+	p.resetAt = nextQuotaReset(now)
+	common.GetLogger().WithField("NextResetAt", p.resetAt).Info("API Key quotas reset")
+}
+
+func (p *APIKeyPool) find(apiKey string) *apiKeyState {
+	for _, state := range p.apiKeys {
+		if state.apiKey == apiKey {
+			return state
+		}
+	}
+	return nil
+}
+
+// Keeps the last 4 characters of a key, enough to tell keys apart
+func maskAPIKey(apiKey string) string {
+	if len(apiKey) <= 4 {
+		return "****"
+	}
+	return "****" + apiKey[len(apiKey)-4:]
+}
+
+// IsQuotaExceeded reports if err was returned because the API key ran out of quota
+func IsQuotaExceeded(err error) bool {
+	var apiError *googleapi.Error
+	if !errors.As(err, &apiError) || apiError.Code != http.StatusForbidden {
+		return false
+	}
+
+	for _, item := range apiError.Errors {
+		switch item.Reason {
+		case "quotaExceeded", "dailyLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
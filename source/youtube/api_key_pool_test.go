@@ -0,0 +1,119 @@
+package youtube_handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/api/googleapi"
+)
+
+type APIKeyPoolSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	now  time.Time
+	pool *APIKeyPool
+}
+
+func TestAPIKeyPoolSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyPoolSuite))
+}
+
+func (s *APIKeyPoolSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	// 10:00 in Los Angeles, the quotas reset 14 hours later
+	s.now = time.Date(2022, 6, 1, 17, 0, 0, 0, time.UTC)
+	s.pool = NewAPIKeyPool([]string{"key-one", "key-two"}, 300)
+	s.pool.now = func() time.Time { return s.now }
+	s.pool.resetAt = nextQuotaReset(s.now)
+}
+
+func (s *APIKeyPoolSuite) current() string {
+	apiKey, err := s.pool.Current()
+	s.NoError(err)
+	return apiKey
+}
+
+func (s *APIKeyPoolSuite) TestNextQuotaReset() {
+	s.Equal(time.Date(2022, 6, 2, 7, 0, 0, 0, time.UTC), nextQuotaReset(s.now))
+
+	// Pacific standard time is UTC-8
+	s.Equal(time.Date(2022, 1, 2, 8, 0, 0, 0, time.UTC), nextQuotaReset(time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)))
+}
+
+func (s *APIKeyPoolSuite) TestRecord_ParksKeyAtDailyQuota() {
+	s.pool.Record("key-one", SearchListCost)
+	s.pool.Record("key-one", SearchListCost)
+	s.Equal("key-one", s.current())
+
+	s.pool.Record("key-one", SearchListCost)
+	s.Equal("key-two", s.current())
+
+	snapshot := s.pool.Snapshot()
+	s.Equal(int64(300), snapshot.Keys[0].UnitsUsed)
+	s.Equal(int64(3), snapshot.Keys[0].Requests)
+	s.True(snapshot.Keys[0].Exhausted)
+	s.True(snapshot.Keys[1].Current)
+}
+
+func (s *APIKeyPoolSuite) TestExhaust_WaitsForResetWhenEveryKeyIsParked() {
+	s.pool.Exhaust("key-one")
+	s.Equal("key-two", s.current())
+
+	// A second report of the same key does not move the pool again
+	s.pool.Exhaust("key-one")
+	s.Equal("key-two", s.current())
+
+	s.pool.Exhaust("key-two")
+	_, err := s.pool.Current()
+
+	var keysExhausted *KeysExhaustedError
+	s.True(errors.As(err, &keysExhausted))
+	s.Equal(time.Date(2022, 6, 2, 7, 0, 0, 0, time.UTC), keysExhausted.Until)
+
+	// After the reset the keys are back with fresh estimates
+	s.now = keysExhausted.Until.Add(time.Second)
+	s.Equal("key-two", s.current())
+
+	snapshot := s.pool.Snapshot()
+	s.False(snapshot.Keys[0].Exhausted)
+	s.False(snapshot.Keys[1].Exhausted)
+	s.Equal(time.Date(2022, 6, 3, 7, 0, 0, 0, time.UTC), snapshot.ResetAt)
+}
+
+func (s *APIKeyPoolSuite) TestSnapshot_MasksKeys() {
+	snapshot := s.pool.Snapshot()
+
+	s.Equal(int64(300), snapshot.DailyQuota)
+	s.Equal("****-one", snapshot.Keys[0].Key)
+	s.Equal("****-two", snapshot.Keys[1].Key)
+}
+
+func TestIsQuotaExceeded(t *testing.T) {
+	quotaError := &googleapi.Error{
+		Code:   http.StatusForbidden,
+		Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}},
+	}
+
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{quotaError, true},
+		{fmt.Errorf("search failed: %w", quotaError), true},
+		{&googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "dailyLimitExceeded"}}}, true},
+		{&googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "forbidden"}}}, false},
+		{&googleapi.Error{Code: http.StatusBadRequest, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}}, false},
+		{errors.New("quotaExceeded"), false},
+	}
+
+	for _, testCase := range testCases {
+		require.Equal(t, testCase.expected, IsQuotaExceeded(testCase.err), testCase.err.Error())
+	}
+}
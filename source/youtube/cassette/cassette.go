@@ -0,0 +1,218 @@
+/*
+Package cassette records the responses of the Youtube API to a file and replays
+them, so the service and the tests can run without a network.
+
+A Recorder wraps the transport of the Youtube client and writes every request and
+response pair to the cassette file as it goes. API keys are scrubbed from the
+file. A Replayer serves the pairs back instead of calling the API.
+
+Requests are matched on their method, path and query. The API key and the
+publishedAfter/publishedBefore bounds are left out of the match, since the bounds
+come from the clock of the worker and are different on every run. When several
+pairs match a request they are served in the order they were recorded, and the
+last one keeps being served once they run out, like an API with nothing new.
+*/
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Value the API key is replaced with in the cassette
+const scrubbedValue = "REDACTED"
+
+// Query parameters left out when matching a request
+var ignoredParameters = []string{"key", "publishedAfter", "publishedBefore"}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Interaction is one request and the response of the API to it
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the content of a cassette file
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+func load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := &Cassette{}
+	err = json.Unmarshal(data, cassette)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	return cassette, nil
+}
+
+func (c *Cassette) save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Recorder is a http.RoundTripper that records every round trip to a cassette file
+type Recorder struct {
+	mu sync.Mutex
+
+	path      string
+	cassette  *Cassette
+	transport http.RoundTripper
+}
+
+// NewRecorder records the round trips of transport to the file at path, replacing
+// the file. A nil transport is http.DefaultTransport.
+func NewRecorder(path string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &Recorder{
+		path:      path,
+		cassette:  &Cassette{Interactions: []*Interaction{}},
+		transport: transport,
+	}
+}
+
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := r.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: Request{
+			Method: request.Method,
+			URL:    scrubURL(request.URL),
+		},
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Header:     response.Header,
+			Body:       string(body),
+		},
+	})
+
+	// Saved on every round trip, so the recording survives the service being killed
+	err = r.cassette.save(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Replayer is a http.RoundTripper that serves the round trips of a cassette file
+type Replayer struct {
+	mu sync.Mutex
+
+	cassette *Cassette
+	served   map[*Interaction]bool
+}
+
+// NewReplayer loads the cassette file at path
+func NewReplayer(path string) (*Replayer, error) {
+	cassette, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replayer{
+		cassette: cassette,
+		served:   map[*Interaction]bool{},
+	}, nil
+}
+
+func (r *Replayer) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := matchKey(request.Method, request.URL)
+
+	var interaction *Interaction
+	for _, candidate := range r.cassette.Interactions {
+		candidateURL, err := url.Parse(candidate.Request.URL)
+		if err != nil || matchKey(candidate.Request.Method, candidateURL) != key {
+			continue
+		}
+
+		interaction = candidate
+		if !r.served[candidate] {
+			break
+		}
+	}
+
+	if interaction == nil {
+		return nil, fmt.Errorf("no recorded response for %s %s", request.Method, scrubURL(request.URL))
+	}
+	r.served[interaction] = true
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Response.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       request,
+	}, nil
+}
+
+// Replaces the API key in the query of u
+func scrubURL(u *url.URL) string {
+	scrubbed := *u
+	query := scrubbed.Query()
+	if query.Has("key") {
+		query.Set("key", scrubbedValue)
+	}
+	scrubbed.RawQuery = query.Encode()
+	return scrubbed.String()
+}
+
+// What a request is matched on, the query is encoded with sorted keys
+func matchKey(method string, u *url.URL) string {
+	query := u.Query()
+	for _, parameter := range ignoredParameters {
+		query.Del(parameter)
+	}
+	return method + " " + u.Path + "?" + query.Encode()
+}
@@ -0,0 +1,126 @@
+package cassette
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	youtube_handler "github.com/ashmeet13/YoutubeDataService/source/youtube"
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/fakeyoutube"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CassetteSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	now  time.Time
+	path string
+}
+
+func TestCassetteSuite(t *testing.T) {
+	suite.Run(t, new(CassetteSuite))
+}
+
+func (s *CassetteSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.now = time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	s.path = filepath.Join(s.T().TempDir(), "cassette.json")
+}
+
+// Records a first page and a next page of search results from the fake
+func (s *CassetteSuite) record() {
+	fake := fakeyoutube.NewServer()
+	defer fake.Close()
+
+	for i := 1; i <= 60; i++ {
+		fake.AddVideos(fakeyoutube.Video{
+			ID:          fmt.Sprintf("video%d", i),
+			Title:       fmt.Sprintf("Cricket %d", i),
+			PublishedAt: s.now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	fake.FailNext(fakeyoutube.QuotaExceeded())
+
+	handler := youtube_handler.NewYoutubeHandlerWithTransport("secret-key", fake.Endpoint(), NewRecorder(s.path, nil))
+	publishedAfter := s.now.Add(-2 * time.Hour).Format(time.RFC3339)
+
+	_, err := handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", publishedAfter, 50)
+	s.True(youtube_handler.IsQuotaExceeded(err))
+
+	response, err := handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", publishedAfter, 50)
+	s.NoError(err)
+	s.Len(response.Items, 50)
+
+	response, err = handler.DoSearchListNextPage(context.Background(), "cricket", []string{"snippet"}, "video", "date", publishedAfter, response.NextPageToken, 50)
+	s.NoError(err)
+	s.Len(response.Items, 10)
+}
+
+func (s *CassetteSuite) TestRecord_ScrubsAPIKey() {
+	s.record()
+
+	data, err := os.ReadFile(s.path)
+	s.NoError(err)
+	s.NotContains(string(data), "secret-key")
+	s.Contains(string(data), "key=REDACTED")
+
+	cassette, err := load(s.path)
+	s.NoError(err)
+	s.Len(cassette.Interactions, 3)
+	s.Equal(403, cassette.Interactions[0].Response.StatusCode)
+	s.Equal(200, cassette.Interactions[1].Response.StatusCode)
+}
+
+func (s *CassetteSuite) TestReplay() {
+	s.record()
+
+	replayer, err := NewReplayer(s.path)
+	s.NoError(err)
+
+	// The fake is gone, another key and another window still match the recording
+	handler := youtube_handler.NewYoutubeHandlerWithTransport("other-key", "http://127.0.0.1:1/", replayer)
+	publishedAfter := s.now.Format(time.RFC3339)
+
+	_, err = handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", publishedAfter, 50)
+	s.True(youtube_handler.IsQuotaExceeded(err))
+
+	response, err := handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", publishedAfter, 50)
+	s.NoError(err)
+	s.Len(response.Items, 50)
+	s.Equal("video1", response.Items[0].Id.VideoId)
+
+	nextPageToken := response.NextPageToken
+	response, err = handler.DoSearchListNextPage(context.Background(), "cricket", []string{"snippet"}, "video", "date", publishedAfter, nextPageToken, 50)
+	s.NoError(err)
+	s.Len(response.Items, 10)
+	s.Equal("video51", response.Items[0].Id.VideoId)
+
+	// Once the recording runs out the last matching response is served again
+	response, err = handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", publishedAfter, 50)
+	s.NoError(err)
+	s.Len(response.Items, 50)
+}
+
+func (s *CassetteSuite) TestReplay_Unrecorded() {
+	s.record()
+
+	replayer, err := NewReplayer(s.path)
+	s.NoError(err)
+
+	handler := youtube_handler.NewYoutubeHandlerWithTransport("other-key", "http://127.0.0.1:1/", replayer)
+
+	_, err = handler.DoSearchList(context.Background(), "football", []string{"snippet"}, "video", "date", s.now.Format(time.RFC3339), 50)
+	s.Error(err)
+	s.Contains(err.Error(), "no recorded response")
+}
+
+func (s *CassetteSuite) TestNewReplayer_MissingFile() {
+	_, err := NewReplayer(s.path)
+	s.Error(err)
+}
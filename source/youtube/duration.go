@@ -0,0 +1,36 @@
+package youtube_handler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Durations of contentDetails are ISO 8601 durations such as PT4M13S, or P1DT2H for long streams
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseISODuration parses the duration of a video as returned by videos.list
+func ParseISODuration(duration string) (time.Duration, error) {
+	matches := isoDurationPattern.FindStringSubmatch(duration)
+	if matches == nil || duration == "P" || duration == "PT" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", duration)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+
+	var parsed time.Duration
+	for i, unit := range units {
+		if matches[i+1] == "" {
+			continue
+		}
+
+		value, err := strconv.ParseInt(matches[i+1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", duration)
+		}
+		parsed += time.Duration(value) * unit
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,32 @@
+package youtube_handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseISODuration(t *testing.T) {
+	testCases := map[string]time.Duration{
+		"PT4M13S":  4*time.Minute + 13*time.Second,
+		"PT1H":     time.Hour,
+		"PT15S":    15 * time.Second,
+		"P1DT2H3M": 26*time.Hour + 3*time.Minute,
+		"P1W":      7 * 24 * time.Hour,
+		"P0D":      0,
+	}
+
+	for duration, expected := range testCases {
+		parsed, err := ParseISODuration(duration)
+		require.NoError(t, err, duration)
+		require.Equal(t, expected, parsed, duration)
+	}
+}
+
+func TestParseISODuration_Invalid(t *testing.T) {
+	for _, duration := range []string{"", "P", "PT", "4M13S", "PT4.5S", "PTM"} {
+		_, err := ParseISODuration(duration)
+		require.Error(t, err, duration)
+	}
+}
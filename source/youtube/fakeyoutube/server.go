@@ -0,0 +1,675 @@
+/*
+Package fakeyoutube is a local fake of the Youtube Data API, for tests that need
+the real YoutubeHandler to make its requests.
+
+The fake serves search.list, videos.list and the uploads playlists of
+playlistItems.list from a script of videos, and channels.list from a script of
+channels. Search results are filtered and paginated the way the API does it, so a
+worker can be run against it end to end. Errors, such as quotaExceeded or a 5xx, can be queued
+to fail the next requests.
+
+	fake := fakeyoutube.NewServer()
+	defer fake.Close()
+
+	fake.AddVideos(fakeyoutube.Video{ID: "abc", Title: "Cricket", PublishedAt: time.Now()})
+	handler := youtube_handler.NewYoutubeHandlerWithEndpoint("key", fake.Endpoint())
+*/
+package fakeyoutube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// Path the API is served under, the same as the real API
+const basePath = "/youtube/v3/"
+
+// Page sizes of search.list, the same as the real API
+const (
+	defaultMaxResults = 5
+	maxMaxResults     = 50
+)
+
+// Video is one scripted video
+type Video struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	ChannelID    string    `json:"channel_id"`
+	ChannelTitle string    `json:"channel_title"`
+	PublishedAt  time.Time `json:"published_at"`
+
+	// Only served by videos.list
+	ViewCount       uint64        `json:"view_count"`
+	LikeCount       uint64        `json:"like_count"`
+	CommentCount    uint64        `json:"comment_count"`
+	Duration        time.Duration `json:"duration"`
+	Tags            []string      `json:"tags"`
+	CategoryID      string        `json:"category_id"`
+	DefaultLanguage string        `json:"default_language"`
+}
+
+// Channel is one scripted channel, only served by channels.list
+type Channel struct {
+	ID              string `json:"id"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	Country         string `json:"country"`
+	SubscriberCount uint64 `json:"subscriber_count"`
+}
+
+// Request is a request received by the fake
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+// Failure is an error response the fake returns instead of serving a request
+type Failure struct {
+	Status  int
+	Reason  string
+	Message string
+}
+
+// QuotaExceeded is the response of the API when the key is out of quota
+func QuotaExceeded() Failure {
+	return Failure{
+		Status:  http.StatusForbidden,
+		Reason:  "quotaExceeded",
+		Message: "The request cannot be completed because you have exceeded your quota.",
+	}
+}
+
+// BackendError is the response of the API when it fails on its side
+func BackendError() Failure {
+	return Failure{
+		Status:  http.StatusServiceUnavailable,
+		Reason:  "backendError",
+		Message: "Backend Error",
+	}
+}
+
+// Server is the fake API. NewServer starts it on a local port, a zero Server can
+// also be served through Handler.
+type Server struct {
+	mu sync.Mutex
+
+	videos   []Video
+	channels []Channel
+	failures []Failure
+	requests []Request
+
+	server *httptest.Server
+}
+
+// NewServer starts a fake with no videos, Close stops it
+func NewServer() *Server {
+	s := &Server{}
+	s.server = httptest.NewServer(s.Handler())
+	return s
+}
+
+// Handler serves the fake, for callers that run their own http server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(basePath+"search", s.handleSearchList)
+	mux.HandleFunc(basePath+"videos", s.handleVideosList)
+	mux.HandleFunc(basePath+"channels", s.handleChannelsList)
+	mux.HandleFunc(basePath+"playlistItems", s.handlePlaylistItemsList)
+	return mux
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Endpoint is the URL to pass to option.WithEndpoint, the client adds basePath itself
+func (s *Server) Endpoint() string {
+	return s.server.URL + "/"
+}
+
+// AddVideos adds videos to the script, they are found by the next requests
+func (s *Server) AddVideos(videos ...Video) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.videos = append(s.videos, videos...)
+}
+
+// AddChannels adds channels to the script, they are found by the next requests
+func (s *Server) AddChannels(channels ...Channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.channels = append(s.channels, channels...)
+}
+
+// FailNext makes the next requests fail, one failure per request in order
+func (s *Server) FailNext(failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failures...)
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request{}, s.requests...)
+}
+
+// Records the request and pops the next failure if one is queued
+func (s *Server) receive(r *http.Request) *Failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query()})
+
+	if len(s.failures) == 0 {
+		return nil
+	}
+
+	failure := s.failures[0]
+	s.failures = s.failures[1:]
+	return &failure
+}
+
+/*
+Serves search.list. The parameters follow the API -
+
+ 1. q - terms separated by |, a video matches if its title or description contains any of them
+ 2. publishedAfter (inclusive) and publishedBefore (exclusive) in RFC3339
+ 3. order - date returns the most recent first, anything else keeps the script order
+ 4. maxResults and pageToken - pages of at most 50 results
+*/
+func (s *Server) handleSearchList(w http.ResponseWriter, r *http.Request) {
+	if failure := s.receive(r); failure != nil {
+		writeFailure(w, *failure)
+		return
+	}
+
+	query := r.URL.Query()
+
+	if query.Get("key") == "" {
+		writeFailure(w, Failure{Status: http.StatusForbidden, Reason: "forbidden", Message: "The request is missing a valid API key."})
+		return
+	}
+
+	publishedAfter, err := parseTimeParameter(query, "publishedAfter")
+	if err != nil {
+		writeFailure(w, invalidParameter(err))
+		return
+	}
+
+	publishedBefore, err := parseTimeParameter(query, "publishedBefore")
+	if err != nil {
+		writeFailure(w, invalidParameter(err))
+		return
+	}
+
+	offset, maxResults, failure := parsePage(query)
+	if failure != nil {
+		writeFailure(w, *failure)
+		return
+	}
+
+	terms := []string{}
+	for _, term := range strings.Split(query.Get("q"), "|") {
+		if term = strings.ToLower(strings.TrimSpace(term)); term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	matches := []Video{}
+	for _, video := range s.snapshot() {
+		if !publishedAfter.IsZero() && video.PublishedAt.Before(publishedAfter) {
+			continue
+		}
+		if !publishedBefore.IsZero() && !video.PublishedAt.Before(publishedBefore) {
+			continue
+		}
+		if !matchesTerms(video, terms) {
+			continue
+		}
+		matches = append(matches, video)
+	}
+
+	if query.Get("order") == "date" {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].PublishedAt.After(matches[j].PublishedAt)
+		})
+	}
+
+	page, nextPageToken, prevPageToken := paginate(matches, offset, maxResults)
+
+	response := &youtube.SearchListResponse{
+		Kind:          "youtube#searchListResponse",
+		PageInfo:      &youtube.PageInfo{TotalResults: int64(len(matches)), ResultsPerPage: int64(maxResults)},
+		Items:         []*youtube.SearchResult{},
+		NextPageToken: nextPageToken,
+		PrevPageToken: prevPageToken,
+	}
+	for _, video := range page {
+		response.Items = append(response.Items, searchResult(video))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Serves videos.list for a comma separated list of ids, unknown ids are left out
+func (s *Server) handleVideosList(w http.ResponseWriter, r *http.Request) {
+	if failure := s.receive(r); failure != nil {
+		writeFailure(w, *failure)
+		return
+	}
+
+	query := r.URL.Query()
+
+	if query.Get("key") == "" {
+		writeFailure(w, Failure{Status: http.StatusForbidden, Reason: "forbidden", Message: "The request is missing a valid API key."})
+		return
+	}
+
+	ids := listIDs(query)
+	if len(ids) > maxMaxResults {
+		writeFailure(w, invalidParameter(fmt.Errorf("at most %d ids can be requested", maxMaxResults)))
+		return
+	}
+
+	videos := map[string]Video{}
+	for _, video := range s.snapshot() {
+		videos[video.ID] = video
+	}
+
+	response := &youtube.VideoListResponse{
+		Kind:  "youtube#videoListResponse",
+		Items: []*youtube.Video{},
+	}
+
+	for _, id := range ids {
+		video, ok := videos[id]
+		if !ok {
+			continue
+		}
+
+		response.Items = append(response.Items, &youtube.Video{
+			Kind:    "youtube#video",
+			Id:      video.ID,
+			Snippet: videoSnippet(video),
+			ContentDetails: &youtube.VideoContentDetails{
+				Duration: isoDuration(video.Duration),
+			},
+			Statistics: &youtube.VideoStatistics{
+				ViewCount:    video.ViewCount,
+				LikeCount:    video.LikeCount,
+				CommentCount: video.CommentCount,
+			},
+		})
+	}
+	response.PageInfo = &youtube.PageInfo{TotalResults: int64(len(response.Items)), ResultsPerPage: int64(len(response.Items))}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Serves channels.list for a comma separated list of ids, unknown ids are left out
+func (s *Server) handleChannelsList(w http.ResponseWriter, r *http.Request) {
+	if failure := s.receive(r); failure != nil {
+		writeFailure(w, *failure)
+		return
+	}
+
+	query := r.URL.Query()
+
+	if query.Get("key") == "" {
+		writeFailure(w, Failure{Status: http.StatusForbidden, Reason: "forbidden", Message: "The request is missing a valid API key."})
+		return
+	}
+
+	ids := listIDs(query)
+	if len(ids) > maxMaxResults {
+		writeFailure(w, invalidParameter(fmt.Errorf("at most %d ids can be requested", maxMaxResults)))
+		return
+	}
+
+	s.mu.Lock()
+	channels := map[string]Channel{}
+	for _, channel := range s.channels {
+		channels[channel.ID] = channel
+	}
+	s.mu.Unlock()
+
+	response := &youtube.ChannelListResponse{
+		Kind:  "youtube#channelListResponse",
+		Items: []*youtube.Channel{},
+	}
+
+	for _, id := range ids {
+		channel, ok := channels[id]
+		if !ok {
+			continue
+		}
+
+		response.Items = append(response.Items, &youtube.Channel{
+			Kind: "youtube#channel",
+			Id:   channel.ID,
+			Snippet: &youtube.ChannelSnippet{
+				Title:       channel.Title,
+				Description: channel.Description,
+				Country:     channel.Country,
+				Thumbnails: &youtube.ThumbnailDetails{
+					Default: &youtube.Thumbnail{Url: fmt.Sprintf("https://yt3.ggpht.com/%s/default.jpg", channel.ID)},
+					Medium:  &youtube.Thumbnail{Url: fmt.Sprintf("https://yt3.ggpht.com/%s/medium.jpg", channel.ID)},
+					High:    &youtube.Thumbnail{Url: fmt.Sprintf("https://yt3.ggpht.com/%s/high.jpg", channel.ID)},
+				},
+			},
+			ContentDetails: &youtube.ChannelContentDetails{
+				RelatedPlaylists: &youtube.ChannelContentDetailsRelatedPlaylists{
+					Uploads: UploadsPlaylistID(channel.ID),
+				},
+			},
+			Statistics: &youtube.ChannelStatistics{
+				SubscriberCount: channel.SubscriberCount,
+			},
+		})
+	}
+	response.PageInfo = &youtube.PageInfo{TotalResults: int64(len(response.Items)), ResultsPerPage: int64(len(response.Items))}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+/*
+Serves playlistItems.list for uploads playlists, the playlist of a channel holds
+the scripted videos of the channel most recent first. Pages follow maxResults and
+pageToken like search.list.
+*/
+func (s *Server) handlePlaylistItemsList(w http.ResponseWriter, r *http.Request) {
+	if failure := s.receive(r); failure != nil {
+		writeFailure(w, *failure)
+		return
+	}
+
+	query := r.URL.Query()
+
+	if query.Get("key") == "" {
+		writeFailure(w, Failure{Status: http.StatusForbidden, Reason: "forbidden", Message: "The request is missing a valid API key."})
+		return
+	}
+
+	offset, maxResults, failure := parsePage(query)
+	if failure != nil {
+		writeFailure(w, *failure)
+		return
+	}
+
+	playlistID := query.Get("playlistId")
+
+	uploads := []Video{}
+	for _, video := range s.snapshot() {
+		if video.ChannelID != "" && UploadsPlaylistID(video.ChannelID) == playlistID {
+			uploads = append(uploads, video)
+		}
+	}
+
+	if len(uploads) == 0 {
+		writeFailure(w, Failure{Status: http.StatusNotFound, Reason: "playlistNotFound", Message: fmt.Sprintf("The playlist %s cannot be found.", playlistID)})
+		return
+	}
+
+	sort.SliceStable(uploads, func(i, j int) bool {
+		return uploads[i].PublishedAt.After(uploads[j].PublishedAt)
+	})
+
+	page, nextPageToken, prevPageToken := paginate(uploads, offset, maxResults)
+
+	response := &youtube.PlaylistItemListResponse{
+		Kind:          "youtube#playlistItemListResponse",
+		PageInfo:      &youtube.PageInfo{TotalResults: int64(len(uploads)), ResultsPerPage: int64(maxResults)},
+		Items:         []*youtube.PlaylistItem{},
+		NextPageToken: nextPageToken,
+		PrevPageToken: prevPageToken,
+	}
+	for position, video := range page {
+		publishedAt := video.PublishedAt.UTC().Format(time.RFC3339)
+
+		response.Items = append(response.Items, &youtube.PlaylistItem{
+			Kind: "youtube#playlistItem",
+			Id:   playlistID + video.ID,
+			Snippet: &youtube.PlaylistItemSnippet{
+				Title:                  video.Title,
+				Description:            video.Description,
+				ChannelId:              video.ChannelID,
+				ChannelTitle:           video.ChannelTitle,
+				VideoOwnerChannelId:    video.ChannelID,
+				VideoOwnerChannelTitle: video.ChannelTitle,
+				PlaylistId:             playlistID,
+				Position:               int64(offset + position),
+				PublishedAt:            publishedAt,
+				ResourceId:             &youtube.ResourceId{Kind: "youtube#video", VideoId: video.ID},
+				Thumbnails:             thumbnails(video),
+			},
+			ContentDetails: &youtube.PlaylistItemContentDetails{
+				VideoId:          video.ID,
+				VideoPublishedAt: publishedAt,
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// UploadsPlaylistID is the id of the uploads playlist of a channel, built the way the real API does
+func UploadsPlaylistID(channelID string) string {
+	return "UU" + strings.TrimPrefix(channelID, "UC")
+}
+
+// Ids of a list request, either repeated or comma separated like the API accepts them
+func listIDs(query url.Values) []string {
+	ids := []string{}
+	for _, value := range query["id"] {
+		ids = append(ids, strings.Split(value, ",")...)
+	}
+	return ids
+}
+
+func (s *Server) snapshot() []Video {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Video{}, s.videos...)
+}
+
+func matchesTerms(video Video, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+
+	text := strings.ToLower(video.Title + " " + video.Description)
+	for _, term := range terms {
+		if strings.Contains(text, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func searchResult(video Video) *youtube.SearchResult {
+	return &youtube.SearchResult{
+		Kind: "youtube#searchResult",
+		Id:   &youtube.ResourceId{Kind: "youtube#video", VideoId: video.ID},
+		Snippet: &youtube.SearchResultSnippet{
+			Title:        video.Title,
+			Description:  video.Description,
+			ChannelId:    video.ChannelID,
+			ChannelTitle: video.ChannelTitle,
+			PublishedAt:  video.PublishedAt.UTC().Format(time.RFC3339),
+			Thumbnails:   thumbnails(video),
+		},
+	}
+}
+
+func videoSnippet(video Video) *youtube.VideoSnippet {
+	return &youtube.VideoSnippet{
+		Title:           video.Title,
+		Description:     video.Description,
+		ChannelId:       video.ChannelID,
+		ChannelTitle:    video.ChannelTitle,
+		PublishedAt:     video.PublishedAt.UTC().Format(time.RFC3339),
+		Thumbnails:      thumbnails(video),
+		Tags:            video.Tags,
+		CategoryId:      video.CategoryID,
+		DefaultLanguage: video.DefaultLanguage,
+	}
+}
+
+// Formats d the way contentDetails does, e.g. PT4M13S
+func isoDuration(d time.Duration) string {
+	if d <= 0 {
+		return "P0D"
+	}
+
+	duration := "PT"
+	if hours := int64(d / time.Hour); hours > 0 {
+		duration += fmt.Sprintf("%dH", hours)
+	}
+	if minutes := int64(d % time.Hour / time.Minute); minutes > 0 {
+		duration += fmt.Sprintf("%dM", minutes)
+	}
+	if seconds := int64(d % time.Minute / time.Second); seconds > 0 {
+		duration += fmt.Sprintf("%dS", seconds)
+	}
+	return duration
+}
+
+func thumbnails(video Video) *youtube.ThumbnailDetails {
+	return &youtube.ThumbnailDetails{
+		Default: &youtube.Thumbnail{Url: fmt.Sprintf("https://i.ytimg.com/vi/%s/default.jpg", video.ID)},
+		High:    &youtube.Thumbnail{Url: fmt.Sprintf("https://i.ytimg.com/vi/%s/hqdefault.jpg", video.ID)},
+	}
+}
+
+func parseTimeParameter(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return parsed, nil
+}
+
+// Reads maxResults and pageToken, pages are at most 50 results like the real API
+func parsePage(query url.Values) (int, int, *Failure) {
+	var err error
+
+	maxResults := defaultMaxResults
+	if maxResultsString := query.Get("maxResults"); maxResultsString != "" {
+		maxResults, err = strconv.Atoi(maxResultsString)
+		if err != nil || maxResults < 0 || maxResults > maxMaxResults {
+			failure := invalidParameter(fmt.Errorf("invalid maxResults %q", maxResultsString))
+			return 0, 0, &failure
+		}
+	}
+
+	offset := 0
+	if token := query.Get("pageToken"); token != "" {
+		offset, err = parsePageToken(token)
+		if err != nil {
+			return 0, 0, &Failure{Status: http.StatusBadRequest, Reason: "invalidPageToken", Message: err.Error()}
+		}
+	}
+
+	return offset, maxResults, nil
+}
+
+// Returns the page of videos at offset and the tokens of the pages around it
+func paginate(videos []Video, offset int, maxResults int) ([]Video, string, string) {
+	end := offset + maxResults
+	if end > len(videos) {
+		end = len(videos)
+	}
+
+	page := []Video{}
+	if offset < end {
+		page = videos[offset:end]
+	}
+
+	var nextPageToken, prevPageToken string
+	if end < len(videos) {
+		nextPageToken = pageToken(end)
+	}
+	if offset > 0 {
+		prevPageToken = pageToken(offset - maxResults)
+	}
+
+	return page, nextPageToken, prevPageToken
+}
+
+// Page tokens are the offset of the page, opaque to the client like the real ones
+func pageToken(offset int) string {
+	if offset < 0 {
+		offset = 0
+	}
+	return "PAGE" + strconv.Itoa(offset)
+}
+
+func parsePageToken(token string) (int, error) {
+	offset, err := strconv.Atoi(strings.TrimPrefix(token, "PAGE"))
+	if err != nil || !strings.HasPrefix(token, "PAGE") || offset < 0 {
+		return 0, fmt.Errorf("invalid page token %q", token)
+	}
+	return offset, nil
+}
+
+func invalidParameter(err error) Failure {
+	return Failure{Status: http.StatusBadRequest, Reason: "invalidParameter", Message: err.Error()}
+}
+
+type errorItem struct {
+	Domain  string `json:"domain"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+type errorBody struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Errors  []errorItem `json:"errors"`
+}
+
+// Writes the failure in the error format of Google APIs, so googleapi.CheckResponse parses it
+func writeFailure(w http.ResponseWriter, failure Failure) {
+	domain := "youtube"
+	if failure.Reason == "quotaExceeded" {
+		domain = "youtube.quota"
+	}
+
+	writeJSON(w, failure.Status, map[string]errorBody{
+		"error": {
+			Code:    failure.Status,
+			Message: failure.Message,
+			Errors:  []errorItem{{Domain: domain, Reason: failure.Reason, Message: failure.Message}},
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
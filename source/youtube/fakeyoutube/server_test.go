@@ -0,0 +1,84 @@
+package fakeyoutube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestVideosList(t *testing.T) {
+	fake := NewServer()
+	defer fake.Close()
+
+	publishedAt := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	fake.AddVideos(
+		Video{ID: "abc", Title: "Cricket", ChannelID: "channel", PublishedAt: publishedAt, ViewCount: 10, LikeCount: 2, Duration: time.Hour + 13*time.Second},
+		Video{ID: "def", Title: "Football", PublishedAt: publishedAt},
+	)
+
+	service, err := youtube.NewService(context.Background(), option.WithAPIKey("abcd"), option.WithEndpoint(fake.Endpoint()))
+	require.NoError(t, err)
+
+	response, err := service.Videos.List([]string{"snippet", "statistics"}).Id("abc", "unknown").Do()
+	require.NoError(t, err)
+	require.Len(t, response.Items, 1)
+	require.Equal(t, "abc", response.Items[0].Id)
+	require.Equal(t, "channel", response.Items[0].Snippet.ChannelId)
+	require.Equal(t, "2022-09-01T12:00:00Z", response.Items[0].Snippet.PublishedAt)
+	require.Equal(t, uint64(10), response.Items[0].Statistics.ViewCount)
+	require.Equal(t, uint64(2), response.Items[0].Statistics.LikeCount)
+	require.Equal(t, "PT1H13S", response.Items[0].ContentDetails.Duration)
+
+	requests := fake.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "/youtube/v3/videos", requests[0].Path)
+}
+
+func TestChannelsList(t *testing.T) {
+	fake := NewServer()
+	defer fake.Close()
+
+	fake.AddChannels(Channel{ID: "UCchannel", Title: "Cricket", Country: "IN", SubscriberCount: 1000})
+
+	service, err := youtube.NewService(context.Background(), option.WithAPIKey("abcd"), option.WithEndpoint(fake.Endpoint()))
+	require.NoError(t, err)
+
+	response, err := service.Channels.List([]string{"snippet", "statistics"}).Id("UCchannel", "unknown").Do()
+	require.NoError(t, err)
+	require.Len(t, response.Items, 1)
+	require.Equal(t, "UCchannel", response.Items[0].Id)
+	require.Equal(t, "Cricket", response.Items[0].Snippet.Title)
+	require.Equal(t, "IN", response.Items[0].Snippet.Country)
+	require.Equal(t, uint64(1000), response.Items[0].Statistics.SubscriberCount)
+	require.Equal(t, "UUchannel", response.Items[0].ContentDetails.RelatedPlaylists.Uploads)
+
+	requests := fake.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "/youtube/v3/channels", requests[0].Path)
+}
+
+func TestPlaylistItemsList_UnknownPlaylist(t *testing.T) {
+	fake := NewServer()
+	defer fake.Close()
+
+	service, err := youtube.NewService(context.Background(), option.WithAPIKey("abcd"), option.WithEndpoint(fake.Endpoint()))
+	require.NoError(t, err)
+
+	_, err = service.PlaylistItems.List([]string{"snippet"}).PlaylistId("UUunknown").Do()
+	require.Error(t, err)
+}
+
+func TestSearchList_InvalidPageToken(t *testing.T) {
+	fake := NewServer()
+	defer fake.Close()
+
+	service, err := youtube.NewService(context.Background(), option.WithAPIKey("abcd"), option.WithEndpoint(fake.Endpoint()))
+	require.NoError(t, err)
+
+	_, err = service.Search.List([]string{"snippet"}).PageToken("garbage").Do()
+	require.Error(t, err)
+}
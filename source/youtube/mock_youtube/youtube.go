@@ -5,6 +5,7 @@
 package mock_youtube
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,34 +35,94 @@ func (m *MockYoutubeInterface) EXPECT() *MockYoutubeInterfaceMockRecorder {
 	return m.recorder
 }
 
+// DoChannelsList mocks base method.
+func (m *MockYoutubeInterface) DoChannelsList(arg0 context.Context, arg1, arg2 []string) (*youtube.ChannelListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoChannelsList", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*youtube.ChannelListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoChannelsList indicates an expected call of DoChannelsList.
+func (mr *MockYoutubeInterfaceMockRecorder) DoChannelsList(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoChannelsList", reflect.TypeOf((*MockYoutubeInterface)(nil).DoChannelsList), arg0, arg1, arg2)
+}
+
+// DoPlaylistItemsList mocks base method.
+func (m *MockYoutubeInterface) DoPlaylistItemsList(arg0 context.Context, arg1 string, arg2 []string, arg3 string, arg4 int) (*youtube.PlaylistItemListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoPlaylistItemsList", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*youtube.PlaylistItemListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoPlaylistItemsList indicates an expected call of DoPlaylistItemsList.
+func (mr *MockYoutubeInterfaceMockRecorder) DoPlaylistItemsList(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoPlaylistItemsList", reflect.TypeOf((*MockYoutubeInterface)(nil).DoPlaylistItemsList), arg0, arg1, arg2, arg3, arg4)
+}
+
 // DoSearchList mocks base method.
-func (m *MockYoutubeInterface) DoSearchList(arg0 string, arg1 []string, arg2, arg3, arg4 string, arg5 int) (*youtube.SearchListResponse, error) {
+func (m *MockYoutubeInterface) DoSearchList(arg0 context.Context, arg1 string, arg2 []string, arg3, arg4, arg5 string, arg6 int) (*youtube.SearchListResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DoSearchList", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret := m.ctrl.Call(m, "DoSearchList", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
 	ret0, _ := ret[0].(*youtube.SearchListResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DoSearchList indicates an expected call of DoSearchList.
-func (mr *MockYoutubeInterfaceMockRecorder) DoSearchList(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+func (mr *MockYoutubeInterfaceMockRecorder) DoSearchList(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSearchList", reflect.TypeOf((*MockYoutubeInterface)(nil).DoSearchList), arg0, arg1, arg2, arg3, arg4, arg5)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSearchList", reflect.TypeOf((*MockYoutubeInterface)(nil).DoSearchList), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
+// DoSearchListBetween mocks base method.
+func (m *MockYoutubeInterface) DoSearchListBetween(arg0 context.Context, arg1 string, arg2 []string, arg3, arg4, arg5, arg6, arg7 string, arg8 int) (*youtube.SearchListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoSearchListBetween", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
+	ret0, _ := ret[0].(*youtube.SearchListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoSearchListBetween indicates an expected call of DoSearchListBetween.
+func (mr *MockYoutubeInterfaceMockRecorder) DoSearchListBetween(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSearchListBetween", reflect.TypeOf((*MockYoutubeInterface)(nil).DoSearchListBetween), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 }
 
 // DoSearchListNextPage mocks base method.
-func (m *MockYoutubeInterface) DoSearchListNextPage(arg0 string, arg1 []string, arg2, arg3, arg4, arg5 string, arg6 int) (*youtube.SearchListResponse, error) {
+func (m *MockYoutubeInterface) DoSearchListNextPage(arg0 context.Context, arg1 string, arg2 []string, arg3, arg4, arg5, arg6 string, arg7 int) (*youtube.SearchListResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DoSearchListNextPage", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret := m.ctrl.Call(m, "DoSearchListNextPage", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
 	ret0, _ := ret[0].(*youtube.SearchListResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DoSearchListNextPage indicates an expected call of DoSearchListNextPage.
-func (mr *MockYoutubeInterfaceMockRecorder) DoSearchListNextPage(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+func (mr *MockYoutubeInterfaceMockRecorder) DoSearchListNextPage(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSearchListNextPage", reflect.TypeOf((*MockYoutubeInterface)(nil).DoSearchListNextPage), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+}
+
+// DoVideosList mocks base method.
+func (m *MockYoutubeInterface) DoVideosList(arg0 context.Context, arg1, arg2 []string) (*youtube.VideoListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoVideosList", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*youtube.VideoListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoVideosList indicates an expected call of DoVideosList.
+func (mr *MockYoutubeInterfaceMockRecorder) DoVideosList(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoSearchListNextPage", reflect.TypeOf((*MockYoutubeInterface)(nil).DoSearchListNextPage), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoVideosList", reflect.TypeOf((*MockYoutubeInterface)(nil).DoVideosList), arg0, arg1, arg2)
 }
 
 // UpdateAPIKey mocks base method.
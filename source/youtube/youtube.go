@@ -2,8 +2,10 @@ package youtube_handler
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/ashmeet13/YoutubeDataService/source/common"
+	googleapi_transport "google.golang.org/api/googleapi/transport"
 	"google.golang.org/api/option"
 	"google.golang.org/api/youtube/v3"
 )
@@ -11,16 +13,56 @@ import (
 //go:generate mockgen --destination=./mock_youtube/youtube.go github.com/ashmeet13/YoutubeDataService/source/youtube YoutubeInterface
 type YoutubeInterface interface {
 	UpdateAPIKey(apiKey string) error
-	DoSearchList(query string, parts []string, resourceType string, orderBy string, publishedAfter string, maxResults int) (*youtube.SearchListResponse, error)
-	DoSearchListNextPage(query string, parts []string, resourceType string, orderBy string, publishedAfter string, nextPageToken string, maxResults int) (*youtube.SearchListResponse, error)
+	DoSearchList(ctx context.Context, query string, parts []string, resourceType string, orderBy string, publishedAfter string, maxResults int) (*youtube.SearchListResponse, error)
+	DoSearchListNextPage(ctx context.Context, query string, parts []string, resourceType string, orderBy string, publishedAfter string, nextPageToken string, maxResults int) (*youtube.SearchListResponse, error)
+	DoSearchListBetween(ctx context.Context, query string, parts []string, resourceType string, orderBy string, publishedAfter string, publishedBefore string, nextPageToken string, maxResults int) (*youtube.SearchListResponse, error)
+	DoVideosList(ctx context.Context, ids []string, parts []string) (*youtube.VideoListResponse, error)
+	DoChannelsList(ctx context.Context, ids []string, parts []string) (*youtube.ChannelListResponse, error)
+	DoPlaylistItemsList(ctx context.Context, playlistID string, parts []string, pageToken string, maxResults int) (*youtube.PlaylistItemListResponse, error)
 }
 
 type YoutubeHandler struct {
 	youtubeClient *youtube.Service
+
+	// Empty for the real API
+	endpoint string
+
+	// Nil for the default transport
+	transport http.RoundTripper
+}
+
+// Endpoint and transport used by NewYoutubeHandler, empty for the real API
+var (
+	defaultEndpoint  string
+	defaultTransport http.RoundTripper
+)
+
+// SetDefaultEndpoint points the handlers created from now on at another server
+// implementing the API, such as a local fake. Called once on startup.
+func SetDefaultEndpoint(endpoint string) {
+	defaultEndpoint = endpoint
+}
+
+// SetDefaultTransport makes the handlers created from now on send their requests
+// through transport, such as a cassette recorder. Called once on startup.
+func SetDefaultTransport(transport http.RoundTripper) {
+	defaultTransport = transport
 }
 
 func NewYoutubeHandler(apiKey string) *YoutubeHandler {
-	youtubeClient, err := youtube.NewService(context.Background(), option.WithAPIKey(apiKey))
+	return NewYoutubeHandlerWithTransport(apiKey, defaultEndpoint, defaultTransport)
+}
+
+// NewYoutubeHandlerWithEndpoint creates a handler that sends its requests to endpoint,
+// e.g. http://localhost:8080/
+func NewYoutubeHandlerWithEndpoint(apiKey string, endpoint string) *YoutubeHandler {
+	return NewYoutubeHandlerWithTransport(apiKey, endpoint, nil)
+}
+
+// NewYoutubeHandlerWithTransport creates a handler that sends its requests to endpoint
+// through transport, either can be empty for the defaults
+func NewYoutubeHandlerWithTransport(apiKey string, endpoint string, transport http.RoundTripper) *YoutubeHandler {
+	youtubeClient, err := newYoutubeService(apiKey, endpoint, transport)
 
 	if err != nil {
 		panic("Failed to create youtube service client")
@@ -28,12 +70,31 @@ func NewYoutubeHandler(apiKey string) *YoutubeHandler {
 
 	return &YoutubeHandler{
 		youtubeClient: youtubeClient,
+		endpoint:      endpoint,
+		transport:     transport,
+	}
+}
+
+func newYoutubeService(apiKey string, endpoint string, transport http.RoundTripper) (*youtube.Service, error) {
+	options := []option.ClientOption{option.WithAPIKey(apiKey)}
+
+	// A custom http client skips the transport that adds the key, so it is added here
+	if transport != nil {
+		options = []option.ClientOption{option.WithHTTPClient(&http.Client{
+			Transport: &googleapi_transport.APIKey{Key: apiKey, Transport: transport},
+		})}
+	}
+
+	if endpoint != "" {
+		options = append(options, option.WithEndpoint(endpoint))
 	}
+
+	return youtube.NewService(context.Background(), options...)
 }
 
 func (h *YoutubeHandler) UpdateAPIKey(apiKey string) error {
 	logger := common.GetLogger()
-	youtubeClient, err := youtube.NewService(context.Background(), option.WithAPIKey(apiKey))
+	youtubeClient, err := newYoutubeService(apiKey, h.endpoint, h.transport)
 
 	if err != nil {
 		logger.WithError(err).Error("failed to create youtube service client")
@@ -43,11 +104,11 @@ func (h *YoutubeHandler) UpdateAPIKey(apiKey string) error {
 	return nil
 }
 
-func (h *YoutubeHandler) DoSearchList(query string, parts []string, resourceType string, orderBy string, publishedAfter string, maxResults int) (*youtube.SearchListResponse, error) {
+func (h *YoutubeHandler) DoSearchList(ctx context.Context, query string, parts []string, resourceType string, orderBy string, publishedAfter string, maxResults int) (*youtube.SearchListResponse, error) {
 	searchRequest := h.youtubeClient.Search.List(parts).Q(query).
 		Type(resourceType).Order(orderBy).PublishedAfter(publishedAfter).MaxResults(int64(maxResults))
 
-	response, err := searchRequest.Do()
+	response, err := searchRequest.Context(ctx).Do()
 
 	if err != nil {
 		return nil, err
@@ -56,11 +117,72 @@ func (h *YoutubeHandler) DoSearchList(query string, parts []string, resourceType
 	return response, nil
 }
 
-func (h *YoutubeHandler) DoSearchListNextPage(query string, parts []string, resourceType string, orderBy string, publishedAfter string, nextPageToken string, maxResults int) (*youtube.SearchListResponse, error) {
+func (h *YoutubeHandler) DoSearchListNextPage(ctx context.Context, query string, parts []string, resourceType string, orderBy string, publishedAfter string, nextPageToken string, maxResults int) (*youtube.SearchListResponse, error) {
 	searchRequest := h.youtubeClient.Search.List(parts).Q(query).
 		Type(resourceType).Order(orderBy).PublishedAfter(publishedAfter).MaxResults(int64(maxResults)).PageToken(nextPageToken)
 
-	response, err := searchRequest.Do()
+	response, err := searchRequest.Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Searches for resources published between publishedAfter and publishedBefore, nextPageToken can be empty for the first page
+func (h *YoutubeHandler) DoSearchListBetween(ctx context.Context, query string, parts []string, resourceType string, orderBy string, publishedAfter string, publishedBefore string, nextPageToken string, maxResults int) (*youtube.SearchListResponse, error) {
+	searchRequest := h.youtubeClient.Search.List(parts).Q(query).
+		Type(resourceType).Order(orderBy).PublishedAfter(publishedAfter).PublishedBefore(publishedBefore).MaxResults(int64(maxResults))
+
+	if nextPageToken != "" {
+		searchRequest = searchRequest.PageToken(nextPageToken)
+	}
+
+	response, err := searchRequest.Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Fetches the videos with the given ids, at most MaxVideosListIDs at a time. Unknown ids are left out of the response.
+func (h *YoutubeHandler) DoVideosList(ctx context.Context, ids []string, parts []string) (*youtube.VideoListResponse, error) {
+	videosRequest := h.youtubeClient.Videos.List(parts).Id(ids...)
+
+	response, err := videosRequest.Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Fetches the channels with the given ids, at most MaxChannelsListIDs at a time. Unknown ids are left out of the response.
+func (h *YoutubeHandler) DoChannelsList(ctx context.Context, ids []string, parts []string) (*youtube.ChannelListResponse, error) {
+	channelsRequest := h.youtubeClient.Channels.List(parts).Id(ids...)
+
+	response, err := channelsRequest.Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Fetches a page of the items of a playlist, pageToken can be empty for the first page
+func (h *YoutubeHandler) DoPlaylistItemsList(ctx context.Context, playlistID string, parts []string, pageToken string, maxResults int) (*youtube.PlaylistItemListResponse, error) {
+	playlistItemsRequest := h.youtubeClient.PlaylistItems.List(parts).PlaylistId(playlistID).MaxResults(int64(maxResults))
+
+	if pageToken != "" {
+		playlistItemsRequest = playlistItemsRequest.PageToken(pageToken)
+	}
+
+	response, err := playlistItemsRequest.Context(ctx).Do()
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,182 @@
+package youtube_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ashmeet13/YoutubeDataService/source/youtube/fakeyoutube"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/api/googleapi"
+)
+
+type YoutubeHandlerSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	now     time.Time
+	fake    *fakeyoutube.Server
+	handler *YoutubeHandler
+}
+
+func TestYoutubeHandlerSuite(t *testing.T) {
+	suite.Run(t, new(YoutubeHandlerSuite))
+}
+
+func (s *YoutubeHandlerSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+
+	s.now = time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	s.fake = fakeyoutube.NewServer()
+	s.handler = NewYoutubeHandlerWithEndpoint("abcd", s.fake.Endpoint())
+}
+
+func (s *YoutubeHandlerSuite) TearDownTest() {
+	s.fake.Close()
+}
+
+// Adds count cricket videos, one a minute before now
+func (s *YoutubeHandlerSuite) addVideos(count int) {
+	for i := 1; i <= count; i++ {
+		s.fake.AddVideos(fakeyoutube.Video{
+			ID:          fmt.Sprintf("video%d", i),
+			Title:       fmt.Sprintf("Cricket %d", i),
+			PublishedAt: s.now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+}
+
+func (s *YoutubeHandlerSuite) TestDoSearchList() {
+	s.addVideos(3)
+	s.fake.AddVideos(fakeyoutube.Video{ID: "tennis", Title: "Tennis", PublishedAt: s.now})
+
+	response, err := s.handler.DoSearchList(context.Background(), "cricket|football", []string{"snippet"}, "video", "date", s.now.Add(-2*time.Minute).Format(time.RFC3339), 50)
+	s.NoError(err)
+	s.Len(response.Items, 2)
+	s.Equal("video1", response.Items[0].Id.VideoId)
+	s.Equal("video2", response.Items[1].Id.VideoId)
+	s.Equal(s.now.Add(-time.Minute).Format(time.RFC3339), response.Items[0].Snippet.PublishedAt)
+	s.Empty(response.NextPageToken)
+
+	requests := s.fake.Requests()
+	s.Len(requests, 1)
+	s.Equal("/youtube/v3/search", requests[0].Path)
+	s.Equal("abcd", requests[0].Query.Get("key"))
+	s.Equal("cricket|football", requests[0].Query.Get("q"))
+	s.Equal("snippet", requests[0].Query.Get("part"))
+	s.Equal("video", requests[0].Query.Get("type"))
+	s.Equal("date", requests[0].Query.Get("order"))
+	s.Equal("50", requests[0].Query.Get("maxResults"))
+}
+
+func (s *YoutubeHandlerSuite) TestDoSearchListNextPage() {
+	s.addVideos(60)
+	publishedAfter := s.now.Add(-time.Hour).Format(time.RFC3339)
+
+	response, err := s.handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", publishedAfter, 50)
+	s.NoError(err)
+	s.Len(response.Items, 50)
+	s.NotEmpty(response.NextPageToken)
+
+	response, err = s.handler.DoSearchListNextPage(context.Background(), "cricket", []string{"snippet"}, "video", "date", publishedAfter, response.NextPageToken, 50)
+	s.NoError(err)
+	s.Len(response.Items, 10)
+	s.Equal("video51", response.Items[0].Id.VideoId)
+	s.Empty(response.NextPageToken)
+}
+
+func (s *YoutubeHandlerSuite) TestDoSearchListBetween() {
+	s.addVideos(5)
+
+	response, err := s.handler.DoSearchListBetween(context.Background(), "cricket", []string{"snippet"}, "video", "date",
+		s.now.Add(-4*time.Minute).Format(time.RFC3339), s.now.Add(-2*time.Minute).Format(time.RFC3339), "", 50)
+	s.NoError(err)
+	s.Len(response.Items, 2)
+	s.Equal("video3", response.Items[0].Id.VideoId)
+	s.Equal("video4", response.Items[1].Id.VideoId)
+
+	s.Equal(s.now.Add(-2*time.Minute).Format(time.RFC3339), s.fake.Requests()[0].Query.Get("publishedBefore"))
+}
+
+func (s *YoutubeHandlerSuite) TestDoChannelsList() {
+	s.fake.AddChannels(fakeyoutube.Channel{ID: "channel", Title: "Cricket", SubscriberCount: 1000})
+
+	response, err := s.handler.DoChannelsList(context.Background(), []string{"channel", "unknown"}, []string{"snippet", "statistics"})
+	s.NoError(err)
+	s.Len(response.Items, 1)
+	s.Equal("Cricket", response.Items[0].Snippet.Title)
+	s.Equal(uint64(1000), response.Items[0].Statistics.SubscriberCount)
+
+	requests := s.fake.Requests()
+	s.Len(requests, 1)
+	s.Equal("/youtube/v3/channels", requests[0].Path)
+	s.Equal([]string{"channel", "unknown"}, requests[0].Query["id"])
+	s.Equal([]string{"snippet", "statistics"}, requests[0].Query["part"])
+}
+
+func (s *YoutubeHandlerSuite) TestDoPlaylistItemsList() {
+	for i := 1; i <= 3; i++ {
+		s.fake.AddVideos(fakeyoutube.Video{
+			ID:          fmt.Sprintf("video%d", i),
+			Title:       fmt.Sprintf("Cricket %d", i),
+			ChannelID:   "UCchannel",
+			PublishedAt: s.now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+
+	response, err := s.handler.DoPlaylistItemsList(context.Background(), "UUchannel", []string{"snippet", "contentDetails"}, "", 2)
+	s.NoError(err)
+	s.Len(response.Items, 2)
+	s.Equal("video1", response.Items[0].ContentDetails.VideoId)
+	s.Equal(s.now.Add(-time.Minute).Format(time.RFC3339), response.Items[0].ContentDetails.VideoPublishedAt)
+	s.NotEmpty(response.NextPageToken)
+
+	response, err = s.handler.DoPlaylistItemsList(context.Background(), "UUchannel", []string{"snippet", "contentDetails"}, response.NextPageToken, 2)
+	s.NoError(err)
+	s.Len(response.Items, 1)
+	s.Equal("video3", response.Items[0].ContentDetails.VideoId)
+	s.Empty(response.NextPageToken)
+
+	requests := s.fake.Requests()
+	s.Len(requests, 2)
+	s.Equal("/youtube/v3/playlistItems", requests[0].Path)
+	s.Equal("UUchannel", requests[0].Query.Get("playlistId"))
+	s.Equal("2", requests[0].Query.Get("maxResults"))
+	s.Empty(requests[0].Query.Get("pageToken"))
+}
+
+func (s *YoutubeHandlerSuite) TestQuotaExceeded() {
+	s.fake.FailNext(fakeyoutube.QuotaExceeded())
+
+	_, err := s.handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", s.now.Format(time.RFC3339), 50)
+	s.Error(err)
+	s.True(IsQuotaExceeded(err))
+
+	// Only the next request fails
+	_, err = s.handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", s.now.Format(time.RFC3339), 50)
+	s.NoError(err)
+}
+
+func (s *YoutubeHandlerSuite) TestServerError() {
+	s.fake.FailNext(fakeyoutube.BackendError())
+
+	_, err := s.handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", s.now.Format(time.RFC3339), 50)
+	s.False(IsQuotaExceeded(err))
+
+	var apiError *googleapi.Error
+	s.True(errors.As(err, &apiError))
+	s.Equal(http.StatusServiceUnavailable, apiError.Code)
+	s.Equal("backendError", apiError.Errors[0].Reason)
+}
+
+func (s *YoutubeHandlerSuite) TestUpdateAPIKey_KeepsEndpoint() {
+	s.NoError(s.handler.UpdateAPIKey("edfg"))
+
+	_, err := s.handler.DoSearchList(context.Background(), "cricket", []string{"snippet"}, "video", "date", s.now.Format(time.RFC3339), 50)
+	s.NoError(err)
+	s.Equal("edfg", s.fake.Requests()[0].Query.Get("key"))
+}